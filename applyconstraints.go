@@ -0,0 +1,331 @@
+package mediadevices
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+var errApplyConstraintsUnsupported = errors.New("applying constraints is only supported by the tracks from drivers")
+
+// ApplyConstraints applies the constraints to the live track. When the constraints can be satisfied
+// by the current driver settings, e.g. by downscaling or throttling the frames, the driver is kept
+// running. Otherwise, the driver is reopened with the settings that fit the constraints best.
+// The readers, encoders, and peer connection bindings of the track continue to work, and the encoders
+// are rebuilt transparently when the resolution changes. If the constraints can't be satisfied,
+// an error is returned and the track keeps the previous settings.
+func (track *VideoTrack) ApplyConstraints(constraints MediaTrackConstraints) error {
+	d, ok := track.capture.source.(driver.Driver)
+	if !ok {
+		return errApplyConstraintsUnsupported
+	}
+	recorder, ok := d.(driver.VideoRecorder)
+	if !ok {
+		return errInvalidDriverType
+	}
+
+	selected, err := selectVideoSettings(d, constraints)
+	if err != nil {
+		return err
+	}
+
+	c := track.capture
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recorded := c.recorded
+	if selected.selectedMedia != c.constraints.selectedMedia {
+		recorded, err = reopenVideoDriver(d, recorder, selected.selectedMedia)
+		if err != nil {
+			// Try to restore the previous settings so that the track can keep going
+			previous, restoreErr := reopenVideoDriver(d, recorder, c.constraints.selectedMedia)
+			if restoreErr != nil {
+				logger.Warnf("failed to restore the previous settings: %s", restoreErr)
+				return err
+			}
+			c.replaceRecorded(previous, c.constraints)
+			return err
+		}
+	}
+
+	c.replaceRecorded(recorded, selected)
+	return nil
+}
+
+// replaceRecorded makes the capture record from recorded with the transforms for constraints.
+// mu must be held by the caller.
+func (c *videoCapture) replaceRecorded(recorded video.Reader, constraints MediaTrackConstraints) {
+	c.recorded = recorded
+	c.reader = video.Merge(videoConstraintsTransforms(constraints)...)(recorded)
+	c.constraints = constraints
+	c.generation++
+}
+
+// ApplyConstraints applies the constraints to the live track. When the constraints don't fit the
+// current driver settings, the driver is reopened with the settings that fit the constraints best.
+// The readers, encoders, and peer connection bindings of the track continue to work, and the encoders
+// are rebuilt transparently when the format changes. If the constraints can't be satisfied,
+// an error is returned and the track keeps the previous settings.
+func (track *AudioTrack) ApplyConstraints(constraints MediaTrackConstraints) error {
+	d, ok := track.capture.source.(driver.Driver)
+	if !ok {
+		return errApplyConstraintsUnsupported
+	}
+	recorder, ok := d.(driver.AudioRecorder)
+	if !ok {
+		return errInvalidDriverType
+	}
+
+	_, selected, err := selectBestDriver(driver.FilterID(d.ID()), constraints)
+	if err != nil {
+		return err
+	}
+
+	c := track.capture
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recorded := c.recorded
+	if selected.selectedMedia != c.constraints.selectedMedia {
+		recorded, err = reopenAudioDriver(d, recorder, selected.selectedMedia)
+		if err != nil {
+			// Try to restore the previous settings so that the track can keep going
+			previous, restoreErr := reopenAudioDriver(d, recorder, c.constraints.selectedMedia)
+			if restoreErr != nil {
+				logger.Warnf("failed to restore the previous settings: %s", restoreErr)
+				return err
+			}
+			c.replaceRecorded(previous, c.constraints)
+			return err
+		}
+	}
+
+	c.replaceRecorded(recorded, selected)
+	return nil
+}
+
+// replaceRecorded makes the capture record from recorded. mu must be held by the caller.
+func (c *audioCapture) replaceRecorded(recorded audio.Reader, constraints MediaTrackConstraints) {
+	c.recorded = recorded
+	c.reader = recorded
+	c.constraints = constraints
+	c.generation++
+}
+
+// selectVideoSettings selects the settings of d that fit the constraints best. If none of the
+// settings fits, including the ones that are cropped and downscaled, the settings that can be
+// throttled to the constrained frame rate are selected.
+func selectVideoSettings(d driver.Driver, constraints MediaTrackConstraints) (MediaTrackConstraints, error) {
+	filter := driver.FilterID(d.ID())
+	_, selected, err := selectBestDriver(filter, constraints)
+	if !errors.Is(err, errNotFound) {
+		return selected, err
+	}
+
+	// Look for the settings that are at least as fast as the constrained ones
+	frameRate, ok := floatConstraintValue(constraints.FrameRate)
+	if !ok {
+		return MediaTrackConstraints{}, err
+	}
+	relaxed := constraints
+	relaxed.FrameRate = prop.FloatRanged{Min: frameRate}
+
+	_, selected, err = selectBestDriver(filter, relaxed)
+	if err != nil {
+		return MediaTrackConstraints{}, err
+	}
+	selected.MediaConstraints = constraints.MediaConstraints
+
+	selected.settings.FrameRate = throttledFrameRate(selected)
+	if _, ok := constraints.FitnessDistance(selected.settings); !ok {
+		return MediaTrackConstraints{}, errNotFound
+	}
+	return selected, nil
+}
+
+// videoConstraintsTransforms returns the transforms that convert the frames recorded with the selected
+// settings of constraints to the frames with the settings of constraints
+func videoConstraintsTransforms(constraints MediaTrackConstraints) []video.TransformFunc {
+	var fns []video.TransformFunc
+	selected, settings := constraints.selectedMedia, constraints.settings
+	if settings.Width == 0 && settings.Height == 0 {
+		// The settings are unknown, e.g. the track isn't from a driver
+		return nil
+	}
+
+	width, height := selected.Width, selected.Height
+	if settings.VideoResizeMode() == prop.ResizeModeCropAndScale {
+		width, height = croppedVideoSize(width, height, settings.VideoAspectRatio())
+		if width != selected.Width || height != selected.Height {
+			x, y := (selected.Width-width)/2, (selected.Height-height)/2
+			fns = append(fns, video.Crop(image.Rect(x, y, x+width, y+height)))
+		}
+	}
+
+	if settings.Width != width || settings.Height != height {
+		fns = append(fns, video.Scale(settings.Width, settings.Height, nil))
+	}
+
+	if settings.FrameRate != 0 && settings.FrameRate < selected.FrameRate {
+		fns = append(fns, video.Throttle(settings.FrameRate))
+	}
+
+	return fns
+}
+
+// throttledFrameRate returns the frame rate that the frames recorded with the selected settings of
+// constraints should be throttled to. Frame rates are never increased.
+func throttledFrameRate(constraints MediaTrackConstraints) float32 {
+	selected := constraints.selectedMedia
+	if frameRate, ok := floatConstraintValue(constraints.FrameRate); ok && frameRate > 0 && frameRate < selected.FrameRate {
+		return frameRate
+	}
+	return selected.FrameRate
+}
+
+// resizedVideoSettings returns the settings of the frames that are cropped and downscaled from p to
+// fit c. If the frames don't need to be resized, or can't be, false is returned.
+func resizedVideoSettings(p prop.Media, c prop.MediaConstraints) (prop.Media, bool) {
+	constraints := MediaTrackConstraints{MediaConstraints: c, selectedMedia: p}
+	width, height := scaledVideoSize(constraints)
+	if width == p.Width && height == p.Height {
+		return prop.Media{}, false
+	}
+
+	resized := p
+	resized.Width, resized.Height = width, height
+	// The size is rounded, so the ratio that the frames are cropped to is reported rather than the
+	// rounded one. It's also the ratio that videoConstraintsTransforms crops the frames to.
+	resized.AspectRatio = p.VideoAspectRatio()
+	if aspectRatio, ok := croppedAspectRatio(c); ok {
+		resized.AspectRatio = aspectRatio
+	}
+	resized.ResizeMode = prop.ResizeModeCropAndScale
+	return resized, true
+}
+
+// scaledVideoSize returns the size that the frames recorded with the selected settings of constraints
+// should be cropped and downscaled to. The frames are cropped to the constrained aspect ratio, or to
+// the ratio of the constrained width and height when both of them are constrained. When only one of
+// the width and height is constrained, the aspect ratio is kept. Frames are never upscaled.
+func scaledVideoSize(constraints MediaTrackConstraints) (int, int) {
+	selected := constraints.selectedMedia
+	if selected.Width <= 0 || selected.Height <= 0 {
+		return selected.Width, selected.Height
+	}
+
+	width, hasWidth := intConstraintValue(constraints.Width)
+	height, hasHeight := intConstraintValue(constraints.Height)
+	croppedWidth, croppedHeight := selected.Width, selected.Height
+	if aspectRatio, ok := croppedAspectRatio(constraints.MediaConstraints); ok {
+		croppedWidth, croppedHeight = croppedVideoSize(selected.Width, selected.Height, aspectRatio)
+	}
+
+	switch {
+	case hasWidth && hasHeight:
+	case hasWidth:
+		height = croppedHeight * width / croppedWidth
+	case hasHeight:
+		width = croppedWidth * height / croppedHeight
+	default:
+		return croppedWidth, croppedHeight
+	}
+
+	if width <= 0 || height <= 0 || width > croppedWidth || height > croppedHeight {
+		return selected.Width, selected.Height
+	}
+	return width, height
+}
+
+// croppedVideoSize returns the largest size within width x height that has aspectRatio. The size
+// is rounded to even numbers to keep the chroma samples aligned.
+func croppedVideoSize(width, height int, aspectRatio float32) (int, int) {
+	if width <= 0 || height <= 0 || aspectRatio <= 0 {
+		return width, height
+	}
+
+	switch current := float32(width) / float32(height); {
+	case current > aspectRatio:
+		if cropped := int(float32(height)*aspectRatio+0.5) &^ 1; cropped > 0 {
+			width = cropped
+		}
+	case current < aspectRatio:
+		if cropped := int(float32(width)/aspectRatio+0.5) &^ 1; cropped > 0 {
+			height = cropped
+		}
+	}
+	return width, height
+}
+
+// croppedAspectRatio returns the aspect ratio that the frames should be cropped to for c, which is
+// the ratio of the width and the height if both of them are constrained, or the constrained aspect ratio
+func croppedAspectRatio(c prop.MediaConstraints) (float32, bool) {
+	width, hasWidth := intConstraintValue(c.Width)
+	height, hasHeight := intConstraintValue(c.Height)
+	if hasWidth && hasHeight && width > 0 && height > 0 {
+		return float32(width) / float32(height), true
+	}
+	if aspectRatio, ok := floatConstraintValue(c.AspectRatio); ok && aspectRatio > 0 {
+		return aspectRatio, true
+	}
+	return 0, false
+}
+
+func intConstraintValue(c prop.IntConstraint) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	return c.Value()
+}
+
+func floatConstraintValue(c prop.FloatConstraint) (float32, bool) {
+	if c == nil {
+		return 0, false
+	}
+	return c.Value()
+}
+
+// reopenVideoDriver closes d and records again with p. The driver has to be closed first since
+// most of the devices can't be opened twice. The driver is locked until it records again, so a probe
+// can't close it in between.
+func reopenVideoDriver(d driver.Driver, recorder driver.VideoRecorder, p prop.Media) (video.Reader, error) {
+	ctx := context.Background()
+	unlock, err := lockDriver(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := d.Close(); err != nil {
+		logger.Warnf("failed to close the driver: %s", err)
+	}
+	if err := openDriver(ctx, d); err != nil {
+		return nil, err
+	}
+	return recorder.VideoRecord(p)
+}
+
+// reopenAudioDriver closes d and records again with p. The driver has to be closed first since
+// most of the devices can't be opened twice. The driver is locked until it records again, so a probe
+// can't close it in between.
+func reopenAudioDriver(d driver.Driver, recorder driver.AudioRecorder, p prop.Media) (audio.Reader, error) {
+	ctx := context.Background()
+	unlock, err := lockDriver(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := d.Close(); err != nil {
+		logger.Warnf("failed to close the driver: %s", err)
+	}
+	if err := openDriver(ctx, d); err != nil {
+		return nil, err
+	}
+	return recorder.AudioRecord(p)
+}
@@ -0,0 +1,261 @@
+package mediadevices
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/webrtc/v3"
+)
+
+// recordingVP8EncoderBuilder records the input properties of the encoders that it builds
+type recordingVP8EncoderBuilder struct {
+	fakeVP8EncoderBuilder
+	built []prop.Media
+}
+
+func (b *recordingVP8EncoderBuilder) BuildVideoEncoder(r video.Reader, p prop.Media) (codec.ReadCloser, error) {
+	b.built = append(b.built, p)
+	return b.fakeVP8EncoderBuilder.BuildVideoEncoder(r, p)
+}
+
+func TestVideoTrackApplyConstraints(t *testing.T) {
+	builder := &recordingVP8EncoderBuilder{}
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.Width = prop.Int(640)
+			c.Height = prop.Int(480)
+		},
+		Codec: NewCodecSelector(WithVideoEncoders(builder)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetVideoTracks()[0].(*VideoTrack)
+	defer track.Close()
+
+	encodedReader, err := track.NewEncodedReader(webrtc.MimeTypeVP8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer encodedReader.Close()
+	if _, _, err := encodedReader.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The driver only supports 640x480, so the frames should be downscaled
+	err = track.ApplyConstraints(MediaTrackConstraints{
+		MediaConstraints: prop.MediaConstraints{
+			VideoConstraints: prop.VideoConstraints{
+				Width:  prop.IntExact(320),
+				Height: prop.IntExact(240),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := track.NewReader(false)
+	// The first frame might have been recorded before applying the constraints
+	reader.Read()
+	img, _, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 320 || bounds.Dy() != 240 {
+		t.Errorf("Expected the frames to be 320x240, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	// The encoder should be rebuilt for the new resolution without interrupting the reader
+	for i := 0; i < 3; i++ {
+		if _, _, err := encodedReader.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(builder.built); n != 2 {
+		t.Fatalf("Expected the encoder to be built twice, got %d", n)
+	}
+	if p := builder.built[1]; p.Width != 320 || p.Height != 240 {
+		t.Errorf("Expected the encoder to be rebuilt for 320x240, got %dx%d", p.Width, p.Height)
+	}
+
+	// Unsatisfiable constraints shouldn't change the track
+	err = track.ApplyConstraints(MediaTrackConstraints{
+		MediaConstraints: prop.MediaConstraints{
+			VideoConstraints: prop.VideoConstraints{
+				Width: prop.IntExact(10000),
+			},
+		},
+	})
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Expected %v, got %v", errNotFound, err)
+	}
+	img, _, err = reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 320 || bounds.Dy() != 240 {
+		t.Errorf("Expected the frames to stay 320x240, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestAudioTrackApplyConstraints(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Audio: func(c *MediaTrackConstraints) {
+			c.ChannelCount = prop.IntExact(1)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetAudioTracks()[0].(*AudioTrack)
+	defer track.Close()
+
+	reader := track.NewReader(false)
+	chunk, _, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channels := chunk.ChunkInfo().Channels; channels != 1 {
+		t.Fatalf("Expected 1 channel, got %d", channels)
+	}
+
+	// The driver should be reopened with 2 channels without ending the track
+	ended := make(chan error, 1)
+	track.OnEnded(func(err error) { ended <- err })
+	err = track.ApplyConstraints(MediaTrackConstraints{
+		MediaConstraints: prop.MediaConstraints{
+			AudioConstraints: prop.AudioConstraints{
+				ChannelCount: prop.IntExact(2),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first chunk might have been recorded before applying the constraints
+	reader.Read()
+	chunk, _, err = reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channels := chunk.ChunkInfo().Channels; channels != 2 {
+		t.Errorf("Expected 2 channels, got %d", channels)
+	}
+	select {
+	case err := <-ended:
+		t.Errorf("Expected the track to be alive, but it ended with %v", err)
+	default:
+	}
+}
+
+func TestApplyConstraintsUnsupported(t *testing.T) {
+	track := NewVideoTrack(&fakeVideoSource{width: 640, height: 480}, nil)
+	defer track.Close()
+
+	if err := track.ApplyConstraints(MediaTrackConstraints{}); err != errApplyConstraintsUnsupported {
+		t.Errorf("Expected %v, got %v", errApplyConstraintsUnsupported, err)
+	}
+}
+
+func TestScaledVideoSize(t *testing.T) {
+	selected := prop.Media{Video: prop.Video{Width: 640, Height: 480}}
+	cases := map[string]struct {
+		width, height        prop.IntConstraint
+		expectedW, expectedH int
+	}{
+		"Unconstrained":  {nil, nil, 640, 480},
+		"Both":           {prop.Int(320), prop.IntExact(180), 320, 180},
+		"WidthOnly":      {prop.Int(320), nil, 320, 240},
+		"HeightOnly":     {nil, prop.IntExact(120), 160, 120},
+		"NoUpscale":      {prop.Int(1280), prop.Int(720), 640, 480},
+		"NoValueToScale": {prop.IntRanged{Min: 100}, nil, 640, 480},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			constraints := MediaTrackConstraints{selectedMedia: selected}
+			constraints.Width, constraints.Height = c.width, c.height
+			if w, h := scaledVideoSize(constraints); w != c.expectedW || h != c.expectedH {
+				t.Errorf("Expected %dx%d, got %dx%d", c.expectedW, c.expectedH, w, h)
+			}
+		})
+	}
+}
+
+func TestVideoTrackApplyConstraintsFrameRate(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetVideoTracks()[0].(*VideoTrack)
+	defer track.Close()
+
+	// None of the modes is 20 fps, so a faster mode is throttled
+	err = track.ApplyConstraints(MediaTrackConstraints{
+		MediaConstraints: prop.MediaConstraints{
+			VideoConstraints: prop.VideoConstraints{
+				FrameRate: prop.FloatExact(20),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings := track.GetSettings(); settings.FrameRate != 20 {
+		t.Errorf("Expected 20 fps, got %v fps", settings.FrameRate)
+	}
+}
+
+func TestReopenDriverLocked(t *testing.T) {
+	a := &propertiesAdapter{{Video: prop.Video{Width: 640, Height: 480}}}
+	id, err := driver.GetManager().RegisterAdapter(a, driver.Info{Label: "reopen-locked", DeviceType: driver.Camera})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.GetManager().Unregister(id)
+	d := driver.GetManager().Query(driver.FilterID(id))[0]
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The driver is locked, e.g. by a probe, so it can't be reopened until the lock is released
+	unlock, err := lockDriver(context.Background(), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened := make(chan error)
+	go func() {
+		_, err := reopenVideoDriver(d, d.(driver.VideoRecorder), (*a)[0])
+		reopened <- err
+	}()
+
+	select {
+	case err := <-reopened:
+		t.Fatalf("Expected the reopen to wait for the lock, but it returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock()
+	if err := <-reopened; err != nil {
+		t.Fatal(err)
+	}
+	if d.Status() != driver.StateRunning {
+		t.Errorf("Expected the driver to be recording, got %v", d.Status())
+	}
+	unlock, err = lockDriver(context.Background(), d)
+	if err != nil {
+		t.Fatalf("Expected the driver to be unlocked after the reopen, got %v", err)
+	}
+	unlock()
+}
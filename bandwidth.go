@@ -0,0 +1,260 @@
+package mediadevices
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/rtcp"
+)
+
+const (
+	defaultStartBitRate = 1_000_000
+	defaultMinBitRate   = 30_000
+
+	// Loss thresholds and rates are taken from the loss-based controller in
+	// https://datatracker.ietf.org/doc/html/draft-ietf-rmcat-gcc-02#section-6
+	lossLowThreshold    = 0.02
+	lossHighThreshold   = 0.1
+	bitRateIncreaseRate = 1.08 // per second
+	// decreaseHoldDuration prevents the estimator from reacting multiple times to the same congestion
+	// since the following reports are likely to contain losses that happened before the previous decrease.
+	decreaseHoldDuration = 300 * time.Millisecond
+	// jitterSpikeRatio is the ratio between the reported and the smoothed jitter that is considered
+	// as an early sign of congestion.
+	jitterSpikeRatio = 2.0
+	jitterSmoothing  = 0.1
+)
+
+// BandwidthEstimator estimates the available bandwidth of a peer connection by consuming
+// RTCP feedback from the remote peer.
+type BandwidthEstimator interface {
+	// OnRTCP updates the estimation with a received RTCP packet.
+	OnRTCP(pkt rtcp.Packet)
+	// BitRate returns the current target bit rate in bps.
+	BitRate() int
+}
+
+// BandwidthEstimationConfig configures how the encoder bit rate is controlled by the
+// RTCP feedback of bound peer connections.
+type BandwidthEstimationConfig struct {
+	// MinBitRate is the lower bound of the target bit rate in bps. The default value is 30 kbps.
+	MinBitRate int
+	// MaxBitRate is the upper bound of the target bit rate in bps. 0 means no upper bound.
+	MaxBitRate int
+	// StartBitRate is the initial target bit rate in bps. The default value is 1 Mbps.
+	StartBitRate int
+	// NewEstimator builds an estimator for each peer connection. If nil, NewBandwidthEstimator is used.
+	NewEstimator func(config BandwidthEstimationConfig) BandwidthEstimator
+}
+
+func (c *BandwidthEstimationConfig) withDefaults() BandwidthEstimationConfig {
+	config := *c
+	if config.MinBitRate == 0 {
+		config.MinBitRate = defaultMinBitRate
+	}
+	if config.StartBitRate == 0 {
+		config.StartBitRate = defaultStartBitRate
+	}
+	if config.NewEstimator == nil {
+		config.NewEstimator = NewBandwidthEstimator
+	}
+	return config
+}
+
+// clamp limits bitRate within the configured range
+func (c *BandwidthEstimationConfig) clamp(bitRate int) int {
+	if bitRate < c.MinBitRate {
+		bitRate = c.MinBitRate
+	}
+	if c.MaxBitRate != 0 && bitRate > c.MaxBitRate {
+		bitRate = c.MaxBitRate
+	}
+	return bitRate
+}
+
+// bitRateControl applies the estimated bit rate to an encoder
+type bitRateControl struct {
+	config     BandwidthEstimationConfig
+	estimator  BandwidthEstimator
+	controller codec.BitRateController
+	current    int
+	// ssrc is the SSRC of the binding, whose reception reports are fed to the estimator
+	ssrc uint32
+}
+
+func newBitRateControl(config *BandwidthEstimationConfig, controller codec.BitRateController, ssrc uint32) (*bitRateControl, error) {
+	c := &bitRateControl{
+		config:     config.withDefaults(),
+		controller: controller,
+		ssrc:       ssrc,
+	}
+	start := c.config.clamp(c.config.StartBitRate)
+	c.config.StartBitRate = start
+	c.estimator = c.config.NewEstimator(c.config)
+
+	if err := controller.SetBitRate(start); err != nil {
+		return nil, err
+	}
+	c.current = start
+	return c, nil
+}
+
+// onRTCP feeds pkt to the estimator. The receiver reports are filtered to the reception reports of
+// the binding, since the others are about the other tracks of the peer connection.
+func (c *bitRateControl) onRTCP(pkt rtcp.Packet) {
+	if rr, ok := pkt.(*rtcp.ReceiverReport); ok {
+		var reports []rtcp.ReceptionReport
+		for _, report := range rr.Reports {
+			if report.SSRC == c.ssrc {
+				reports = append(reports, report)
+			}
+		}
+		if len(reports) == 0 {
+			return
+		}
+		pkt = &rtcp.ReceiverReport{SSRC: rr.SSRC, Reports: reports, ProfileExtensions: rr.ProfileExtensions}
+	}
+	c.estimator.OnRTCP(pkt)
+}
+
+// update sets the encoder bit rate if the estimation has changed
+func (c *bitRateControl) update() error {
+	bitRate := c.config.clamp(c.estimator.BitRate())
+	if bitRate == c.current {
+		return nil
+	}
+
+	if err := c.controller.SetBitRate(bitRate); err != nil {
+		return err
+	}
+	c.current = bitRate
+	return nil
+}
+
+type bandwidthEstimator struct {
+	mu           sync.Mutex
+	config       BandwidthEstimationConfig
+	bitRate      float64
+	rembBitRate  float64
+	jitter       float64
+	lastUpdate   time.Time
+	lastDecrease time.Time
+	now          func() time.Time
+}
+
+// NewBandwidthEstimator creates a BandwidthEstimator that combines the receiver estimated
+// maximum bit rate (REMB) with a loss-based controller. Losses are taken from both receiver
+// reports and transport-wide congestion control feedback, and a jitter spike in receiver reports
+// stops the bit rate from increasing.
+func NewBandwidthEstimator(config BandwidthEstimationConfig) BandwidthEstimator {
+	return newBandwidthEstimator(config.withDefaults(), time.Now)
+}
+
+func newBandwidthEstimator(config BandwidthEstimationConfig, now func() time.Time) *bandwidthEstimator {
+	return &bandwidthEstimator{
+		config:     config,
+		bitRate:    float64(config.clamp(config.StartBitRate)),
+		lastUpdate: now(),
+		now:        now,
+	}
+}
+
+// OnRTCP implements BandwidthEstimator.
+func (e *bandwidthEstimator) OnRTCP(pkt rtcp.Packet) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch pkt := pkt.(type) {
+	case *rtcp.ReceiverEstimatedMaximumBitrate:
+		e.rembBitRate = float64(pkt.Bitrate)
+	case *rtcp.ReceiverReport:
+		for _, report := range pkt.Reports {
+			jitterSpike := e.updateJitter(float64(report.Jitter))
+			e.onLoss(float64(report.FractionLost)/256, jitterSpike)
+		}
+	case *rtcp.TransportLayerCC:
+		received, total := countTransportLayerCCPackets(pkt)
+		if total > 0 {
+			e.onLoss(float64(total-received)/float64(total), false)
+		}
+	}
+}
+
+// BitRate implements BandwidthEstimator.
+func (e *bandwidthEstimator) BitRate() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bitRate := e.bitRate
+	if e.rembBitRate > 0 && e.rembBitRate < bitRate {
+		bitRate = e.rembBitRate
+	}
+	return int(bitRate)
+}
+
+// updateJitter updates the smoothed jitter, and returns true if jitter is growing rapidly
+func (e *bandwidthEstimator) updateJitter(jitter float64) bool {
+	if e.jitter == 0 {
+		e.jitter = jitter
+		return false
+	}
+
+	spike := jitter > e.jitter*jitterSpikeRatio
+	e.jitter += (jitter - e.jitter) * jitterSmoothing
+	return spike
+}
+
+func (e *bandwidthEstimator) onLoss(loss float64, hold bool) {
+	now := e.now()
+	elapsed := now.Sub(e.lastUpdate)
+	e.lastUpdate = now
+
+	switch {
+	case loss > lossHighThreshold:
+		if now.Sub(e.lastDecrease) < decreaseHoldDuration {
+			return
+		}
+		e.bitRate *= 1 - 0.5*loss
+		e.lastDecrease = now
+		e.bitRate = math.Max(e.bitRate, float64(e.config.MinBitRate))
+	case loss < lossLowThreshold && !hold:
+		e.bitRate *= math.Pow(bitRateIncreaseRate, elapsed.Seconds())
+		if e.rembBitRate > 0 && e.bitRate > e.rembBitRate {
+			// Don't let the loss-based estimation grow beyond what the receiver can handle,
+			// otherwise it won't react to losses until it comes back below REMB.
+			e.bitRate = e.rembBitRate
+		}
+		if e.config.MaxBitRate != 0 {
+			e.bitRate = math.Min(e.bitRate, float64(e.config.MaxBitRate))
+		}
+	}
+}
+
+// countTransportLayerCCPackets counts received packets and total packets in the feedback
+func countTransportLayerCCPackets(pkt *rtcp.TransportLayerCC) (received, total int) {
+	count := func(symbol uint16) {
+		if total >= int(pkt.PacketStatusCount) {
+			return
+		}
+		total++
+		if symbol != rtcp.TypeTCCPacketNotReceived {
+			received++
+		}
+	}
+
+	for _, chunk := range pkt.PacketChunks {
+		switch chunk := chunk.(type) {
+		case *rtcp.RunLengthChunk:
+			for i := 0; i < int(chunk.RunLength); i++ {
+				count(chunk.PacketStatusSymbol)
+			}
+		case *rtcp.StatusVectorChunk:
+			for _, symbol := range chunk.SymbolList {
+				count(symbol)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,204 @@
+package mediadevices
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestBandwidthEstimator(t *testing.T) {
+	config := BandwidthEstimationConfig{
+		MinBitRate:   100_000,
+		MaxBitRate:   2_000_000,
+		StartBitRate: 1_000_000,
+	}
+
+	t.Run("DecreaseOnLoss", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		e := newBandwidthEstimator(config.withDefaults(), clock.Now)
+
+		clock.Add(time.Second)
+		e.OnRTCP(&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 128}}})
+		if bitRate := e.BitRate(); bitRate != 750_000 {
+			t.Errorf("Expected bit rate to be 750000, got %d", bitRate)
+		}
+
+		// Losses that are reported right after a decrease should be ignored
+		clock.Add(100 * time.Millisecond)
+		e.OnRTCP(&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 128}}})
+		if bitRate := e.BitRate(); bitRate != 750_000 {
+			t.Errorf("Expected bit rate to be 750000, got %d", bitRate)
+		}
+
+		for i := 0; i < 100; i++ {
+			clock.Add(time.Second)
+			e.OnRTCP(&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 255}}})
+		}
+		if bitRate := e.BitRate(); bitRate != config.MinBitRate {
+			t.Errorf("Expected bit rate to be %d, got %d", config.MinBitRate, bitRate)
+		}
+	})
+
+	t.Run("IncreaseWithoutLoss", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		e := newBandwidthEstimator(config.withDefaults(), clock.Now)
+
+		clock.Add(time.Second)
+		e.OnRTCP(&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 0, Jitter: 10}}})
+		if bitRate := e.BitRate(); bitRate != 1_080_000 {
+			t.Errorf("Expected bit rate to be 1080000, got %d", bitRate)
+		}
+
+		// A jitter spike should hold the bit rate
+		clock.Add(time.Second)
+		e.OnRTCP(&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 0, Jitter: 100}}})
+		if bitRate := e.BitRate(); bitRate != 1_080_000 {
+			t.Errorf("Expected bit rate to be 1080000, got %d", bitRate)
+		}
+
+		for i := 0; i < 100; i++ {
+			clock.Add(time.Second)
+			e.OnRTCP(&rtcp.TransportLayerCC{
+				PacketStatusCount: 10,
+				PacketChunks: []rtcp.PacketStatusChunk{
+					&rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta, RunLength: 10},
+				},
+			})
+		}
+		if bitRate := e.BitRate(); bitRate != config.MaxBitRate {
+			t.Errorf("Expected bit rate to be %d, got %d", config.MaxBitRate, bitRate)
+		}
+	})
+
+	t.Run("LimitedByREMB", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		e := newBandwidthEstimator(config.withDefaults(), clock.Now)
+
+		e.OnRTCP(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 500_000})
+		if bitRate := e.BitRate(); bitRate != 500_000 {
+			t.Errorf("Expected bit rate to be 500000, got %d", bitRate)
+		}
+
+		clock.Add(time.Second)
+		e.OnRTCP(&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 0}}})
+		if bitRate := e.BitRate(); bitRate != 500_000 {
+			t.Errorf("Expected bit rate to be 500000, got %d", bitRate)
+		}
+	})
+}
+
+func TestCountTransportLayerCCPackets(t *testing.T) {
+	received, total := countTransportLayerCCPackets(&rtcp.TransportLayerCC{
+		PacketStatusCount: 12,
+		PacketChunks: []rtcp.PacketStatusChunk{
+			&rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketNotReceived, RunLength: 3},
+			&rtcp.StatusVectorChunk{
+				SymbolSize: rtcp.TypeTCCSymbolSizeOneBit,
+				SymbolList: []uint16{
+					rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketNotReceived,
+					rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketReceivedSmallDelta,
+					rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketReceivedSmallDelta,
+					rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketReceivedSmallDelta,
+					rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketReceivedSmallDelta,
+					rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketReceivedSmallDelta,
+					rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketReceivedSmallDelta,
+				},
+			},
+		},
+	})
+
+	if received != 8 || total != 12 {
+		t.Errorf("Expected 8 received packets out of 12, got %d out of %d", received, total)
+	}
+}
+
+type fakeBitRateController struct {
+	called chan int
+}
+
+func (mock *fakeBitRateController) SetBitRate(bitRate int) error {
+	mock.called <- bitRate
+	return nil
+}
+
+func TestRtcpHandlerBitRate(t *testing.T) {
+	tr := &baseTrack{}
+	stop := make(chan struct{}, 1)
+	defer func() {
+		stop <- struct{}{}
+	}()
+
+	mockBitRateController := &fakeBitRateController{called: make(chan int, 1)}
+	bitRate, err := newBitRateControl(&BandwidthEstimationConfig{StartBitRate: 1_000_000}, mockBitRateController, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start := <-mockBitRateController.called; start != 1_000_000 {
+		t.Errorf("Expected start bit rate to be 1000000, got %d", start)
+	}
+
+	mockRTCPReader := &fakeRTCPReader{end: stop, mockReturn: make(chan []byte, 1)}
+	go tr.rtcpReadLoop(mockRTCPReader, mockBitRateController, bitRate, stop)
+
+	remb, err := (&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 300_000, SSRCs: []uint32{1}}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockRTCPReader.mockReturn <- remb
+
+	select {
+	case <-time.After(time.Second):
+		t.Error("Timeout")
+	case bitRate := <-mockBitRateController.called:
+		if bitRate != 300_000 {
+			t.Errorf("Expected bit rate to be 300000, got %d", bitRate)
+		}
+	}
+}
+
+// recordingEstimator records the RTCP packets that it's fed
+type recordingEstimator struct {
+	pkts []rtcp.Packet
+}
+
+func (e *recordingEstimator) OnRTCP(pkt rtcp.Packet) { e.pkts = append(e.pkts, pkt) }
+func (e *recordingEstimator) BitRate() int           { return 1_000_000 }
+
+func TestBitRateControlReceiverReports(t *testing.T) {
+	estimator := &recordingEstimator{}
+	config := &BandwidthEstimationConfig{
+		NewEstimator: func(BandwidthEstimationConfig) BandwidthEstimator { return estimator },
+	}
+	bitRate, err := newBitRateControl(config, bitRateControllerFunc(func(int) error { return nil }), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bitRate.onRTCP(&rtcp.ReceiverReport{SSRC: 9, Reports: []rtcp.ReceptionReport{
+		{SSRC: 2, FractionLost: 255},
+		{SSRC: 1, FractionLost: 10},
+	}})
+	// A report of the other tracks only is dropped
+	bitRate.onRTCP(&rtcp.ReceiverReport{SSRC: 9, Reports: []rtcp.ReceptionReport{{SSRC: 2, FractionLost: 255}}})
+
+	expected := []rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: 9, Reports: []rtcp.ReceptionReport{{SSRC: 1, FractionLost: 10}}},
+	}
+	if !reflect.DeepEqual(expected, estimator.pkts) {
+		t.Errorf("Expected %v, got %v", expected, estimator.pkts)
+	}
+}
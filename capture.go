@@ -0,0 +1,219 @@
+package mediadevices
+
+import (
+	"image"
+	"io"
+	"sync"
+	"time"
+
+	mio "github.com/pion/mediadevices/pkg/io"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// captureBroadcasterConfig wakes the tracks up as soon as the data has been captured. Each track
+// has its own broadcaster on top of the capture, so polling here would add to the latency of the
+// track's broadcaster.
+var captureBroadcasterConfig = &mio.BroadcasterConfig{Mode: mio.BroadcasterNotify}
+
+// capture is the capture source that is shared by a track and its clones. The source is closed when
+// all of the tracks that share it have been closed.
+type capture struct {
+	source Source
+
+	// mu guards the fields below and the recorded readers of the specialized capture
+	mu          sync.Mutex
+	refs        int
+	closed      bool
+	generation  uint64
+	constraints MediaTrackConstraints
+}
+
+// newTrackSource creates a source for a new track on top of the capture. If the capture has
+// already been closed, the new source is closed too.
+func (c *capture) newTrackSource() *trackSource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.refs++
+	}
+	return &trackSource{capture: c, closed: c.closed}
+}
+
+// release removes a reference from the capture, and closes the source when it was the last one
+func (c *capture) release() error {
+	c.mu.Lock()
+	c.refs--
+	if c.refs > 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	return c.source.Close()
+}
+
+// isReplaced tells if the recorded reader has been replaced since generation
+func (c *capture) isReplaced(generation uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation != generation
+}
+
+// trackSource is the source of a single track on top of a shared capture. Closing it only stops
+// the track itself, unless it's the last track of the capture.
+type trackSource struct {
+	capture *capture
+	mu      sync.Mutex
+	closed  bool
+}
+
+func (source *trackSource) ID() string {
+	return source.capture.source.ID()
+}
+
+func (source *trackSource) Close() error {
+	source.mu.Lock()
+	if source.closed {
+		source.mu.Unlock()
+		return nil
+	}
+	source.closed = true
+	source.mu.Unlock()
+
+	return source.capture.release()
+}
+
+func (source *trackSource) isClosed() bool {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return source.closed
+}
+
+// videoCapture records the frames from the source, and broadcasts them to the tracks
+type videoCapture struct {
+	*capture
+	broadcaster *video.Broadcaster
+
+	// recorded is the reader from the source, and reader is recorded with the transforms that are
+	// needed to satisfy the constraints. They're guarded by mu.
+	recorded video.Reader
+	reader   video.Reader
+}
+
+func newVideoCapture(source Source, reader video.Reader, constraints MediaTrackConstraints) *videoCapture {
+	c := &videoCapture{
+		capture:  &capture{source: source, constraints: constraints},
+		recorded: reader,
+		reader:   video.Merge(videoConstraintsTransforms(constraints)...)(reader),
+	}
+
+	var timestamp time.Time
+	recordedReader := video.WithTimestamp(video.ReaderFunc(func() (img image.Image, release func(), err error) {
+		for {
+			reader, generation := c.currentReader()
+			img, _, err = reader.Read()
+			if err != nil && c.isReplaced(generation) {
+				// The driver has been reopened while reading, e.g. by ApplyConstraints, so read from the new one
+				continue
+			}
+
+			// Drivers that don't report the capture time are assumed to return the frame as soon as it's captured
+			timestamp = video.Timestamp(reader)
+			if timestamp.IsZero() {
+				timestamp = time.Now()
+			}
+			return img, func() {}, err
+		}
+	}), func() time.Time {
+		return timestamp
+	})
+
+	c.broadcaster = video.NewBroadcaster(recordedReader, &video.BroadcasterConfig{Core: captureBroadcasterConfig})
+	return c
+}
+
+// currentReader returns the reader that the capture records from, and its generation
+func (c *videoCapture) currentReader() (video.Reader, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reader, c.generation
+}
+
+// newReader creates a reader of the frames for a track. The reader returns io.EOF once source is closed.
+func (c *videoCapture) newReader(source *trackSource) video.Reader {
+	reader := c.broadcaster.NewReader(false)
+	return video.WithTimestamp(video.ReaderFunc(func() (image.Image, func(), error) {
+		if source.isClosed() {
+			return nil, func() {}, io.EOF
+		}
+		return reader.Read()
+	}), func() time.Time {
+		return video.Timestamp(reader)
+	})
+}
+
+// audioCapture records the chunks from the source, and broadcasts them to the tracks
+type audioCapture struct {
+	*capture
+	broadcaster *audio.Broadcaster
+
+	// recorded is the reader from the source, and reader is recorded with the transforms that are
+	// needed to satisfy the constraints. They're guarded by mu.
+	recorded audio.Reader
+	reader   audio.Reader
+}
+
+func newAudioCapture(source Source, reader audio.Reader, constraints MediaTrackConstraints) *audioCapture {
+	c := &audioCapture{
+		capture:  &capture{source: source, constraints: constraints},
+		recorded: reader,
+		reader:   reader,
+	}
+
+	var timestamp time.Time
+	recordedReader := audio.WithTimestamp(audio.ReaderFunc(func() (chunk wave.Audio, release func(), err error) {
+		for {
+			reader, generation := c.currentReader()
+			chunk, _, err = reader.Read()
+			if err != nil && c.isReplaced(generation) {
+				// The driver has been reopened while reading, e.g. by ApplyConstraints, so read from the new one
+				continue
+			}
+
+			// Drivers that don't report the capture time are assumed to return the chunk as soon as it's captured
+			timestamp = audio.Timestamp(reader)
+			if timestamp.IsZero() {
+				timestamp = time.Now()
+			}
+			return chunk, func() {}, err
+		}
+	}), func() time.Time {
+		return timestamp
+	})
+
+	c.broadcaster = audio.NewBroadcaster(recordedReader, &audio.BroadcasterConfig{Core: captureBroadcasterConfig})
+	return c
+}
+
+// currentReader returns the reader that the capture records from, and its generation
+func (c *audioCapture) currentReader() (audio.Reader, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reader, c.generation
+}
+
+// newReader creates a reader of the chunks for a track. The reader returns io.EOF once source is closed.
+func (c *audioCapture) newReader(source *trackSource) audio.Reader {
+	reader := c.broadcaster.NewReader(false)
+	return audio.WithTimestamp(audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		if source.isClosed() {
+			return nil, func() {}, io.EOF
+		}
+		return reader.Read()
+	}), func() time.Time {
+		return audio.Timestamp(reader)
+	})
+}
@@ -0,0 +1,105 @@
+package mediadevices
+
+import (
+	"io"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/video"
+)
+
+func TestVideoTrackClone(t *testing.T) {
+	source := &fakeVideoSource{id: "camera", width: 640, height: 480}
+	track := NewVideoTrack(source, nil).(*VideoTrack)
+	track.SetSharedEncoding(true)
+
+	clone := track.Clone().(*VideoTrack)
+	if clone.ID() != track.ID() {
+		t.Errorf("Expected ID %s, got %s", track.ID(), clone.ID())
+	}
+	if !clone.SharedEncoding() {
+		t.Error("Expected the clone to have the settings of the track")
+	}
+
+	// The clone has its own transforms
+	clone.Transform(video.Scale(160, 120, nil))
+	img, _, err := clone.NewReader(false).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 160 || bounds.Dy() != 120 {
+		t.Errorf("Expected the clone to be 160x120, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	img, _, err = track.NewReader(false).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 640 || bounds.Dy() != 480 {
+		t.Errorf("Expected the track to be 640x480, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	// Closing the track only ends the track itself
+	trackEnded := make(chan error, 1)
+	track.OnEnded(func(err error) { trackEnded <- err })
+	cloneEnded := make(chan error, 1)
+	clone.OnEnded(func(err error) { cloneEnded <- err })
+
+	track.Close()
+	if source.closed {
+		t.Error("Expected the capture source to be alive while the clone is alive")
+	}
+	if _, _, err := track.NewReader(false).Read(); err != io.EOF {
+		t.Errorf("Expected the closed track to return EOF, got %v", err)
+	}
+	if err := <-trackEnded; err != io.EOF {
+		t.Errorf("Expected the track to end with EOF, got %v", err)
+	}
+	if _, _, err := clone.NewReader(false).Read(); err != nil {
+		t.Errorf("Expected the clone to be alive, got %v", err)
+	}
+	select {
+	case err := <-cloneEnded:
+		t.Errorf("Expected the clone to be alive, but it ended with %v", err)
+	default:
+	}
+
+	// Closing the last clone closes the capture source
+	clone.Close()
+	if !source.closed {
+		t.Error("Expected the capture source to be closed")
+	}
+
+	// Clones of the closed tracks are ended from the start
+	if _, _, err := clone.Clone().(*VideoTrack).NewReader(false).Read(); err != io.EOF {
+		t.Errorf("Expected the clone of the closed track to return EOF, got %v", err)
+	}
+}
+
+func TestAudioTrackClone(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Audio: func(c *MediaTrackConstraints) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetAudioTracks()[0].(*AudioTrack)
+	d := driver.GetManager().Query(driver.FilterID(track.ID()))[0]
+
+	clone := track.Clone().(*AudioTrack)
+	if settings, cloneSettings := track.GetSettings(), clone.GetSettings(); settings != cloneSettings {
+		t.Errorf("Expected the clone to have the same settings, got\n%s\n\nand\n%s", &settings, &cloneSettings)
+	}
+
+	track.Close()
+	if d.Status() == driver.StateClosed {
+		t.Error("Expected the driver to be running while the clone is alive")
+	}
+	if _, _, err := clone.NewReader(false).Read(); err != nil {
+		t.Errorf("Expected the clone to be alive, got %v", err)
+	}
+
+	clone.Close()
+	if status := d.Status(); status != driver.StateClosed {
+		t.Errorf("Expected the driver to be closed, got %s", status)
+	}
+}
@@ -0,0 +1,117 @@
+package mediadevices
+
+import (
+	"image"
+	"reflect"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// disabledFrameInterval is the interval of the black frames that disabled video tracks send
+const disabledFrameInterval = time.Second
+
+// Enabled tells if the track is enabled. Tracks are enabled when they're created.
+func (track *baseTrack) Enabled() bool {
+	track.enabledMu.Lock()
+	defer track.enabledMu.Unlock()
+	return track.enabled
+}
+
+// SetEnabled enables or disables the track. While disabled, video tracks send black frames at
+// a low rate with the resolution of the captured frames, and audio tracks send silent chunks with
+// the ChunkInfo of the captured chunks. Since the readers, encoders, and peer connection bindings
+// keep running, the remote peers won't need a renegotiation. TrackMuteEvent or TrackUnmuteEvent
+// is emitted when the state changes.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediastreamtrack-enabled
+func (track *baseTrack) SetEnabled(enabled bool) {
+	track.enabledMu.Lock()
+	changed := track.enabled != enabled
+	track.enabled = enabled
+	track.enabledMu.Unlock()
+
+	switch {
+	case !changed:
+	case enabled:
+		track.events.emit(TrackUnmuteEvent{})
+	default:
+		track.events.emit(TrackMuteEvent{})
+	}
+}
+
+// OnMute sets a handler that is called when the track is disabled by SetEnabled. The handler
+// replaces the one that has been set by the previous call. Use Subscribe to have multiple handlers.
+func (track *baseTrack) OnMute(handler func()) {
+	var eventHandler func(TrackEvent)
+	if handler != nil {
+		eventHandler = func(event TrackEvent) {
+			if _, ok := event.(TrackMuteEvent); ok {
+				handler()
+			}
+		}
+	}
+	track.replaceHandler(&track.onMuteUnsubscribe, eventHandler)
+}
+
+// OnUnmute sets a handler that is called when the track is enabled again by SetEnabled. The handler
+// replaces the one that has been set by the previous call. Use Subscribe to have multiple handlers.
+func (track *baseTrack) OnUnmute(handler func()) {
+	var eventHandler func(TrackEvent)
+	if handler != nil {
+		eventHandler = func(event TrackEvent) {
+			if _, ok := event.(TrackUnmuteEvent); ok {
+				handler()
+			}
+		}
+	}
+	track.replaceHandler(&track.onUnmuteUnsubscribe, eventHandler)
+}
+
+// blackFrames creates the black frames that disabled video tracks send. The last frame is
+// reused as long as the resolution doesn't change.
+type blackFrames struct {
+	frame *image.YCbCr
+}
+
+func (b *blackFrames) get(bounds image.Rectangle) image.Image {
+	if b.frame != nil && b.frame.Rect == bounds {
+		return b.frame
+	}
+
+	frame := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+	// Encoders take the frames in the video range, where 16 is black
+	for i := range frame.Y {
+		frame.Y[i] = 16
+	}
+	for i := range frame.Cb {
+		frame.Cb[i] = 128
+		frame.Cr[i] = 128
+	}
+	b.frame = frame
+	return frame
+}
+
+// silentChunks creates the silent chunks that disabled audio tracks send. The last chunk is
+// reused as long as the format doesn't change.
+type silentChunks struct {
+	chunk wave.Audio
+}
+
+func (s *silentChunks) get(chunk wave.Audio) wave.Audio {
+	if s.chunk != nil && s.chunk.ChunkInfo() == chunk.ChunkInfo() && reflect.TypeOf(s.chunk) == reflect.TypeOf(chunk) {
+		return s.chunk
+	}
+
+	info := chunk.ChunkInfo()
+	switch chunk.(type) {
+	case *wave.Float32Interleaved:
+		s.chunk = wave.NewFloat32Interleaved(info)
+	case *wave.Float32NonInterleaved:
+		s.chunk = wave.NewFloat32NonInterleaved(info)
+	case *wave.Int16NonInterleaved:
+		s.chunk = wave.NewInt16NonInterleaved(info)
+	default:
+		s.chunk = wave.NewInt16Interleaved(info)
+	}
+	return s.chunk
+}
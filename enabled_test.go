@@ -0,0 +1,114 @@
+package mediadevices
+
+import (
+	"image"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+func TestVideoTrackSetEnabled(t *testing.T) {
+	track := NewVideoTrack(&fakeVideoSource{width: 320, height: 240}, nil).(*VideoTrack)
+	defer track.Close()
+
+	var muted, unmuted int
+	track.OnMute(func() { muted++ })
+	track.OnUnmute(func() { unmuted++ })
+
+	track.SetEnabled(false)
+	track.SetEnabled(false)
+	if track.Enabled() {
+		t.Fatal("Expected the track to be disabled")
+	}
+	if muted != 1 || unmuted != 0 {
+		t.Errorf("Expected the mute handler to be called once, got %d mutes and %d unmutes", muted, unmuted)
+	}
+
+	reader := track.NewReader(false)
+	img, _, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame, ok := img.(*image.YCbCr)
+	if !ok {
+		t.Fatalf("Expected a YCbCr frame, got %T", img)
+	}
+	if bounds := frame.Bounds(); bounds.Dx() != 320 || bounds.Dy() != 240 {
+		t.Errorf("Expected the black frame to be 320x240, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if frame.Y[0] != 16 || frame.Cb[0] != 128 || frame.Cr[0] != 128 {
+		t.Errorf("Expected a black frame, got Y=%d Cb=%d Cr=%d", frame.Y[0], frame.Cb[0], frame.Cr[0])
+	}
+
+	track.SetEnabled(true)
+	if muted != 1 || unmuted != 1 {
+		t.Errorf("Expected the unmute handler to be called once, got %d mutes and %d unmutes", muted, unmuted)
+	}
+
+	// The first frame might have been sent before enabling the track
+	reader.Read()
+	img, _, err = reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame := img.(*image.YCbCr); frame.Y[0] != 0 {
+		t.Errorf("Expected the captured frame, got Y=%d", frame.Y[0])
+	}
+}
+
+func TestAudioTrackSetEnabled(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Audio: func(c *MediaTrackConstraints) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetAudioTracks()[0].(*AudioTrack)
+	defer track.Close()
+
+	reader := track.NewReader(false)
+	captured, _, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	track.SetEnabled(false)
+	// The first chunk might have been captured before disabling the track
+	reader.Read()
+	chunk, _, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chunk.ChunkInfo() != captured.ChunkInfo() {
+		t.Errorf("Expected the silent chunk to be %v, got %v", captured.ChunkInfo(), chunk.ChunkInfo())
+	}
+	if _, ok := chunk.(*wave.Float32Interleaved); !ok {
+		t.Errorf("Expected the silent chunk to have the format of the captured chunks, got %T", chunk)
+	}
+	info := chunk.ChunkInfo()
+	for i := 0; i < info.Len; i++ {
+		for ch := 0; ch < info.Channels; ch++ {
+			if s := chunk.At(i, ch).Int(); s != 0 {
+				t.Fatalf("Expected silence, got %d at %d on channel %d", s, i, ch)
+			}
+		}
+	}
+}
+
+func TestCloneEnabled(t *testing.T) {
+	track := NewVideoTrack(&fakeVideoSource{width: 320, height: 240}, nil).(*VideoTrack)
+	defer track.Close()
+
+	track.SetEnabled(false)
+	clone := track.Clone()
+	defer clone.Close()
+	if clone.Enabled() {
+		t.Error("Expected the clone to be disabled like the track")
+	}
+
+	clone.SetEnabled(true)
+	if track.Enabled() {
+		t.Error("Expected the track to be disabled independently of the clone")
+	}
+}
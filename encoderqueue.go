@@ -0,0 +1,85 @@
+package mediadevices
+
+import (
+	"sync"
+	"time"
+)
+
+// queuedInput is a frame or an audio chunk that has been read by an encoder
+type queuedInput struct {
+	// timestamp is the capture time of the frame, or of the first sample of the chunk
+	timestamp time.Time
+	// inputTime is when the input has reached the encoder
+	inputTime time.Time
+	// samples and sampleRate describe the audio chunk. They're zero for video frames.
+	samples, sampleRate int
+}
+
+// encoderQueue keeps the inputs that have been read by an encoder, but haven't come out of it yet.
+// Encoders may buffer some frames before they output the first one, e.g. x264 lookahead and vpx lag,
+// and audio encoders output a fixed number of samples at once regardless of the chunks that they
+// read, so the encoded data doesn't always belong to the latest input.
+type encoderQueue struct {
+	mu     sync.Mutex
+	inputs []queuedInput
+	// consumed is the number of the samples of the first input that have already been encoded
+	consumed int
+}
+
+func (q *encoderQueue) push(in queuedInput) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inputs = append(q.inputs, in)
+}
+
+// reset drops the queued inputs, e.g. since the encoder that has buffered them has been closed
+func (q *encoderQueue) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inputs = nil
+	q.consumed = 0
+}
+
+// pop removes the oldest frame, which has been encoded. ok is false if there's no frame left.
+func (q *encoderQueue) pop() (in queuedInput, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.inputs) == 0 {
+		return queuedInput{}, false
+	}
+	in = q.inputs[0]
+	q.inputs = q.inputs[1:]
+	q.consumed = 0
+	return in, true
+}
+
+// popSamples removes n samples, which have been encoded, and returns the input of the first one,
+// whose timestamp is the capture time of the sample. If n isn't positive, the oldest input is
+// removed as a whole. ok is false if there's no sample left.
+func (q *encoderQueue) popSamples(n int) (in queuedInput, ok bool) {
+	if n <= 0 {
+		return q.pop()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.inputs) == 0 {
+		return queuedInput{}, false
+	}
+	in = q.inputs[0]
+	if !in.timestamp.IsZero() && in.sampleRate > 0 {
+		in.timestamp = in.timestamp.Add(time.Duration(q.consumed) * time.Second / time.Duration(in.sampleRate))
+	}
+
+	for n > 0 && len(q.inputs) > 0 {
+		left := q.inputs[0].samples - q.consumed
+		if n < left {
+			q.consumed += n
+			break
+		}
+		n -= left
+		q.inputs = q.inputs[1:]
+		q.consumed = 0
+	}
+	return in, true
+}
@@ -0,0 +1,29 @@
+package mediadevices
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncoderQueuePopSamples(t *testing.T) {
+	var q encoderQueue
+	start := time.Now()
+	// 10ms chunks at 48kHz are encoded by 20ms, starting from the middle of the first chunk
+	for i := 0; i < 4; i++ {
+		q.push(queuedInput{timestamp: start.Add(time.Duration(i) * 10 * time.Millisecond), samples: 480, sampleRate: 48000})
+	}
+	q.popSamples(240)
+
+	for _, expected := range []time.Duration{5 * time.Millisecond, 25 * time.Millisecond} {
+		in, ok := q.popSamples(960)
+		if !ok {
+			t.Fatal("Expected the samples to be in the queue")
+		}
+		if !in.timestamp.Equal(start.Add(expected)) {
+			t.Errorf("Expected the samples to be captured at %v, got %v", expected, in.timestamp.Sub(start))
+		}
+	}
+	if _, ok := q.popSamples(960); ok {
+		t.Error("Expected no sample left")
+	}
+}
@@ -0,0 +1,205 @@
+package mediadevices
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+const (
+	// settingsDetectInterval is the interval that the frame rate is measured over to detect the changes
+	settingsDetectInterval = time.Second
+	// frameRateChangeTolerance is the difference of the frame rates that is considered as a change
+	frameRateChangeTolerance = 1
+)
+
+// TrackEvent is an event in the lifecycle of a track. It's one of TrackEndedEvent, TrackMuteEvent,
+// TrackUnmuteEvent, TrackSettingsChangedEvent, TrackKeyFrameForcedEvent, and TrackBitRateChangedEvent.
+type TrackEvent interface {
+	isTrackEvent()
+}
+
+// TrackEndedEvent is emitted exactly once when the track has ended, e.g. the source returned an error.
+type TrackEndedEvent struct {
+	// Err is the error that ended the track
+	Err error
+}
+
+// TrackMuteEvent is emitted when the track has been disabled by SetEnabled.
+type TrackMuteEvent struct{}
+
+// TrackUnmuteEvent is emitted when the track has been enabled again by SetEnabled.
+type TrackUnmuteEvent struct{}
+
+// TrackSettingsChangedEvent is emitted when the properties of the captured media have changed,
+// e.g. the resolution has been changed by ApplyConstraints.
+type TrackSettingsChangedEvent struct {
+	// Settings contains the properties that are detected from the captured media, i.e. the resolution
+	// and the frame rate of video, or the sample rate, channel count, and latency of audio, and the
+	// device ID of the track.
+	Settings prop.Media
+}
+
+// TrackKeyFrameForcedEvent is emitted when an encoder of the track has been forced to produce a key frame,
+// e.g. by PLI or FIR.
+type TrackKeyFrameForcedEvent struct{}
+
+// TrackBitRateChangedEvent is emitted when the bit rate of an encoder of the track has been changed,
+// e.g. by the bandwidth estimation.
+type TrackBitRateChangedEvent struct {
+	// BitRate is the new bit rate in bps
+	BitRate int
+}
+
+func (TrackEndedEvent) isTrackEvent()           {}
+func (TrackMuteEvent) isTrackEvent()            {}
+func (TrackUnmuteEvent) isTrackEvent()          {}
+func (TrackSettingsChangedEvent) isTrackEvent() {}
+func (TrackKeyFrameForcedEvent) isTrackEvent()  {}
+func (TrackBitRateChangedEvent) isTrackEvent()  {}
+
+// trackEvents dispatches the events of a track to the subscribers. The zero value is ready to use.
+type trackEvents struct {
+	mu          sync.Mutex
+	subscribers []*trackSubscriber
+	ended       *TrackEndedEvent
+}
+
+type trackSubscriber struct {
+	handler func(TrackEvent)
+}
+
+// subscribe adds a subscriber, and returns a function to remove it. If the track has already ended,
+// handler is called with the ended event right away.
+func (e *trackEvents) subscribe(handler func(TrackEvent)) func() {
+	e.mu.Lock()
+	if e.ended != nil {
+		ended := *e.ended
+		e.mu.Unlock()
+		handler(ended)
+		return func() {}
+	}
+
+	subscriber := &trackSubscriber{handler: handler}
+	e.subscribers = append(e.subscribers, subscriber)
+	e.mu.Unlock()
+
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for i, s := range e.subscribers {
+			if s == subscriber {
+				e.subscribers = append(e.subscribers[:i:i], e.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit calls the subscribers with event. The events after the track has ended are dropped, and
+// only the first ended event is emitted.
+func (e *trackEvents) emit(event TrackEvent) {
+	e.mu.Lock()
+	if e.ended != nil {
+		e.mu.Unlock()
+		return
+	}
+	if ended, ok := event.(TrackEndedEvent); ok {
+		e.ended = &ended
+	}
+	subscribers := e.subscribers
+	e.mu.Unlock()
+
+	for _, s := range subscribers {
+		s.handler(event)
+	}
+}
+
+// Subscribe registers handler to receive the lifecycle events of the track, and returns a function
+// to unsubscribe. There can be any number of subscribers, and each of them receives TrackEndedEvent
+// exactly once. If the track has already ended, handler is called with TrackEndedEvent right away.
+// The handlers are called synchronously from the goroutine that caused the event, so they
+// shouldn't block.
+func (track *baseTrack) Subscribe(handler func(TrackEvent)) (unsubscribe func()) {
+	return track.events.subscribe(handler)
+}
+
+// replaceHandler replaces the handler that has been set by one of the OnX methods. unsubscribe is
+// the slot of the OnX method.
+func (track *baseTrack) replaceHandler(unsubscribe *func(), handler func(TrackEvent)) {
+	track.handlersMu.Lock()
+	defer track.handlersMu.Unlock()
+	if *unsubscribe != nil {
+		(*unsubscribe)()
+		*unsubscribe = nil
+	}
+	if handler != nil {
+		*unsubscribe = track.events.subscribe(handler)
+	}
+}
+
+// onSettingsChanged is called by DetectChanges of the track
+func (track *baseTrack) onSettingsChanged(settings prop.Media) {
+	settings.DeviceID = track.ID()
+	track.events.emit(TrackSettingsChangedEvent{Settings: settings})
+}
+
+// settingsChanges filters the properties that are detected by DetectChanges into the changes of the settings
+type settingsChanges struct {
+	last *prop.Media
+}
+
+// changed records p, and tells if it's a change of the settings. The first properties are the initial
+// settings rather than a change.
+func (s *settingsChanges) changed(p prop.Media) bool {
+	last := s.last
+	current := p
+	s.last = &current
+	if last == nil {
+		return false
+	}
+
+	// The frame rate is only known after the first interval, so the first measurement isn't a change
+	if last.FrameRate == 0 {
+		p.FrameRate = 0
+	}
+	return p != *last
+}
+
+// wrapController wraps controller to collect the statistics and emit the events of the track
+func (track *baseTrack) wrapController(controller codec.EncoderController) codec.EncoderController {
+	keyFrameController, isKeyFrameController := controller.(codec.KeyFrameController)
+	bitRateController, isBitRateController := controller.(codec.BitRateController)
+
+	forceKeyFrame := keyFrameControllerFunc(func() error {
+		if err := keyFrameController.ForceKeyFrame(); err != nil {
+			return err
+		}
+		track.stats.onForcedKeyFrame()
+		track.events.emit(TrackKeyFrameForcedEvent{})
+		return nil
+	})
+	setBitRate := bitRateControllerFunc(func(bitRate int) error {
+		if err := bitRateController.SetBitRate(bitRate); err != nil {
+			return err
+		}
+		track.events.emit(TrackBitRateChangedEvent{BitRate: bitRate})
+		return nil
+	})
+
+	switch {
+	case isKeyFrameController && isBitRateController:
+		return &struct {
+			keyFrameControllerFunc
+			bitRateControllerFunc
+		}{forceKeyFrame, setBitRate}
+	case isKeyFrameController:
+		return forceKeyFrame
+	case isBitRateController:
+		return setBitRate
+	default:
+		return controller
+	}
+}
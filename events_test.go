@@ -0,0 +1,161 @@
+package mediadevices
+
+import (
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+func TestTrackEventsEnded(t *testing.T) {
+	tr := &baseTrack{}
+
+	var first, second, unsubscribed []error
+	tr.Subscribe(func(event TrackEvent) {
+		if ended, ok := event.(TrackEndedEvent); ok {
+			first = append(first, ended.Err)
+		}
+	})
+	tr.Subscribe(func(event TrackEvent) {
+		if ended, ok := event.(TrackEndedEvent); ok {
+			second = append(second, ended.Err)
+		}
+	})
+	unsubscribe := tr.Subscribe(func(event TrackEvent) {
+		if ended, ok := event.(TrackEndedEvent); ok {
+			unsubscribed = append(unsubscribed, ended.Err)
+		}
+	})
+	unsubscribe()
+
+	// OnEnded shouldn't replace the subscribers
+	var onEnded []error
+	tr.OnEnded(func(err error) { onEnded = append(onEnded, err) })
+
+	tr.onError(errExpected)
+	tr.onError(errExpected)
+
+	for name, errs := range map[string][]error{"first": first, "second": second, "OnEnded": onEnded} {
+		if len(errs) != 1 || errs[0] != errExpected {
+			t.Errorf("Expected %s subscriber to receive %v once, got %v", name, errExpected, errs)
+		}
+	}
+	if len(unsubscribed) != 0 {
+		t.Errorf("Expected the unsubscribed handler not to be called, got %v", unsubscribed)
+	}
+
+	// Subscribers after the track has ended should receive the ended event right away
+	var late []TrackEvent
+	tr.Subscribe(func(event TrackEvent) { late = append(late, event) })
+	if len(late) != 1 || late[0] != (TrackEndedEvent{Err: errExpected}) {
+		t.Errorf("Expected the late subscriber to receive the ended event, got %v", late)
+	}
+
+	// No events should be emitted after the track has ended
+	tr.SetEnabled(true)
+	if len(late) != 1 {
+		t.Errorf("Expected no events after the ended event, got %v", late)
+	}
+}
+
+func TestTrackEventsMute(t *testing.T) {
+	tr := newBaseTrack(nil, VideoInput, nil)
+
+	var events []TrackEvent
+	tr.Subscribe(func(event TrackEvent) { events = append(events, event) })
+
+	tr.SetEnabled(false)
+	tr.SetEnabled(false)
+	tr.SetEnabled(true)
+
+	expected := []TrackEvent{TrackMuteEvent{}, TrackUnmuteEvent{}}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, events)
+		}
+	}
+}
+
+type fakeEncoderController struct {
+	*fakeKeyFrameController
+	*fakeBitRateController
+}
+
+func TestTrackEventsController(t *testing.T) {
+	tr := newBaseTrack(nil, VideoInput, nil)
+
+	var events []TrackEvent
+	tr.Subscribe(func(event TrackEvent) { events = append(events, event) })
+
+	controller := tr.wrapController(&fakeEncoderController{
+		&fakeKeyFrameController{called: make(chan struct{}, 1)},
+		&fakeBitRateController{called: make(chan int, 1)},
+	})
+	if err := controller.(codec.KeyFrameController).ForceKeyFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if err := controller.(codec.BitRateController).SetBitRate(500_000); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []TrackEvent{TrackKeyFrameForcedEvent{}, TrackBitRateChangedEvent{BitRate: 500_000}}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, events)
+		}
+	}
+	if forced := tr.stats.snapshot().ForcedKeyFrames; forced != 1 {
+		t.Errorf("Expected 1 forced key frame, got %d", forced)
+	}
+}
+
+func TestTrackEventsSettingsChanged(t *testing.T) {
+	source := &fakeVideoSource{id: "source", width: 640, height: 480}
+	track := NewVideoTrack(source, nil).(*VideoTrack)
+	defer track.Close()
+
+	var changes []prop.Media
+	track.Subscribe(func(event TrackEvent) {
+		if changed, ok := event.(TrackSettingsChangedEvent); ok {
+			changes = append(changes, changed.Settings)
+		}
+	})
+
+	reader := track.NewReader(false)
+	if _, _, err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("Expected the initial settings not to be a change, got %v", changes)
+	}
+
+	source.width, source.height = 320, 240
+	if _, _, err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("Expected 1 change, got %v", changes)
+	}
+	if s := changes[0]; s.DeviceID != "source" || s.Width != 320 || s.Height != 240 {
+		t.Errorf("Expected the source to be changed to 320x240, got %dx%d from %s", s.Width, s.Height, s.DeviceID)
+	}
+}
+
+func TestSettingsChanges(t *testing.T) {
+	var changes settingsChanges
+	if changes.changed(prop.Media{Video: prop.Video{Width: 640, Height: 480}}) {
+		t.Error("Expected the initial settings not to be a change")
+	}
+	if changes.changed(prop.Media{Video: prop.Video{Width: 640, Height: 480, FrameRate: 30}}) {
+		t.Error("Expected the first measurement of the frame rate not to be a change")
+	}
+	if !changes.changed(prop.Media{Video: prop.Video{Width: 640, Height: 480, FrameRate: 15}}) {
+		t.Error("Expected the frame rate to be changed")
+	}
+}
@@ -31,10 +31,8 @@ func main() {
 			},
 		},
 	}
-	driver.GetManager().Register(
-		vncdriver.NewVnc("127.0.0.1:5900"),
-		driver.Info{Label: "VNC", DeviceType: driver.Camera, Priority: driver.PriorityLow},
-	)
+	vncAddr := "127.0.0.1:5900"
+	driver.GetManager().Register(vncdriver.NewVnc(vncAddr), vncdriver.Info(vncAddr))
 	// Wait for the offer to be pasted
 	offer := webrtc.SessionDescription{}
 	signal.Decode(signal.MustReadStdin(), &offer)
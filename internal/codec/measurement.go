@@ -2,6 +2,7 @@ package codec
 
 import (
 	"io"
+	"sync"
 	"time"
 
 	mio "github.com/pion/mediadevices/pkg/io"
@@ -44,3 +45,66 @@ func MeasureBitRate(r io.Reader, dur time.Duration) (float64, error) {
 	avg := float64(totalBytes*8) / dur.Seconds()
 	return avg, nil
 }
+
+// RateMeter measures the average rate of an amount, e.g. bits or frames, over a sliding window.
+// Unlike MeasureBitRate, it doesn't read the data by itself, so it can be fed by a live stream.
+type RateMeter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	start   time.Time
+	samples []rateSample
+}
+
+type rateSample struct {
+	amount float64
+	time   time.Time
+}
+
+// NewRateMeter creates a RateMeter that averages the amount over window
+func NewRateMeter(window time.Duration) *RateMeter {
+	return &RateMeter{window: window}
+}
+
+// Add records amount at now
+func (m *RateMeter) Add(amount float64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.start.IsZero() {
+		m.start = now
+	}
+	m.samples = append(m.samples, rateSample{amount: amount, time: now})
+	m.prune(now)
+}
+
+// Rate returns the average amount per second over the window that ends at now
+func (m *RateMeter) Rate(now time.Time) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prune(now)
+
+	// Don't underestimate the rate while the meter hasn't been running for the whole window
+	dur := now.Sub(m.start)
+	if dur > m.window {
+		dur = m.window
+	}
+	if dur <= 0 {
+		return 0
+	}
+
+	var total float64
+	for _, sample := range m.samples {
+		total += sample.amount
+	}
+	return total / dur.Seconds()
+}
+
+// prune removes the samples that are out of the window
+func (m *RateMeter) prune(now time.Time) {
+	i := 0
+	for i < len(m.samples) && now.Sub(m.samples[i].time) >= m.window {
+		i++
+	}
+	m.samples = m.samples[i:]
+}
@@ -117,3 +117,32 @@ func TestMeasureBitRateDynamic(t *testing.T) {
 		t.Fatalf("expected: %f (with %f precision), but got %f", expected, precision, bitrate)
 	}
 }
+
+func TestRateMeter(t *testing.T) {
+	m := NewRateMeter(time.Second)
+	start := time.Now()
+
+	if rate := m.Rate(start); rate != 0 {
+		t.Errorf("Expected 0 before any sample, got %f", rate)
+	}
+
+	for i := 0; i < 10; i++ {
+		m.Add(100, start.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	// The first sample is out of the window that ends at 1s
+	if rate := m.Rate(start.Add(time.Second)); rate != 900 {
+		t.Errorf("Expected 900, got %f", rate)
+	}
+
+	// The rate shouldn't be underestimated before the meter has run for the whole window
+	m = NewRateMeter(time.Second)
+	m.Add(100, start)
+	m.Add(100, start.Add(500*time.Millisecond))
+	if rate := m.Rate(start.Add(500 * time.Millisecond)); rate != 400 {
+		t.Errorf("Expected 400, got %f", rate)
+	}
+
+	if rate := m.Rate(start.Add(2 * time.Second)); rate != 0 {
+		t.Errorf("Expected 0 after all samples are out of the window, got %f", rate)
+	}
+}
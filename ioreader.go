@@ -1,10 +1,17 @@
 package mediadevices
 
-import "github.com/pion/mediadevices/pkg/codec"
+import (
+	"time"
+
+	"github.com/pion/mediadevices/pkg/codec"
+)
 
 type EncodedBuffer struct {
-	Data    []byte
+	Data []byte
+	// Samples is the time since the previous encoded data in the codec's clock rate
 	Samples uint32
+	// Timestamp is the capture time of the encoded data
+	Timestamp time.Time
 }
 
 type EncodedReadCloser interface {
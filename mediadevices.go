@@ -1,9 +1,10 @@
 package mediadevices
 
 import (
+	"context"
 	"fmt"
-	"math"
 	"strings"
+	"time"
 
 	"github.com/pion/mediadevices/pkg/driver"
 	"github.com/pion/mediadevices/pkg/prop"
@@ -15,6 +16,12 @@ var errNotFound = fmt.Errorf("failed to find the best driver that fits the const
 // of a display or portion thereof (such as a window) as a MediaStream.
 // Reference: https://developer.mozilla.org/en-US/docs/Web/API/MediaDevices/getDisplayMedia
 func GetDisplayMedia(constraints MediaStreamConstraints) (MediaStream, error) {
+	return GetDisplayMediaContext(context.Background(), constraints)
+}
+
+// GetDisplayMediaContext is GetDisplayMedia with a context. The context is propagated to the drivers
+// while they are opened and queried, and see GetUserMediaContext for the details.
+func GetDisplayMediaContext(ctx context.Context, constraints MediaStreamConstraints) (MediaStream, error) {
 	trackers := make([]Track, 0)
 
 	cleanTrackers := func() {
@@ -26,7 +33,7 @@ func GetDisplayMedia(constraints MediaStreamConstraints) (MediaStream, error) {
 	var videoConstraints MediaTrackConstraints
 	if constraints.Video != nil {
 		constraints.Video(&videoConstraints)
-		tracker, err := selectScreen(videoConstraints, constraints.Codec)
+		tracker, err := selectScreen(ctx, videoConstraints, constraints)
 		if err != nil {
 			cleanTrackers()
 			return nil, err
@@ -48,6 +55,16 @@ func GetDisplayMedia(constraints MediaStreamConstraints) (MediaStream, error) {
 // with tracks containing the requested types of media.
 // Reference: https://developer.mozilla.org/en-US/docs/Web/API/MediaDevices/getUserMedia
 func GetUserMedia(constraints MediaStreamConstraints) (MediaStream, error) {
+	return GetUserMediaContext(context.Background(), constraints)
+}
+
+// GetUserMediaContext is GetUserMedia with a context. The candidate drivers are opened and queried
+// in parallel, and each of them is given MediaStreamConstraints.ProbeTimeout, so a hung device doesn't
+// block the others. The drivers that fail or time out are skipped. If none of the remaining drivers fits
+// the constraints, *OverconstrainedError is returned with the candidates that have been considered and
+// the constraints that they don't satisfy. It's wrapped by *ProbeError with the drivers that have failed
+// and why, if any. When ctx is done, ctx.Err() is returned.
+func GetUserMediaContext(ctx context.Context, constraints MediaStreamConstraints) (MediaStream, error) {
 	// TODO: It should return media stream based on constraints
 	trackers := make([]Track, 0)
 
@@ -60,7 +77,7 @@ func GetUserMedia(constraints MediaStreamConstraints) (MediaStream, error) {
 	var videoConstraints, audioConstraints MediaTrackConstraints
 	if constraints.Video != nil {
 		constraints.Video(&videoConstraints)
-		tracker, err := selectVideo(videoConstraints, constraints.Codec)
+		tracker, err := selectVideo(ctx, videoConstraints, constraints)
 		if err != nil {
 			cleanTrackers()
 			return nil, err
@@ -71,7 +88,7 @@ func GetUserMedia(constraints MediaStreamConstraints) (MediaStream, error) {
 
 	if constraints.Audio != nil {
 		constraints.Audio(&audioConstraints)
-		tracker, err := selectAudio(audioConstraints, constraints.Codec)
+		tracker, err := selectAudio(ctx, audioConstraints, constraints)
 		if err != nil {
 			cleanTrackers()
 			return nil, err
@@ -89,113 +106,203 @@ func GetUserMedia(constraints MediaStreamConstraints) (MediaStream, error) {
 	return s, nil
 }
 
-func queryDriverProperties(filter driver.FilterFn) map[driver.Driver][]prop.Media {
-	var needToClose []driver.Driver
-	drivers := driver.GetManager().Query(filter)
-	m := make(map[driver.Driver][]prop.Media)
+func queryDriverProperties(ctx context.Context, filter driver.FilterFn, timeout time.Duration) (map[driver.Driver][]prop.Media, []*DriverError) {
+	return probeDrivers(ctx, driver.GetManager().Query(filter), timeout)
+}
 
-	for _, d := range drivers {
-		if d.Status() == driver.StateClosed {
-			err := d.Open()
-			if err != nil {
-				// Skip this driver if we failed to open because we can't get the properties
-				continue
-			}
-			needToClose = append(needToClose, d)
-		}
+// select implements SelectSettings algorithm.
+// Reference: https://w3c.github.io/mediacapture-main/#dfn-selectsettings
+func selectBestDriver(filter driver.FilterFn, constraints MediaTrackConstraints) (driver.Driver, MediaTrackConstraints, error) {
+	return selectBestDriverContext(context.Background(), filter, constraints, defaultProbeTimeout)
+}
 
-		m[d] = d.Properties()
-	}
+// resizePenalty is added to the fitness distance of the settings that are cropped or downscaled
+// when ResizeMode isn't constrained, so the native settings are preferred over the resized settings
+// that fit as well
+const resizePenalty = 1e-3
 
-	for _, d := range needToClose {
-		// Since it was closed, we should close it to avoid a leak
-		d.Close()
-	}
+// unknownFrameRatePenalty is added to the fitness distance of the settings without the frame rate when
+// the frame rate is constrained, so the frame rates that are reported to fit are preferred
+const unknownFrameRatePenalty = 1e-3
 
-	return m
+// driverSettings is a candidate of the selection. settings is p itself, or p resized by the transforms.
+type driverSettings struct {
+	driver      driver.Driver
+	p, settings prop.Media
+	distance    float64
 }
 
-// select implements SelectSettings algorithm.
-// Reference: https://w3c.github.io/mediacapture-main/#dfn-selectsettings
-func selectBestDriver(filter driver.FilterFn, constraints MediaTrackConstraints) (driver.Driver, MediaTrackConstraints, error) {
-	var bestDriver driver.Driver
-	var bestProp prop.Media
+func selectBestDriverContext(ctx context.Context, filter driver.FilterFn, constraints MediaTrackConstraints, probeTimeout time.Duration) (driver.Driver, MediaTrackConstraints, error) {
 	var foundPropertiesLog []string
-	minFitnessDist := math.Inf(1)
 
 	foundPropertiesLog = append(foundPropertiesLog, "\n============ Found Properties ============")
-	driverProperties := queryDriverProperties(filter)
+	driverProperties, driverErrs := queryDriverProperties(ctx, filter, probeTimeout)
+	var candidates []Candidate
+	var fits []driverSettings
 	for d, props := range driverProperties {
 		priority := float64(d.Info().Priority)
 		for _, p := range props {
 			foundPropertiesLog = append(foundPropertiesLog, p.String())
-			fitnessDist, ok := constraints.MediaConstraints.FitnessDistance(p)
-			if !ok {
-				continue
-			}
-			fitnessDist -= priority
-			if fitnessDist < minFitnessDist {
-				minFitnessDist = fitnessDist
-				bestDriver = d
-				bestProp = p
+			for _, settings := range candidateSettings(p, constraints) {
+				fitnessDist, ok := constraints.MediaConstraints.FitnessDistance(settings)
+				if !ok {
+					if settings.ResizeMode != prop.ResizeModeCropAndScale {
+						candidates = append(candidates, newCandidate(d, p, constraints.MediaConstraints))
+					}
+					continue
+				}
+				if settings.ResizeMode == prop.ResizeModeCropAndScale && constraints.ResizeMode == nil {
+					fitnessDist += resizePenalty
+				}
+				if settings.FrameRate == 0 && constraints.FrameRate != nil {
+					fitnessDist += unknownFrameRatePenalty
+				}
+				fits = append(fits, driverSettings{driver: d, p: p, settings: settings, distance: fitnessDist - priority})
 			}
 		}
 	}
 
+	var best *driverSettings
+	fits = selectAdvanced(fits, constraints.Advanced)
+	for i, fit := range fits {
+		if best == nil || fit.distance < best.distance {
+			best = &fits[i]
+		}
+	}
+
+	if len(driverErrs) > 0 {
+		foundPropertiesLog = append(foundPropertiesLog, "============= Failed Drivers =============")
+		for _, err := range driverErrs {
+			foundPropertiesLog = append(foundPropertiesLog, err.Error())
+		}
+	}
+
 	foundPropertiesLog = append(foundPropertiesLog, "=============== Constraints ==============")
 	foundPropertiesLog = append(foundPropertiesLog, constraints.String())
+	for _, advanced := range constraints.Advanced {
+		foundPropertiesLog = append(foundPropertiesLog, advanced.String())
+	}
 	foundPropertiesLog = append(foundPropertiesLog, "================ Best Fit ================")
 
-	if bestDriver == nil {
+	if best == nil {
 		foundPropertiesLog = append(foundPropertiesLog, "Not found")
 		logger.Debug(strings.Join(foundPropertiesLog, "\n\n"))
-		return nil, MediaTrackConstraints{}, errNotFound
+		if err := ctx.Err(); err != nil {
+			return nil, MediaTrackConstraints{}, err
+		}
+		err := errNotFound
+		if len(candidates) > 0 {
+			err = newOverconstrainedError(candidates)
+		}
+		if len(driverErrs) > 0 {
+			return nil, MediaTrackConstraints{}, &ProbeError{Err: err, Drivers: driverErrs}
+		}
+		return nil, MediaTrackConstraints{}, err
 	}
 
-	foundPropertiesLog = append(foundPropertiesLog, bestProp.String())
+	foundPropertiesLog = append(foundPropertiesLog, best.settings.String())
 	logger.Debug(strings.Join(foundPropertiesLog, "\n\n"))
 	constraints.selectedMedia = prop.Media{}
 	constraints.selectedMedia.MergeConstraints(constraints.MediaConstraints)
-	constraints.selectedMedia.Merge(bestProp)
-	return bestDriver, constraints, nil
+	constraints.selectedMedia.Merge(best.p)
+	// The aspect ratio and the resize mode are achieved by the transforms rather than the driver
+	constraints.selectedMedia.AspectRatio = best.p.AspectRatio
+	constraints.selectedMedia.ResizeMode = best.p.ResizeMode
+
+	constraints.settings = constraints.selectedMedia
+	if best.settings.ResizeMode == prop.ResizeModeCropAndScale {
+		constraints.settings.Width, constraints.settings.Height = best.settings.Width, best.settings.Height
+		constraints.settings.AspectRatio = best.settings.AspectRatio
+		constraints.settings.ResizeMode = best.settings.ResizeMode
+	}
+	return best.driver, constraints, nil
+}
+
+// candidateSettings returns the settings that can be produced from p: p itself, and for video, p
+// cropped and downscaled to fit the basic and each of the advanced constraint sets.
+func candidateSettings(p prop.Media, constraints MediaTrackConstraints) []prop.Media {
+	settings := []prop.Media{p}
+	if p.Width <= 0 || p.Height <= 0 {
+		return settings
+	}
+
+	sets := append([]prop.MediaConstraints{constraints.MediaConstraints}, constraints.Advanced...)
+	for _, set := range sets {
+		resized, ok := resizedVideoSettings(p, set)
+		if !ok || containsMedia(settings, resized) {
+			continue
+		}
+		settings = append(settings, resized)
+	}
+	return settings
+}
+
+func containsMedia(props []prop.Media, p prop.Media) bool {
+	for _, prop := range props {
+		if prop == p {
+			return true
+		}
+	}
+	return false
+}
+
+// selectAdvanced narrows fits down by the advanced constraint sets in order. A set is skipped when
+// none of the remaining settings satisfies it. The ideal values of the sets have to be matched exactly.
+// Reference: https://w3c.github.io/mediacapture-main/#dfn-selectsettings
+func selectAdvanced(fits []driverSettings, advanced []prop.MediaConstraints) []driverSettings {
+	for _, set := range advanced {
+		var satisfied []driverSettings
+		for _, fit := range fits {
+			if dist, ok := set.FitnessDistance(fit.settings); ok && dist == 0 {
+				satisfied = append(satisfied, fit)
+			}
+		}
+		if len(satisfied) > 0 {
+			fits = satisfied
+		}
+	}
+	return fits
 }
 
-func selectAudio(constraints MediaTrackConstraints, selector *CodecSelector) (Track, error) {
+func selectAudio(ctx context.Context, constraints MediaTrackConstraints, streamConstraints MediaStreamConstraints) (Track, error) {
 	typeFilter := driver.FilterAudioRecorder()
 
-	d, c, err := selectBestDriver(typeFilter, constraints)
+	d, c, err := selectBestDriverContext(ctx, typeFilter, constraints, streamConstraints.probeTimeout())
 	if err != nil {
 		return nil, err
 	}
 
-	return newTrackFromDriver(d, c, selector)
+	return newTrackFromDriver(ctx, d, c, streamConstraints.Codec)
 }
-func selectVideo(constraints MediaTrackConstraints, selector *CodecSelector) (Track, error) {
+func selectVideo(ctx context.Context, constraints MediaTrackConstraints, streamConstraints MediaStreamConstraints) (Track, error) {
 	typeFilter := driver.FilterVideoRecorder()
 	notScreenFilter := driver.FilterNot(driver.FilterDeviceType(driver.Screen))
 	filter := driver.FilterAnd(typeFilter, notScreenFilter)
 
-	d, c, err := selectBestDriver(filter, constraints)
+	d, c, err := selectBestDriverContext(ctx, filter, constraints, streamConstraints.probeTimeout())
 	if err != nil {
 		return nil, err
 	}
 
-	return newTrackFromDriver(d, c, selector)
+	return newTrackFromDriver(ctx, d, c, streamConstraints.Codec)
 }
 
-func selectScreen(constraints MediaTrackConstraints, selector *CodecSelector) (Track, error) {
+func selectScreen(ctx context.Context, constraints MediaTrackConstraints, streamConstraints MediaStreamConstraints) (Track, error) {
 	typeFilter := driver.FilterVideoRecorder()
 	screenFilter := driver.FilterDeviceType(driver.Screen)
 	filter := driver.FilterAnd(typeFilter, screenFilter)
 
-	d, c, err := selectBestDriver(filter, constraints)
+	d, c, err := selectBestDriverContext(ctx, filter, constraints, streamConstraints.probeTimeout())
 	if err != nil {
 		return nil, err
 	}
 
-	return newTrackFromDriver(d, c, selector)
+	return newTrackFromDriver(ctx, d, c, streamConstraints.Codec)
 }
 
+// EnumerateDevices lists the registered devices. The DeviceIDs are derived from the identities of
+// the devices, so they can be saved and used as DeviceID constraints in the later runs. Use
+// driver.GetManager().SetNamespace to salt them.
 func EnumerateDevices() []MediaDeviceInfo {
 	drivers := driver.GetManager().Query(
 		driver.FilterFn(func(driver.Driver) bool { return true }))
@@ -207,6 +314,8 @@ func EnumerateDevices() []MediaDeviceInfo {
 			kind = VideoInput
 		case driver.FilterAudioRecorder()(d):
 			kind = AudioInput
+		case driver.FilterAudioPlayer()(d):
+			kind = AudioOutput
 		default:
 			continue
 		}
@@ -1,6 +1,7 @@
 package mediadevices
 
 import (
+	"errors"
 	"io"
 	"testing"
 	"time"
@@ -8,7 +9,9 @@ import (
 	"github.com/pion/mediadevices/pkg/driver"
 	_ "github.com/pion/mediadevices/pkg/driver/audiotest"
 	_ "github.com/pion/mediadevices/pkg/driver/videotest"
+	"github.com/pion/mediadevices/pkg/driver/wavfile"
 	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/io/video"
 	"github.com/pion/mediadevices/pkg/prop"
 )
 
@@ -175,6 +178,57 @@ func TestSelectBestDriverConstraintsResultIsSetProperly(t *testing.T) {
 
 }
 
+// propertiesAdapter is a camera that only reports its properties
+type propertiesAdapter []prop.Media
+
+func (a propertiesAdapter) Open() error              { return nil }
+func (a propertiesAdapter) Close() error             { return nil }
+func (a propertiesAdapter) Properties() []prop.Media { return a }
+
+func (a propertiesAdapter) VideoRecord(p prop.Media) (video.Reader, error) { return nil, nil }
+
+func TestSelectBestDriverUnknownFrameRate(t *testing.T) {
+	// The first property has no frame rate, which is the default of the device. The frame rates are
+	// in the ascending order like the ranges of the frame intervals of the cameras.
+	a := &propertiesAdapter{}
+	for _, frameRate := range []float32{0, 5, 15, 30} {
+		*a = append(*a, prop.Media{Video: prop.Video{Width: 640, Height: 480, FrameRate: frameRate}})
+	}
+	id, err := driver.GetManager().RegisterAdapter(a, driver.Info{Label: "unknown-frame-rate", DeviceType: driver.Camera})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.GetManager().Unregister(id)
+
+	for name, c := range map[string]struct {
+		frameRate prop.FloatConstraint
+		expected  float32
+	}{
+		"Unconstrained": {frameRate: nil, expected: 0},
+		"Exact":         {frameRate: prop.FloatExact(15), expected: 15},
+		"Ideal":         {frameRate: prop.Float(30), expected: 30},
+		"Ranged":        {frameRate: prop.FloatRanged{Min: 10, Max: 20}, expected: 15},
+		// The property without the frame rate fits any constraint, and the frame rate is taken from it
+		"Unsupported": {frameRate: prop.FloatExact(45), expected: 45},
+	} {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			constraints := MediaTrackConstraints{
+				MediaConstraints: prop.MediaConstraints{
+					VideoConstraints: prop.VideoConstraints{FrameRate: c.frameRate},
+				},
+			}
+			_, selected, err := selectBestDriver(driver.FilterID(id), constraints)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if frameRate := selected.selectedMedia.FrameRate; frameRate != c.expected {
+				t.Errorf("Expected the frame rate %v, got %v", c.expected, frameRate)
+			}
+		})
+	}
+}
+
 func TestSelectBestDriverConstraintsNoFit(t *testing.T) {
 	filterFn := driver.FilterVideoRecorder()
 	drivers := driver.GetManager().Query(filterFn)
@@ -240,3 +294,198 @@ func TestSelectBestDriverConstraintsNoFit(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUserMediaOverconstrained(t *testing.T) {
+	_, err := GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.Width = prop.IntExact(10000)
+			c.Height = prop.Int(480)
+		},
+	})
+
+	var overconstrained *OverconstrainedError
+	if !errors.As(err, &overconstrained) {
+		t.Fatalf("Expected OverconstrainedError, got %v", err)
+	}
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Expected the error to be %v", errNotFound)
+	}
+	if overconstrained.Constraint != "Width" {
+		t.Errorf("Expected Width to be the unsatisfied constraint, got %s", overconstrained.Constraint)
+	}
+	if len(overconstrained.Candidates) == 0 {
+		t.Fatal("Expected the candidates to be reported")
+	}
+
+	closest := overconstrained.Candidates[0]
+	unsatisfied := closest.Unsatisfied()
+	if len(unsatisfied) != 1 || unsatisfied[0].Name != "Width" || unsatisfied[0].Actual != closest.Media.Width {
+		t.Errorf("Expected the closest candidate to only miss the width, got %v", unsatisfied)
+	}
+}
+
+func TestGetUserMediaFrameRate(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.FrameRate = prop.FloatExact(60)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetVideoTracks()[0]
+	settings := track.GetSettings()
+	track.Close()
+	if settings.FrameRate != 60 {
+		t.Errorf("Expected the 60 fps mode to be selected, got %v fps", settings.FrameRate)
+	}
+
+	_, err = GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.FrameRate = prop.FloatExact(20)
+		},
+	})
+	var overconstrained *OverconstrainedError
+	if !errors.As(err, &overconstrained) || overconstrained.Constraint != "FrameRate" {
+		t.Errorf("Expected the frame rate to be overconstrained, got %v", err)
+	}
+}
+
+func TestGetUserMediaResizeMode(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.Width = prop.IntExact(320)
+			c.Height = prop.IntExact(180)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetVideoTracks()[0].(*VideoTrack)
+	defer track.Close()
+
+	// The driver only supports 640x480, so the frames should be cropped to 16:9 and downscaled
+	settings := track.GetSettings()
+	if settings.Width != 320 || settings.Height != 180 || settings.ResizeMode != prop.ResizeModeCropAndScale {
+		t.Errorf("Expected 320x180 with %s, got %dx%d with %s",
+			prop.ResizeModeCropAndScale, settings.Width, settings.Height, settings.ResizeMode)
+	}
+
+	reader := track.NewReader(false)
+	img, _, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 320 || bounds.Dy() != 180 {
+		t.Errorf("Expected the frames to be 320x180, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	_, err = GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.Width = prop.IntExact(320)
+			c.Height = prop.IntExact(180)
+			c.ResizeMode = prop.StringExact(prop.ResizeModeNone)
+		},
+	})
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Expected the native sizes only to be overconstrained, got %v", err)
+	}
+}
+
+func TestGetUserMediaAspectRatio(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.AspectRatio = prop.FloatExact(16.0 / 9)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetVideoTracks()[0].(*VideoTrack)
+	defer track.Close()
+
+	settings := track.GetSettings()
+	if settings.Width != 640 || settings.Height != 360 || settings.AspectRatio != 16.0/9 {
+		t.Errorf("Expected 640x360 cropped to 16:9, got %dx%d at %v", settings.Width, settings.Height, settings.AspectRatio)
+	}
+
+	img, _, err := track.NewReader(false).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 640 || bounds.Dy() != 360 {
+		t.Errorf("Expected the frames to be 640x360, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestGetUserMediaAdvanced(t *testing.T) {
+	cases := map[string]struct {
+		advanced             []prop.MediaConstraints
+		expectedW, expectedH int
+	}{
+		"FirstSatisfied": {
+			advanced: []prop.MediaConstraints{
+				{VideoConstraints: prop.VideoConstraints{Width: prop.Int(320), Height: prop.Int(240)}},
+				{VideoConstraints: prop.VideoConstraints{Width: prop.Int(160), AspectRatio: prop.Float(16.0 / 9)}},
+			},
+			expectedW: 320, expectedH: 240,
+		},
+		"FirstSkipped": {
+			// "prefer 1080p, else 16:9"
+			advanced: []prop.MediaConstraints{
+				{VideoConstraints: prop.VideoConstraints{Width: prop.Int(1920), Height: prop.Int(1080)}},
+				{VideoConstraints: prop.VideoConstraints{Width: prop.Int(320), AspectRatio: prop.Float(16.0 / 9)}},
+			},
+			expectedW: 320, expectedH: 180,
+		},
+		"NoneSatisfied": {
+			advanced: []prop.MediaConstraints{
+				{VideoConstraints: prop.VideoConstraints{Width: prop.Int(1920), Height: prop.Int(1080)}},
+			},
+			expectedW: 640, expectedH: 480,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			stream, err := GetUserMedia(MediaStreamConstraints{
+				Video: func(constraints *MediaTrackConstraints) {
+					constraints.Advanced = c.advanced
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			track := stream.GetVideoTracks()[0]
+			defer track.Close()
+
+			if settings := track.GetSettings(); settings.Width != c.expectedW || settings.Height != c.expectedH {
+				t.Errorf("Expected %dx%d, got %dx%d", c.expectedW, c.expectedH, settings.Width, settings.Height)
+			}
+		})
+	}
+}
+
+func TestEnumerateDevices(t *testing.T) {
+	sink := wavfile.NewSink("unused.wav", prop.Audio{ChannelCount: 1, SampleRate: 48000, SampleSize: 2})
+	if err := driver.GetManager().Register(sink, driver.Info{Label: "wav-sink", DeviceType: driver.Speaker}); err != nil {
+		t.Fatal(err)
+	}
+	defer driver.GetManager().UnregisterAdapter(sink)
+
+	kinds := make(map[string]MediaDeviceType)
+	for _, info := range EnumerateDevices() {
+		kinds[info.Label] = info.Kind
+	}
+	expected := map[string]MediaDeviceType{
+		"VideoTest": VideoInput,
+		"AudioTest": AudioInput,
+		"wav-sink":  AudioOutput,
+	}
+	for label, kind := range expected {
+		if kinds[label] != kind {
+			t.Errorf("Expected %s to be listed as %v, got %v", label, kind, kinds[label])
+		}
+	}
+}
@@ -3,11 +3,14 @@ package mediadevices
 import (
 	"sync"
 
+	"github.com/google/uuid"
 	"github.com/pion/webrtc/v3"
 )
 
 // MediaStream is an interface that represents a collection of existing tracks.
 type MediaStream interface {
+	// ID implements https://w3c.github.io/mediacapture-main/#dom-mediastream-id
+	ID() string
 	// GetAudioTracks implements https://w3c.github.io/mediacapture-main/#dom-mediastream-getaudiotracks
 	GetAudioTracks() []Track
 	// GetVideoTracks implements https://w3c.github.io/mediacapture-main/#dom-mediastream-getvideotracks
@@ -18,29 +21,81 @@ type MediaStream interface {
 	AddTrack(t Track)
 	// RemoveTrack implements https://w3c.github.io/mediacapture-main/#dom-mediastream-removetrack
 	RemoveTrack(t Track)
+	// Subscribe registers a handler to receive the addtrack and removetrack events of the stream,
+	// and returns a function to unsubscribe.
+	Subscribe(func(MediaStreamEvent)) (unsubscribe func())
+	// Close ends all the tracks of the stream
+	Close() error
+}
+
+// MediaStreamEvent is an event of a stream. It's either MediaStreamAddTrackEvent or MediaStreamRemoveTrackEvent.
+// Reference: https://w3c.github.io/mediacapture-main/#mediastreamtrackevent
+type MediaStreamEvent interface {
+	isMediaStreamEvent()
+}
+
+// MediaStreamAddTrackEvent is emitted when a track has been added to the stream
+type MediaStreamAddTrackEvent struct {
+	Track Track
+}
+
+// MediaStreamRemoveTrackEvent is emitted when a track has been removed from the stream
+type MediaStreamRemoveTrackEvent struct {
+	Track Track
+}
+
+func (MediaStreamAddTrackEvent) isMediaStreamEvent()    {}
+func (MediaStreamRemoveTrackEvent) isMediaStreamEvent() {}
+
+// streamIDSetter is implemented by the tracks that can be grouped by a stream
+type streamIDSetter interface {
+	setStreamID(id string)
 }
 
 type mediaStream struct {
+	id     string
 	tracks map[Track]struct{}
 	l      sync.RWMutex
+
+	subscribersMu sync.Mutex
+	subscribers   []*mediaStreamSubscriber
+}
+
+type mediaStreamSubscriber struct {
+	handler func(MediaStreamEvent)
 }
 
 const trackTypeDefault webrtc.RTPCodecType = 0
 
 // NewMediaStream creates a MediaStream interface that's defined in
 // https://w3c.github.io/mediacapture-main/#dom-mediastream
+//
+// The stream has a unique ID, which is set as the StreamID of the tracks that are added to the stream,
+// so the tracks are grouped, and can be synchronized, by the remote peers. Since a track has only one
+// StreamID, a track that is added to multiple streams takes the ID of the last one. The StreamID
+// has to be set before the track is added to a peer connection to have an effect on the negotiation.
 func NewMediaStream(tracks ...Track) (MediaStream, error) {
-	m := mediaStream{tracks: make(map[Track]struct{})}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	m := mediaStream{id: id.String(), tracks: make(map[Track]struct{})}
 
 	for _, track := range tracks {
 		if _, ok := m.tracks[track]; !ok {
 			m.tracks[track] = struct{}{}
+			m.group(track)
 		}
 	}
 
 	return &m, nil
 }
 
+func (m *mediaStream) ID() string {
+	return m.id
+}
+
 func (m *mediaStream) GetAudioTracks() []Track {
 	return m.queryTracks(webrtc.RTPCodecTypeAudio)
 }
@@ -71,18 +126,78 @@ func (m *mediaStream) queryTracks(t webrtc.RTPCodecType) []Track {
 
 func (m *mediaStream) AddTrack(t Track) {
 	m.l.Lock()
-	defer m.l.Unlock()
-
 	if _, ok := m.tracks[t]; ok {
+		m.l.Unlock()
 		return
 	}
 
 	m.tracks[t] = struct{}{}
+	m.group(t)
+	m.l.Unlock()
+
+	m.emit(MediaStreamAddTrackEvent{Track: t})
 }
 
 func (m *mediaStream) RemoveTrack(t Track) {
 	m.l.Lock()
-	defer m.l.Unlock()
+	if _, ok := m.tracks[t]; !ok {
+		m.l.Unlock()
+		return
+	}
 
 	delete(m.tracks, t)
+	m.l.Unlock()
+
+	m.emit(MediaStreamRemoveTrackEvent{Track: t})
+}
+
+// Subscribe registers handler to receive the events of the stream, and returns a function to unsubscribe.
+// The events are emitted by AddTrack and RemoveTrack only when the tracks of the stream have changed.
+// The handlers are called synchronously from the goroutine that changed the stream.
+func (m *mediaStream) Subscribe(handler func(MediaStreamEvent)) (unsubscribe func()) {
+	m.subscribersMu.Lock()
+	defer m.subscribersMu.Unlock()
+
+	subscriber := &mediaStreamSubscriber{handler: handler}
+	m.subscribers = append(m.subscribers, subscriber)
+
+	return func() {
+		m.subscribersMu.Lock()
+		defer m.subscribersMu.Unlock()
+		for i, s := range m.subscribers {
+			if s == subscriber {
+				m.subscribers = append(m.subscribers[:i:i], m.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Close closes all the tracks of the stream. The tracks stay in the stream after they have ended.
+// The first error of closing the tracks is returned.
+func (m *mediaStream) Close() error {
+	var closeErr error
+	for _, track := range m.GetTracks() {
+		if err := track.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// group sets the ID of the stream as the StreamID of t
+func (m *mediaStream) group(t Track) {
+	if setter, ok := t.(streamIDSetter); ok {
+		setter.setStreamID(m.id)
+	}
+}
+
+func (m *mediaStream) emit(event MediaStreamEvent) {
+	m.subscribersMu.Lock()
+	subscribers := m.subscribers
+	m.subscribersMu.Unlock()
+
+	for _, s := range subscribers {
+		s.handler(event)
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"io"
 	"testing"
 
+	"github.com/pion/mediadevices/pkg/prop"
 	"github.com/pion/webrtc/v3"
 )
 
@@ -41,6 +42,10 @@ func (track *mockMediaStreamTrack) Kind() webrtc.RTPCodecType {
 func (track *mockMediaStreamTrack) OnEnded(handler func(error)) {
 }
 
+func (track *mockMediaStreamTrack) Subscribe(handler func(TrackEvent)) func() {
+	return func() {}
+}
+
 func (track *mockMediaStreamTrack) Bind(ctx webrtc.TrackLocalContext) (webrtc.RTPCodecParameters, error) {
 	return webrtc.RTPCodecParameters{}, nil
 }
@@ -61,6 +66,31 @@ func (track *mockMediaStreamTrack) NewEncodedIOReader(codecName string) (io.Read
 	return nil, nil
 }
 
+func (track *mockMediaStreamTrack) ApplyConstraints(constraints MediaTrackConstraints) error {
+	return nil
+}
+
+func (track *mockMediaStreamTrack) GetSettings() prop.Media {
+	return prop.Media{}
+}
+
+func (track *mockMediaStreamTrack) GetCapabilities() prop.MediaCapabilities {
+	return prop.MediaCapabilities{}
+}
+
+func (track *mockMediaStreamTrack) Enabled() bool {
+	return true
+}
+
+func (track *mockMediaStreamTrack) SetEnabled(enabled bool) {
+}
+
+func (track *mockMediaStreamTrack) OnMute(handler func()) {
+}
+
+func (track *mockMediaStreamTrack) OnUnmute(handler func()) {
+}
+
 func TestMediaStreamFilters(t *testing.T) {
 	audioTracks := []Track{
 		&mockMediaStreamTrack{AudioInput},
@@ -114,3 +144,92 @@ func TestMediaStreamFilters(t *testing.T) {
 		expect(t, stream.GetTracks(), tracks)
 	})
 }
+
+func TestMediaStreamID(t *testing.T) {
+	videoTrack := NewVideoTrack(&fakeVideoSource{width: 320, height: 240}, nil)
+	defer videoTrack.Close()
+	if videoTrack.StreamID() != videoTrack.StreamID() {
+		t.Error("Expected the StreamID of the track to be stable")
+	}
+
+	stream, err := NewMediaStream(videoTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.ID() == "" {
+		t.Fatal("Expected the stream to have an ID")
+	}
+	if videoTrack.StreamID() != stream.ID() {
+		t.Errorf("Expected the track to have StreamID %s, got %s", stream.ID(), videoTrack.StreamID())
+	}
+
+	otherTrack := NewVideoTrack(&fakeVideoSource{width: 320, height: 240}, nil)
+	defer otherTrack.Close()
+	stream.AddTrack(otherTrack)
+	if otherTrack.StreamID() != stream.ID() {
+		t.Errorf("Expected the added track to have StreamID %s, got %s", stream.ID(), otherTrack.StreamID())
+	}
+
+	other, err := NewMediaStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID() == stream.ID() {
+		t.Error("Expected the streams to have distinct IDs")
+	}
+}
+
+func TestMediaStreamEvents(t *testing.T) {
+	stream, err := NewMediaStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []MediaStreamEvent
+	unsubscribe := stream.Subscribe(func(event MediaStreamEvent) { events = append(events, event) })
+
+	track := &mockMediaStreamTrack{VideoInput}
+	stream.AddTrack(track)
+	stream.AddTrack(track)
+	stream.RemoveTrack(track)
+	stream.RemoveTrack(track)
+
+	expected := []MediaStreamEvent{MediaStreamAddTrackEvent{Track: track}, MediaStreamRemoveTrackEvent{Track: track}}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, events)
+		}
+	}
+
+	unsubscribe()
+	stream.AddTrack(track)
+	if len(events) != len(expected) {
+		t.Errorf("Expected no events after unsubscribing, got %v", events[len(expected):])
+	}
+}
+
+func TestMediaStreamClose(t *testing.T) {
+	sources := []*fakeVideoSource{
+		{id: "first", width: 320, height: 240},
+		{id: "second", width: 320, height: 240},
+	}
+	stream, err := NewMediaStream(NewVideoTrack(sources[0], nil), NewVideoTrack(sources[1], nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range sources {
+		if !source.closed {
+			t.Errorf("Expected %s to be closed", source.id)
+		}
+	}
+	if len(stream.GetTracks()) != len(sources) {
+		t.Error("Expected the ended tracks to stay in the stream")
+	}
+}
@@ -1,6 +1,10 @@
 package mediadevices
 
 import (
+	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/pion/mediadevices/pkg/prop"
 )
 
@@ -8,12 +12,134 @@ type MediaStreamConstraints struct {
 	Audio MediaOption
 	Video MediaOption
 	Codec *CodecSelector
+	// ProbeTimeout limits the time to open and query each of the candidate drivers. If it's zero,
+	// the drivers are given 5 seconds.
+	ProbeTimeout time.Duration
+}
+
+func (c *MediaStreamConstraints) probeTimeout() time.Duration {
+	if c.ProbeTimeout == 0 {
+		return defaultProbeTimeout
+	}
+	return c.ProbeTimeout
 }
 
 // MediaTrackConstraints represents https://w3c.github.io/mediacapture-main/#dom-mediatrackconstraints
 type MediaTrackConstraints struct {
 	prop.MediaConstraints
+	// Advanced are the sets of constraints that are applied in order. Each of them narrows the
+	// properties down if some of the properties satisfy it, and is skipped otherwise. Unlike
+	// MediaConstraints, the ideal values of the advanced sets have to be matched exactly.
+	// Reference: https://w3c.github.io/mediacapture-main/#dom-mediatrackconstraints-advanced
+	Advanced []prop.MediaConstraints
+
+	// selectedMedia is the properties that the driver records with, and settings is the properties
+	// of the media after the transforms that are needed to satisfy the constraints, e.g. cropping.
 	selectedMedia prop.Media
+	settings      prop.Media
 }
 
 type MediaOption func(*MediaTrackConstraints)
+
+// MarshalJSON implements json.Marshaler in the same shape as MediaTrackConstraints of the browsers,
+// including the advanced constraint sets.
+func (c MediaTrackConstraints) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(c.MediaConstraints)
+	if err != nil || len(c.Advanced) == 0 {
+		return b, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	if fields["advanced"], err = json.Marshal(c.Advanced); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements json.Unmarshaler in the same shape as MediaTrackConstraints of the browsers,
+// including the advanced constraint sets.
+func (c *MediaTrackConstraints) UnmarshalJSON(b []byte) error {
+	var advanced struct {
+		Advanced []prop.MediaConstraints `json:"advanced"`
+	}
+	if err := json.Unmarshal(b, &advanced); err != nil {
+		return err
+	}
+
+	var constraints prop.MediaConstraints
+	if err := json.Unmarshal(b, &constraints); err != nil {
+		return err
+	}
+
+	*c = MediaTrackConstraints{MediaConstraints: constraints, Advanced: advanced.Advanced}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler in the same shape as MediaStreamConstraints of the browsers,
+// e.g. {"audio": true, "video": {"width": {"ideal": 1280}}}. Audio and Video are called to get their
+// constraints, and the media without constraints are true. Codec and ProbeTimeout aren't serialized.
+func (c MediaStreamConstraints) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	for name, option := range map[string]MediaOption{"audio": c.Audio, "video": c.Video} {
+		if option == nil {
+			continue
+		}
+		var constraints MediaTrackConstraints
+		option(&constraints)
+		b, err := json.Marshal(constraints)
+		if err != nil {
+			return nil, err
+		}
+		if string(b) == "{}" {
+			b = []byte("true")
+		}
+		fields[name] = b
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements json.Unmarshaler in the same shape as MediaStreamConstraints of the browsers.
+// true requests the media without constraints, and false or a missing field doesn't request the media.
+// Codec and ProbeTimeout are left unchanged.
+func (c *MediaStreamConstraints) UnmarshalJSON(b []byte) error {
+	var fields struct {
+		Audio json.RawMessage `json:"audio"`
+		Video json.RawMessage `json:"video"`
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	audio, err := unmarshalMediaOption(fields.Audio)
+	if err != nil {
+		return fmt.Errorf("audio: %w", err)
+	}
+	video, err := unmarshalMediaOption(fields.Video)
+	if err != nil {
+		return fmt.Errorf("video: %w", err)
+	}
+
+	c.Audio, c.Video = audio, video
+	return nil
+}
+
+func unmarshalMediaOption(b json.RawMessage) (MediaOption, error) {
+	var requested bool
+	if b == nil || json.Unmarshal(b, &requested) == nil {
+		if !requested {
+			return nil, nil
+		}
+		return func(*MediaTrackConstraints) {}, nil
+	}
+
+	var constraints MediaTrackConstraints
+	if err := json.Unmarshal(b, &constraints); err != nil {
+		return nil, err
+	}
+	return func(c *MediaTrackConstraints) {
+		*c = constraints
+	}, nil
+}
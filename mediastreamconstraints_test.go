@@ -0,0 +1,77 @@
+package mediadevices
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+func TestMediaTrackConstraintsJSON(t *testing.T) {
+	constraints := MediaTrackConstraints{
+		MediaConstraints: prop.MediaConstraints{
+			VideoConstraints: prop.VideoConstraints{
+				Width:     prop.Int(1280),
+				FrameRate: prop.FloatRanged{Max: 30},
+			},
+		},
+		Advanced: []prop.MediaConstraints{
+			{VideoConstraints: prop.VideoConstraints{Width: prop.Int(1920), Height: prop.Int(1080)}},
+			{VideoConstraints: prop.VideoConstraints{AspectRatio: prop.Float(16.0 / 9)}},
+		},
+	}
+
+	b, err := json.Marshal(constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded MediaTrackConstraints
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(constraints, decoded) {
+		t.Errorf("Expected %+v, got %+v from %s", constraints, decoded, b)
+	}
+}
+
+func TestMediaStreamConstraintsJSON(t *testing.T) {
+	var constraints MediaStreamConstraints
+	err := json.Unmarshal([]byte(`{"audio": true, "video": {"width": {"ideal": 1280}, "advanced": [{"height": 720}]}}`), &constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if constraints.Audio == nil || constraints.Video == nil {
+		t.Fatal("Expected both audio and video to be requested")
+	}
+
+	var video MediaTrackConstraints
+	constraints.Video(&video)
+	expected := MediaTrackConstraints{
+		MediaConstraints: prop.MediaConstraints{
+			VideoConstraints: prop.VideoConstraints{Width: prop.Int(1280)},
+		},
+		Advanced: []prop.MediaConstraints{
+			{VideoConstraints: prop.VideoConstraints{Height: prop.Int(720)}},
+		},
+	}
+	if !reflect.DeepEqual(expected, video) {
+		t.Errorf("Expected %+v, got %+v", expected, video)
+	}
+
+	b, err := json.Marshal(constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"audio":true,"video":{"advanced":[{"height":{"ideal":720}}],"width":{"ideal":1280}}}`; string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	if err := json.Unmarshal([]byte(`{"audio": false}`), &constraints); err != nil {
+		t.Fatal(err)
+	}
+	if constraints.Audio != nil || constraints.Video != nil {
+		t.Error("Expected neither audio nor video to be requested")
+	}
+}
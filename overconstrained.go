@@ -0,0 +1,92 @@
+package mediadevices
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+// OverconstrainedError is returned when none of the properties of the drivers satisfies the constraints.
+// It unwraps to the same error that has been returned before, so errors.Is keeps working.
+// Reference: https://w3c.github.io/mediacapture-main/#overconstrainederror-interface
+type OverconstrainedError struct {
+	// Constraint is the name of a constraint that couldn't be satisfied, e.g. "Width". It's taken from
+	// the closest candidate.
+	Constraint string
+	// Candidates are all the properties that have been considered, sorted from the closest to the
+	// constraints. The candidates with fewer unsatisfied constraints come first, and then the ones with
+	// smaller fitness distances.
+	Candidates []Candidate
+}
+
+// Candidate is a set of the properties of a driver that has been considered by the selection
+type Candidate struct {
+	DeviceID string
+	Label    string
+	Media    prop.Media
+	// Results are the comparisons of the properties with each of the constraints
+	Results []prop.ConstraintResult
+	// Distance is the sum of the fitness distances of all the constraints, including the unsatisfied ones
+	Distance float64
+}
+
+// Unsatisfied returns the comparisons of the constraints that the candidate doesn't satisfy
+func (c *Candidate) Unsatisfied() []prop.ConstraintResult {
+	var unsatisfied []prop.ConstraintResult
+	for _, r := range c.Results {
+		if !r.Satisfied {
+			unsatisfied = append(unsatisfied, r)
+		}
+	}
+	return unsatisfied
+}
+
+func (c *Candidate) String() string {
+	unsatisfied := make([]string, 0)
+	for _, r := range c.Unsatisfied() {
+		unsatisfied = append(unsatisfied, r.String())
+	}
+	return fmt.Sprintf("%s (%s): %s, distance %.2f", c.Label, c.DeviceID, strings.Join(unsatisfied, ", "), c.Distance)
+}
+
+func newCandidate(d driver.Driver, p prop.Media, constraints prop.MediaConstraints) Candidate {
+	c := Candidate{
+		DeviceID: d.ID(),
+		Label:    d.Info().Label,
+		Media:    p,
+		Results:  constraints.Compare(p),
+	}
+	for _, r := range c.Results {
+		c.Distance += r.Distance
+	}
+	return c
+}
+
+// newOverconstrainedError creates an error from the candidates that don't satisfy the constraints
+func newOverconstrainedError(candidates []Candidate) *OverconstrainedError {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ui, uj := len(candidates[i].Unsatisfied()), len(candidates[j].Unsatisfied())
+		if ui != uj {
+			return ui < uj
+		}
+		return candidates[i].Distance < candidates[j].Distance
+	})
+
+	e := &OverconstrainedError{Candidates: candidates}
+	if unsatisfied := candidates[0].Unsatisfied(); len(unsatisfied) > 0 {
+		e.Constraint = unsatisfied[0].Name
+	}
+	return e
+}
+
+func (e *OverconstrainedError) Error() string {
+	return fmt.Sprintf("%v: %s can't be satisfied, and the closest of %d candidate(s) is %s",
+		errNotFound, e.Constraint, len(e.Candidates), &e.Candidates[0])
+}
+
+func (e *OverconstrainedError) Unwrap() error {
+	return errNotFound
+}
@@ -3,8 +3,8 @@ package audiotest
 
 import (
 	"context"
+	"fmt"
 	"io"
-	"math"
 	"time"
 
 	"github.com/pion/mediadevices/pkg/driver"
@@ -15,15 +15,48 @@ import (
 
 func init() {
 	driver.GetManager().Register(
-		&dummy{}, driver.Info{Label: "AudioTest", DeviceType: driver.Microphone},
+		newAudioTest(Config{}), driver.Info{Label: "AudioTest", DeviceType: driver.Microphone},
 	)
 }
 
+// Config is the configuration of a test audio
+type Config struct {
+	// Label is the label of the driver. It defaults to "AudioTest".
+	Label string
+	// SampleRate is the sample rate of the audio. It defaults to 48000.
+	SampleRate int
+	// Signal generates the samples of all the channels. It defaults to a 480 Hz tone.
+	Signal Signal
+}
+
+// Register registers a test audio of c as a Microphone device, and returns the ID of its driver
+func Register(c Config) (string, error) {
+	if c.Label == "" {
+		c.Label = "AudioTest"
+	}
+	if c.SampleRate < 0 {
+		return "", fmt.Errorf("audiotest: invalid sample rate %d", c.SampleRate)
+	}
+
+	return driver.GetManager().RegisterAdapter(newAudioTest(c), driver.Info{Label: c.Label, DeviceType: driver.Microphone})
+}
+
 type dummy struct {
+	config Config
 	closed <-chan struct{}
 	cancel func()
 }
 
+func newAudioTest(c Config) *dummy {
+	if c.SampleRate == 0 {
+		c.SampleRate = 48000
+	}
+	if c.Signal == nil {
+		c.Signal = Tone(480, 0.25)
+	}
+	return &dummy{config: c}
+}
+
 func (d *dummy) Open() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	d.closed = ctx.Done()
@@ -37,10 +70,7 @@ func (d *dummy) Close() error {
 }
 
 func (d *dummy) AudioRecord(p prop.Media) (audio.Reader, error) {
-	var sin [100]float32
-	for i := range sin {
-		sin[i] = float32(math.Sin(2*math.Pi*float64(i)/100) * 0.25) // 480 Hz
-	}
+	signal := d.config.Signal
 
 	if p.Latency == 0 {
 		p.Latency = 20 * time.Millisecond
@@ -48,7 +78,7 @@ func (d *dummy) AudioRecord(p prop.Media) (audio.Reader, error) {
 	nSample := int(uint64(p.SampleRate) * uint64(p.Latency) / uint64(time.Second))
 
 	nextReadTime := time.Now()
-	var phase int
+	var timestamp time.Time
 
 	closed := d.closed
 
@@ -60,6 +90,7 @@ func (d *dummy) AudioRecord(p prop.Media) (audio.Reader, error) {
 		}
 
 		time.Sleep(nextReadTime.Sub(time.Now()))
+		timestamp = nextReadTime
 		nextReadTime = nextReadTime.Add(p.Latency)
 
 		a := wave.NewFloat32Interleaved(
@@ -70,32 +101,32 @@ func (d *dummy) AudioRecord(p prop.Media) (audio.Reader, error) {
 			},
 		)
 
+		start := time.Duration(timestamp.UnixNano())
 		for i := 0; i < nSample; i++ {
-			phase++
-			if phase >= 100 {
-				phase = 0
-			}
+			v := signal(start + time.Duration(i)*time.Second/time.Duration(p.SampleRate))
 			for ch := 0; ch < p.ChannelCount; ch++ {
-				a.SetFloat32(i, ch, wave.Float32Sample(sin[phase]))
+				a.SetFloat32(i, ch, wave.Float32Sample(v))
 			}
 		}
 		return a, func() {}, nil
 	})
-	return reader, nil
+	return audio.WithTimestamp(reader, func() time.Time {
+		return timestamp
+	}), nil
 }
 
 func (d *dummy) Properties() []prop.Media {
 	return []prop.Media{
 		{
 			Audio: prop.Audio{
-				SampleRate:   48000,
+				SampleRate:   d.config.SampleRate,
 				Latency:      time.Millisecond * 20,
 				ChannelCount: 1,
 			},
 		},
 		{
 			Audio: prop.Audio{
-				SampleRate:   48000,
+				SampleRate:   d.config.SampleRate,
 				Latency:      time.Millisecond * 20,
 				ChannelCount: 2,
 			},
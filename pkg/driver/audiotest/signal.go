@@ -0,0 +1,97 @@
+package audiotest
+
+import (
+	"math"
+	"time"
+)
+
+// Signal returns the level of the sample at t, which ranges within [-1, 1]. t is the time of the
+// sample since the Unix epoch, so the periodic signals, e.g. Beep, are aligned to the wall clock and
+// can be compared with the timestamps of a video for A/V sync measurement.
+type Signal func(t time.Duration) float32
+
+// phase returns the phase of the wave of freq at t in radians. The whole seconds and the fraction
+// of t are multiplied separately to keep the precision of the large t.
+func phase(freq float64, t time.Duration) float64 {
+	cycles := freq * float64(t/time.Second)
+	cycles -= math.Floor(cycles)
+	cycles += freq * float64(t%time.Second) / float64(time.Second)
+	return 2 * math.Pi * (cycles - math.Floor(cycles))
+}
+
+// Tone generates a sine wave of freq Hz
+func Tone(freq float64, amplitude float32) Signal {
+	return func(t time.Duration) float32 {
+		return amplitude * float32(math.Sin(phase(freq, t)))
+	}
+}
+
+// Sweep generates a sine wave whose frequency rises linearly from the from Hz to the to Hz in every
+// duration
+func Sweep(from, to float64, duration time.Duration, amplitude float32) Signal {
+	if duration <= 0 {
+		return Tone(from, amplitude)
+	}
+	return func(t time.Duration) float32 {
+		// The phase is the integral of the frequency since the start of the sweep
+		elapsed := (t % duration).Seconds()
+		cycles := from*elapsed + (to-from)*elapsed*elapsed/(2*duration.Seconds())
+		return amplitude * float32(math.Sin(2*math.Pi*cycles))
+	}
+}
+
+// dtmfFrequencies are the low and high frequencies of the DTMF digits
+var dtmfFrequencies = map[rune][2]float64{
+	'1': {697, 1209}, '2': {697, 1336}, '3': {697, 1477}, 'A': {697, 1633},
+	'4': {770, 1209}, '5': {770, 1336}, '6': {770, 1477}, 'B': {770, 1633},
+	'7': {852, 1209}, '8': {852, 1336}, '9': {852, 1477}, 'C': {852, 1633},
+	'*': {941, 1209}, '0': {941, 1336}, '#': {941, 1477}, 'D': {941, 1633},
+}
+
+// DTMF generates the DTMF tones of digits repeatedly. Each of the digits is played for toneDuration,
+// and followed by the silence of gapDuration. The characters other than the DTMF digits are silent.
+func DTMF(digits string, toneDuration, gapDuration time.Duration, amplitude float32) Signal {
+	symbols := []rune(digits)
+	period := toneDuration + gapDuration
+	return func(t time.Duration) float32 {
+		if len(symbols) == 0 || period <= 0 {
+			return 0
+		}
+		elapsed := t % (period * time.Duration(len(symbols)))
+		if elapsed%period >= toneDuration {
+			return 0
+		}
+		freqs, ok := dtmfFrequencies[symbols[elapsed/period]]
+		if !ok {
+			return 0
+		}
+		return amplitude / 2 * float32(math.Sin(phase(freqs[0], t))+math.Sin(phase(freqs[1], t)))
+	}
+}
+
+// WhiteNoise generates white noise. The noise is derived from t, so it's reproducible.
+func WhiteNoise(amplitude float32) Signal {
+	return func(t time.Duration) float32 {
+		// splitmix64
+		x := uint64(t) + 0x9e3779b97f4a7c15
+		x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+		x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+		x ^= x >> 31
+		return amplitude * (float32(x>>40)/(1<<23) - 1)
+	}
+}
+
+// Beep generates a tone of freq Hz for duration at every period, which is aligned to the wall clock,
+// e.g. every second on the second. It's silent between the beeps.
+func Beep(freq float64, duration, period time.Duration, amplitude float32) Signal {
+	tone := Tone(freq, amplitude)
+	if period <= 0 {
+		return tone
+	}
+	return func(t time.Duration) float32 {
+		if t%period >= duration {
+			return 0
+		}
+		return tone(t)
+	}
+}
@@ -0,0 +1,94 @@
+package audiotest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// epoch is a time since the Unix epoch, which is large enough to check the precision of the signals
+const epoch = 1700000000 * time.Second
+
+func assertLevel(t *testing.T, name string, expected, actual float32) {
+	t.Helper()
+	if math.Abs(float64(expected-actual)) > 1e-3 {
+		t.Errorf("%s: Expected %v, got %v", name, expected, actual)
+	}
+}
+
+func TestSignals(t *testing.T) {
+	tone := Tone(480, 0.5)
+	assertLevel(t, "Tone at the start", 0, tone(epoch))
+	assertLevel(t, "Tone at the quarter", 0.5, tone(epoch+time.Second/1920))
+
+	sweep := Sweep(100, 200, time.Second, 0.5)
+	assertLevel(t, "Sweep at the start", 0, sweep(epoch))
+	// The phase at 1/400 s is 100*(1/400) + 100*(1/400)^2/2 cycles, which is slightly more than 1/4
+	assertLevel(t, "Sweep at the quarter", 0.5*float32(math.Sin(2*math.Pi*(0.25+100.0/400/400/2))), sweep(epoch+time.Second/400))
+
+	// The cycle of 400ms is aligned to the epoch
+	dtmf := DTMF("1x", 150*time.Millisecond, 50*time.Millisecond, 0.5)
+	expected := 0.25 * float32(math.Sin(2*math.Pi*697*0.01)+math.Sin(2*math.Pi*1209*0.01))
+	assertLevel(t, "DTMF in the tone of 1", expected, dtmf(epoch+10*time.Millisecond))
+	assertLevel(t, "DTMF in the gap", 0, dtmf(epoch+170*time.Millisecond))
+	assertLevel(t, "DTMF in the unknown digit", 0, dtmf(epoch+210*time.Millisecond))
+
+	beep := Beep(1000, 100*time.Millisecond, time.Second, 0.5)
+	assertLevel(t, "Beep", 0.5, beep(epoch+time.Second/4000))
+	assertLevel(t, "Beep in the silence", 0, beep(epoch+500*time.Millisecond+time.Second/4000))
+
+	noise := WhiteNoise(0.5)
+	var sum float64
+	for i := 0; i < 48000; i++ {
+		v := noise(epoch + time.Duration(i)*time.Second/48000)
+		if v < -0.5 || v >= 0.5 {
+			t.Fatalf("Expected the noise within [-0.5, 0.5), got %v", v)
+		}
+		sum += float64(v)
+	}
+	if mean := sum / 48000; math.Abs(mean) > 0.01 {
+		t.Errorf("Expected the mean of the noise to be 0, got %v", mean)
+	}
+	assertLevel(t, "Reproducible noise", noise(epoch), noise(epoch))
+}
+
+func TestRegister(t *testing.T) {
+	signal := Beep(1000, 100*time.Millisecond, time.Second, 0.5)
+	id, err := Register(Config{Label: "signal-unittest", SampleRate: 8000, Signal: signal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := driver.GetManager().Query(driver.FilterID(id))[0]
+	defer driver.GetManager().Unregister(id)
+
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	p := d.Properties()[0]
+	if p.SampleRate != 8000 {
+		t.Errorf("Expected the sample rate 8000, got %d", p.SampleRate)
+	}
+	r, err := d.(driver.AudioRecorder).AudioRecord(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunk, _, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The samples are generated at their capture time
+	start := time.Duration(audio.Timestamp(r).UnixNano())
+	a := chunk.(*wave.Float32Interleaved)
+	for i := 0; i < a.Size.Len; i++ {
+		expected := signal(start + time.Duration(i)*time.Second/8000)
+		if actual := float32(a.At(i, 0).(wave.Float32Sample)); actual != expected {
+			t.Fatalf("Expected the sample %d to be %v, got %v", i, expected, actual)
+		}
+	}
+}
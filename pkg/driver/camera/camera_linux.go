@@ -1,6 +1,35 @@
 package camera
 
-// #include <linux/videodev2.h>
+/*
+#include <linux/videodev2.h>
+#include <string.h>
+#include <sys/ioctl.h>
+
+// get_frame_interval gets the index-th frame interval of the pixel format and the frame size.
+// Discrete intervals are returned as the same min and max.
+static int get_frame_interval(int fd, __u32 index, __u32 pixel_format, __u32 width, __u32 height,
+		struct v4l2_fract *min, struct v4l2_fract *max, __u32 *type) {
+	struct v4l2_frmivalenum e;
+	memset(&e, 0, sizeof(e));
+	e.index = index;
+	e.pixel_format = pixel_format;
+	e.width = width;
+	e.height = height;
+	if (ioctl(fd, VIDIOC_ENUM_FRAMEINTERVALS, &e) < 0) {
+		return -1;
+	}
+
+	*type = e.type;
+	if (e.type == V4L2_FRMIVAL_TYPE_DISCRETE) {
+		*min = e.discrete;
+		*max = e.discrete;
+	} else {
+		*min = e.stepwise.min;
+		*max = e.stepwise.max;
+	}
+	return 0;
+}
+*/
 import "C"
 
 import (
@@ -12,6 +41,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/blackjack/webcam"
@@ -59,6 +89,9 @@ var (
 		{1920, 1200},
 		{2560, 1600},
 	}
+	// supportedFrameRates are the frame rates that are reported for the devices that support
+	// a range of frame intervals instead of the discrete ones
+	supportedFrameRates = []float32{5, 10, 15, 20, 24, 25, 30, 50, 60, 120}
 )
 
 const bufCount = 2
@@ -76,16 +109,73 @@ type camera struct {
 	prevFrameTime   time.Time
 }
 
+var (
+	// devicePatterns are the device files to discover the cameras from, in the order of the preference
+	// of the labels
+	devicePatterns = []string{"/dev/v4l/by-id/*", "/dev/v4l/by-path/*", "/dev/video*"}
+
+	// cameras are the registered cameras by the names of their device files, e.g. video0
+	cameras   = make(map[string]*camera)
+	camerasMu sync.Mutex
+	// rescanMu serializes the rescans of the hotplug watcher and driver.Manager.Rescan
+	rescanMu  sync.Mutex
+	watchOnce sync.Once
+)
+
 func init() {
 	Initialize()
 }
 
-// Initialize finds and registers camera devices. This is part of an experimental API.
+// Initialize finds and registers camera devices. The cameras that are plugged in or unplugged later
+// are registered or unregistered by watching /dev/video* and /dev/v4l/by-path with inotify. They can also
+// be discovered again by driver.Manager.Rescan. This is part of an experimental API.
 func Initialize() {
+	rescan(devicePatterns...)
+	watchOnce.Do(func() {
+		driver.GetManager().RegisterRescanner(driver.RescannerFunc(func() error {
+			rescan(devicePatterns...)
+			return nil
+		}))
+		// Hotplug isn't supported without inotify, but the cameras can still be rescanned
+		_ = watchDevices(func() {
+			rescan(devicePatterns...)
+		})
+	})
+}
+
+// rescan registers the cameras that are found by patterns, and unregisters the registered ones that
+// have been found by patterns but aren't found anymore. The cameras of the other patterns are kept.
+func rescan(patterns ...string) {
+	rescanMu.Lock()
+	defer rescanMu.Unlock()
+
 	discovered := make(map[string]struct{})
-	discover(discovered, "/dev/v4l/by-id/*")
-	discover(discovered, "/dev/v4l/by-path/*")
-	discover(discovered, "/dev/video*")
+	for _, pattern := range patterns {
+		discover(discovered, pattern)
+	}
+
+	camerasMu.Lock()
+	var removed []*camera
+	for name, cam := range cameras {
+		if _, ok := discovered[name]; !ok && matchAny(patterns, cam.path) {
+			removed = append(removed, cam)
+			delete(cameras, name)
+		}
+	}
+	camerasMu.Unlock()
+
+	for _, cam := range removed {
+		_ = driver.GetManager().UnregisterAdapter(cam)
+	}
+}
+
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
 }
 
 func discover(discovered map[string]struct{}, pattern string) {
@@ -107,6 +197,14 @@ func discover(discovered map[string]struct{}, pattern string) {
 		}
 
 		discovered[reallink] = struct{}{}
+
+		camerasMu.Lock()
+		_, registered := cameras[reallink]
+		camerasMu.Unlock()
+		if registered {
+			continue
+		}
+
 		cam := newCamera(device)
 		priority := driver.PriorityNormal
 		if reallink == prioritizedDevice {
@@ -117,6 +215,7 @@ func discover(discovered map[string]struct{}, pattern string) {
 		if webcamCam, err := webcam.Open(cam.path); err == nil {
 			name, _ = webcamCam.GetName()
 			busInfo, _ = webcamCam.GetBusInfo()
+			webcamCam.Close()
 		}
 
 		driver.GetManager().Register(cam, driver.Info{
@@ -130,7 +229,14 @@ func discover(discovered map[string]struct{}, pattern string) {
 			Label:      label + LabelSeparator + reallink,
 			DeviceType: driver.Camera,
 			Priority:   priority,
+			// The by-id and by-path links are stable across reboots unlike /dev/video*, which depends on the
+			// order of the devices to be detected
+			HardwareID: device,
 		})
+
+		camerasMu.Lock()
+		cameras[reallink] = cam
+		camerasMu.Unlock()
 	}
 }
 
@@ -240,6 +346,7 @@ func (c *camera) VideoRecord(p prop.Media) (video.Reader, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	c.cancel = cancel
 	var buf []byte
+	var timestamp time.Time
 	r := video.ReaderFunc(func() (img image.Image, release func(), err error) {
 		// Lock to avoid accessing the buffer after StopStreaming()
 		c.mutex.Lock()
@@ -266,14 +373,16 @@ func (c *camera) VideoRecord(p prop.Media) (video.Reader, error) {
 				return nil, func() {}, errReadTimeout
 			default:
 				// Camera has been stopped.
-				return nil, func() {}, err
+				return nil, func() {}, c.readError(err)
 			}
 
 			b, err := cam.ReadFrame()
 			if err != nil {
 				// Camera has been stopped.
-				return nil, func() {}, err
+				return nil, func() {}, c.readError(err)
 			}
+			// The frame has been dequeued right after it's been captured
+			timestamp = time.Now()
 
 			if p.DiscardFramesOlderThan != 0 {
 				c.prevFrameTime = time.Now()
@@ -299,11 +408,49 @@ func (c *camera) VideoRecord(p prop.Media) (video.Reader, error) {
 		return nil, func() {}, errEmptyFrame
 	})
 
-	return r, nil
+	return video.WithTimestamp(r, func() time.Time {
+		return timestamp
+	}), nil
+}
+
+// readError tells if err is caused by the device that has been unplugged
+func (c *camera) readError(err error) error {
+	if errors.Is(err, syscall.ENODEV) {
+		return driver.ErrDeviceRemoved
+	}
+	if _, statErr := os.Stat(c.path); os.IsNotExist(statErr) {
+		return driver.ErrDeviceRemoved
+	}
+	return err
 }
 
 func (c *camera) Properties() []prop.Media {
+	// The frame intervals are enumerated on a separate file descriptor since the webcam doesn't
+	// expose its own
+	var fd uintptr
+	if f, err := os.OpenFile(c.path, os.O_RDWR, 0); err == nil {
+		defer f.Close()
+		fd = f.Fd()
+	}
+
 	properties := make([]prop.Media, 0)
+	appendProperties := func(format webcam.PixelFormat, supportedFormat frame.Format, width, height int) {
+		// The property without the frame rate comes first. It's selected when the frame rate isn't
+		// constrained, so the frame rate of the camera isn't changed, and it's the only one if the frame
+		// rates are unknown.
+		frameRates := append([]float32{0}, enumFrameRates(fd, format, width, height)...)
+		for _, frameRate := range frameRates {
+			properties = append(properties, prop.Media{
+				Video: prop.Video{
+					Width:       width,
+					Height:      height,
+					FrameFormat: supportedFormat,
+					FrameRate:   frameRate,
+				},
+			})
+		}
+	}
+
 	for format := range c.cam.GetSupportedFormats() {
 		for _, frameSize := range c.cam.GetSupportedFrameSizes(format) {
 			supportedFormat, ok := c.formats[format]
@@ -312,13 +459,7 @@ func (c *camera) Properties() []prop.Media {
 			}
 
 			if frameSize.StepWidth == 0 || frameSize.StepHeight == 0 {
-				properties = append(properties, prop.Media{
-					Video: prop.Video{
-						Width:       int(frameSize.MaxWidth),
-						Height:      int(frameSize.MaxHeight),
-						FrameFormat: supportedFormat,
-					},
-				})
+				appendProperties(format, supportedFormat, int(frameSize.MaxWidth), int(frameSize.MaxHeight))
 			} else {
 				// FIXME: we should probably use a custom data structure to capture all of the supported resolutions
 				for _, supportedResolution := range supportedResolutions {
@@ -337,16 +478,51 @@ func (c *camera) Properties() []prop.Media {
 						continue
 					}
 
-					properties = append(properties, prop.Media{
-						Video: prop.Video{
-							Width:       width,
-							Height:      height,
-							FrameFormat: supportedFormat,
-						},
-					})
+					appendProperties(format, supportedFormat, width, height)
 				}
 			}
 		}
 	}
 	return properties
 }
+
+// enumFrameRates returns the frame rates that the device supports with the pixel format and the frame size.
+// Discrete frame intervals are returned in the order of the device, and ranges of frame intervals are
+// returned as supportedFrameRates within the ranges. If the device can't enumerate the frame intervals,
+// nil is returned.
+// Reference: https://www.kernel.org/doc/html/v4.9/media/uapi/v4l/vidioc-enum-frameintervals.html
+func enumFrameRates(fd uintptr, format webcam.PixelFormat, width, height int) []float32 {
+	if fd == 0 {
+		return nil
+	}
+
+	var frameRates []float32
+	for i := 0; ; i++ {
+		var min, max C.struct_v4l2_fract
+		var typ C.__u32
+		if C.get_frame_interval(C.int(fd), C.__u32(i), C.__u32(format), C.__u32(width), C.__u32(height), &min, &max, &typ) != 0 {
+			return frameRates
+		}
+
+		if typ == C.V4L2_FRMIVAL_TYPE_DISCRETE {
+			if min.numerator != 0 {
+				frameRates = append(frameRates, float32(min.denominator)/float32(min.numerator))
+			}
+			continue
+		}
+
+		// Continuous and stepwise intervals are enumerated only once. Since the frame rate is the inverse
+		// of the frame interval, the minimum interval is the maximum frame rate.
+		if min.numerator == 0 || max.numerator == 0 {
+			return nil
+		}
+		maxFrameRate := float32(min.denominator) / float32(min.numerator)
+		minFrameRate := float32(max.denominator) / float32(max.numerator)
+		for _, frameRate := range supportedFrameRates {
+			if frameRate >= minFrameRate && frameRate <= maxFrameRate {
+				frameRates = append(frameRates, frameRate)
+			}
+		}
+		return frameRates
+	}
+}
@@ -133,6 +133,72 @@ func TestDiscoverByID(t *testing.T) {
 	}
 }
 
+func TestRescan(t *testing.T) {
+	const name = "rescan-unittest-video0"
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	isTarget := func(d driver.Driver) bool {
+		// Ignore real cameras and the ones of the other tests
+		return strings.Contains(d.Info().Label, "rescan-unittest")
+	}
+	// The cameras that haven't been found by the pattern of the rescan, e.g. real cameras, should be kept
+	others := driver.GetManager().Query(func(d driver.Driver) bool {
+		return d.Info().DeviceType == driver.Camera && !isTarget(d)
+	})
+	query := func() []driver.Driver {
+		return driver.GetManager().Query(isTarget)
+	}
+
+	var events []driver.DeviceChangeEvent
+	unsubscribe := driver.GetManager().Subscribe(func(e driver.DeviceChangeEvent) {
+		for _, d := range append(e.Added, e.Removed...) {
+			if isTarget(d) {
+				events = append(events, e)
+				return
+			}
+		}
+	})
+	defer unsubscribe()
+	pattern := filepath.Join(dir, "rescan-unittest-video*")
+
+	// Plug in
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rescan(pattern)
+	rescan(pattern)
+	drvs := query()
+	if len(drvs) != 1 {
+		t.Fatalf("Expected the camera to be registered once, got %d drivers", len(drvs))
+	}
+	if len(events) != 1 || len(events[0].Added) != 1 || events[0].Added[0] != drvs[0] {
+		t.Errorf("Expected an event of the added camera, got %v", events)
+	}
+
+	// Unplug
+	if err := os.Remove(filepath.Join(dir, name)); err != nil {
+		t.Fatal(err)
+	}
+	rescan(pattern)
+	if n := len(query()); n != 0 {
+		t.Errorf("Expected the camera to be unregistered, got %d drivers", n)
+	}
+	if len(events) != 2 || len(events[1].Removed) != 1 || events[1].Removed[0] != drvs[0] {
+		t.Errorf("Expected an event of the removed camera, got %v", events)
+	}
+
+	for _, d := range others {
+		if len(driver.GetManager().Query(driver.FilterID(d.ID()))) != 1 {
+			t.Errorf("Expected %s to be kept by the rescan", d.Info().Label)
+		}
+	}
+}
+
 func TestGetCameraReadTimeout(t *testing.T) {
 	var expected uint32 = 5
 	value := getCameraReadTimeout()
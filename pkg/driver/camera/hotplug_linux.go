@@ -0,0 +1,97 @@
+package camera
+
+import (
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	devDir    = "/dev"
+	byPathDir = "/dev/v4l/by-path"
+	// hotplugDelay is the time to wait before rescanning, since udev creates the symlinks and sets
+	// the permissions of the device files after they have been created
+	hotplugDelay = 500 * time.Millisecond
+
+	inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+)
+
+// watchDevices watches /dev/video* and /dev/v4l/by-path with inotify, and calls rescan when a device
+// file has been added or removed
+func watchDevices(rescan func()) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return err
+	}
+
+	devWatch, err := syscall.InotifyAddWatch(fd, devDir, inotifyMask)
+	if err != nil {
+		syscall.Close(fd)
+		return err
+	}
+
+	// /dev/v4l/by-path only exists while a camera is plugged in, so it's watched once it's been created
+	byPathWatch := -1
+	watchByPath := func() {
+		if byPathWatch < 0 {
+			if wd, err := syscall.InotifyAddWatch(fd, byPathDir, inotifyMask); err == nil {
+				byPathWatch = wd
+			}
+		}
+	}
+	watchByPath()
+
+	go func() {
+		defer syscall.Close(fd)
+
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := syscall.Read(fd, buf)
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil || n <= 0 {
+				return
+			}
+
+			changed := false
+			parseInotifyEvents(buf[:n], func(wd int, mask uint32, name string) {
+				switch {
+				case wd == byPathWatch && mask&syscall.IN_IGNORED != 0:
+					// The directory has been removed with the last camera
+					byPathWatch = -1
+				case wd == byPathWatch:
+					changed = true
+				case wd == devWatch && (strings.HasPrefix(name, "video") || name == "v4l"):
+					changed = true
+				}
+			})
+			if !changed {
+				continue
+			}
+
+			time.Sleep(hotplugDelay)
+			watchByPath()
+			rescan()
+		}
+	}()
+
+	return nil
+}
+
+// parseInotifyEvents calls f with each of the events in buf
+func parseInotifyEvents(buf []byte, f func(wd int, mask uint32, name string)) {
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		offset += syscall.SizeofInotifyEvent
+
+		var name string
+		if end := offset + int(event.Len); event.Len > 0 && end <= len(buf) {
+			name = strings.TrimRight(string(buf[offset:end]), "\x00")
+		}
+		offset += int(event.Len)
+
+		f(int(event.Wd), event.Mask, name)
+	}
+}
@@ -11,4 +11,6 @@ const (
 	Microphone = "microphone"
 	// Screen represents screen devices
 	Screen = "screen"
+	// Speaker represents audio output devices
+	Speaker = "speaker"
 )
@@ -1,6 +1,8 @@
 package driver
 
 import (
+	"context"
+
 	"github.com/pion/mediadevices/pkg/io/audio"
 	"github.com/pion/mediadevices/pkg/io/video"
 	"github.com/pion/mediadevices/pkg/prop"
@@ -14,6 +16,13 @@ type AudioRecorder interface {
 	AudioRecord(p prop.Media) (r audio.Reader, err error)
 }
 
+// AudioPlayer is implemented by the adapters of audio output devices, e.g. speakers
+type AudioPlayer interface {
+	// AudioPlay renders the audio of r with p, which is one of Properties. It blocks until r returns an
+	// error or the driver is closed, and returns nil when r returns io.EOF or the driver is closed.
+	AudioPlay(r audio.Reader, p prop.Media) error
+}
+
 // Priority represents device selection priority level
 type Priority float32
 
@@ -31,6 +40,9 @@ type Info struct {
 	DeviceType DeviceType
 	Priority   Priority
 	Name       string
+	// HardwareID is the identity of the device that is stable across runs and reboots, e.g. the
+	// V4L2 by-path of a camera. The ID of the driver is derived from it, or from Label if it's empty.
+	HardwareID string
 }
 
 type Adapter interface {
@@ -39,6 +51,24 @@ type Adapter interface {
 	Properties() []prop.Media
 }
 
+// OpenerContext is an optional interface to open the device with a context, which returns ctx.Err()
+// when ctx is done before the device has been opened. Adapters that may block on Open, e.g. to connect
+// to a remote device, should implement it to be cancellable.
+//
+// The drivers of Manager always implement it. If their adapter doesn't, Open keeps running in the
+// background, and the adapter is closed once it has been opened.
+type OpenerContext interface {
+	OpenContext(ctx context.Context) error
+}
+
+// LockerContext is implemented by the drivers of Manager. The callers that open a driver, use it and
+// close it again hold its lock, so they can't close the driver underneath each other.
+type LockerContext interface {
+	// LockContext locks the driver, and returns the function to unlock it. It returns ctx.Err() if ctx
+	// is done before the driver has been locked.
+	LockContext(ctx context.Context) (unlock func(), err error)
+}
+
 type Driver interface {
 	Adapter
 	ID() string
@@ -0,0 +1,195 @@
+// Package filesource provides a video driver that reads YUV4MPEG2 (.y4m) and raw YUV files, which
+// gives deterministic camera input, e.g. for CI and load testing.
+package filesource
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+// Mode decides what happens when all the frames of the file have been read
+type Mode int
+
+const (
+	// ModeLoop reads the file again from the first frame
+	ModeLoop Mode = iota
+	// ModeEOF ends the reader with io.EOF
+	ModeEOF
+)
+
+// Source is a camera that reads the frames from a Y4M or raw video file
+type Source struct {
+	path string
+	p    prop.Video
+	mode Mode
+	y4m  bool
+	// dataOffset is the offset of the first frame in the file
+	dataOffset int64
+
+	file   *os.File
+	closed <-chan struct{}
+	cancel func()
+	tick   *time.Ticker
+}
+
+// NewY4M returns a video source that reads the YUV4MPEG2 file at path. The resolution and the frame
+// rate are read from the header of the file. Only the 4:2:0 chroma subsampling is supported, and the
+// frames are read as frame.FormatI420.
+func NewY4M(path string, mode Mode) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	p, err := parseY4MHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(p); err != nil {
+		return nil, err
+	}
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{
+		path:       path,
+		p:          p,
+		mode:       mode,
+		y4m:        true,
+		dataOffset: offset - int64(r.Buffered()),
+	}, nil
+}
+
+// NewRaw returns a video source that reads the raw frames without any header from the file at path.
+// p describes the frames, which have to be in frame.FormatI420 or frame.FormatNV12.
+func NewRaw(path string, p prop.Video, mode Mode) (*Source, error) {
+	switch p.FrameFormat {
+	case frame.FormatI420, frame.FormatNV12:
+	default:
+		return nil, fmt.Errorf("filesource: unsupported frame format %s", p.FrameFormat)
+	}
+	if err := checkSize(p); err != nil {
+		return nil, err
+	}
+	if p.FrameRate <= 0 {
+		return nil, fmt.Errorf("filesource: invalid frame rate %v", p.FrameRate)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	return &Source{
+		path: path,
+		p:    p,
+		mode: mode,
+	}, nil
+}
+
+// checkSize checks that the frames can be decoded, since the chroma planes are subsampled by 2
+func checkSize(p prop.Video) error {
+	if p.Width <= 0 || p.Height <= 0 || p.Width%2 != 0 || p.Height%2 != 0 {
+		return fmt.Errorf("filesource: the size has to be even, got %dx%d", p.Width, p.Height)
+	}
+	return nil
+}
+
+// Register registers s as a Camera device with driver.RegisterFile, and returns the ID of its driver
+func Register(s *Source) (string, error) {
+	return driver.RegisterFile(s, s.path, driver.Camera)
+}
+
+func (s *Source) Open() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.file = f
+	s.closed = ctx.Done()
+	s.cancel = cancel
+	return nil
+}
+
+func (s *Source) Close() error {
+	s.cancel()
+	if s.tick != nil {
+		s.tick.Stop()
+	}
+	return s.file.Close()
+}
+
+func (s *Source) VideoRecord(p prop.Media) (video.Reader, error) {
+	decoder, err := frame.NewDecoder(s.p.FrameFormat)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.file.Seek(s.dataOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(s.file)
+	buf := make([]byte, s.p.Width*s.p.Height*3/2)
+	// readFrame reads the next frame to buf, and returns io.EOF once all the frames have been read
+	readFrame := func() error {
+		if s.y4m {
+			if err := readY4MFrameHeader(r); err != nil {
+				return err
+			}
+		}
+		_, err := io.ReadFull(r, buf)
+		if err == io.ErrUnexpectedEOF && !s.y4m {
+			// The trailing partial frame of a raw file is ignored
+			return io.EOF
+		}
+		return err
+	}
+
+	tick := time.NewTicker(time.Duration(float32(time.Second) / s.p.FrameRate))
+	s.tick = tick
+	closed := s.closed
+	var timestamp time.Time
+
+	reader := video.ReaderFunc(func() (image.Image, func(), error) {
+		select {
+		case <-closed:
+			return nil, func() {}, io.EOF
+		case timestamp = <-tick.C:
+		}
+
+		err := readFrame()
+		if err == io.EOF && s.mode == ModeLoop {
+			if _, err := s.file.Seek(s.dataOffset, io.SeekStart); err != nil {
+				return nil, func() {}, err
+			}
+			r.Reset(s.file)
+			err = readFrame()
+		}
+		if err != nil {
+			return nil, func() {}, err
+		}
+
+		return decoder.Decode(buf, s.p.Width, s.p.Height)
+	})
+
+	return video.WithTimestamp(reader, func() time.Time {
+		return timestamp
+	}), nil
+}
+
+func (s *Source) Properties() []prop.Media {
+	return []prop.Media{{Video: s.p}}
+}
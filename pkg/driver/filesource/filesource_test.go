@@ -0,0 +1,195 @@
+package filesource
+
+import (
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pion/mediadevices"
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+const (
+	testWidth, testHeight = 4, 2
+	testFrames            = 3
+)
+
+// writeTestFile writes a file of testFrames frames in I420 to dir. The luma of each frame is its
+// index, and the frames are preceded by y4mHeader and "FRAME" if y4mHeader isn't empty.
+func writeTestFile(t *testing.T, dir, name, y4mHeader string) string {
+	var b strings.Builder
+	b.WriteString(y4mHeader)
+	for i := 0; i < testFrames; i++ {
+		if y4mHeader != "" {
+			b.WriteString("FRAME\n")
+		}
+		b.WriteString(strings.Repeat(string(rune(i)), testWidth*testHeight))
+		b.WriteString(strings.Repeat("\x80", testWidth*testHeight/2))
+	}
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestY4M(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestFile(t, dir, "test.y4m", "YUV4MPEG2 W4 H2 F1000:1 Ip A1:1 C420jpeg\n")
+
+	for name, c := range map[string]struct {
+		mode     Mode
+		expected []byte
+	}{
+		"Loop": {mode: ModeLoop, expected: []byte{0, 1, 2, 0, 1}},
+		"EOF":  {mode: ModeEOF, expected: []byte{0, 1, 2}},
+	} {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			s, err := NewY4M(path, c.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedProp := prop.Video{Width: testWidth, Height: testHeight, FrameRate: 1000, FrameFormat: frame.FormatI420}
+			if p := s.Properties(); len(p) != 1 || p[0].Video != expectedProp {
+				t.Errorf("Expected properties %v, got %v", expectedProp, p)
+			}
+
+			if err := s.Open(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			r, err := s.VideoRecord(s.Properties()[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, expected := range c.expected {
+				img, _, err := r.Read()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if y := img.(*image.YCbCr).Y[0]; y != expected {
+					t.Errorf("Expected the luma of the frame %d to be %d, got %d", i, expected, y)
+				}
+			}
+			if c.mode == ModeEOF {
+				if _, _, err := r.Read(); err != io.EOF {
+					t.Errorf("Expected %v, got %v", io.EOF, err)
+				}
+			}
+		})
+	}
+}
+
+func TestY4MInvalidHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, header := range map[string]string{
+		"NoSignature":      "W4 H2 F30:1\n",
+		"NoFrameRate":      "YUV4MPEG2 W4 H2\n",
+		"UnsupportedC444":  "YUV4MPEG2 W4 H2 F30:1 C444\n",
+		"Unsupported10Bit": "YUV4MPEG2 W4 H2 F30:1 C420p10\n",
+		"Unsupported12Bit": "YUV4MPEG2 W4 H2 F30:1 C420p12\n",
+		"OddWidth":         "YUV4MPEG2 W3 H2 F30:1\n",
+	} {
+		path := writeTestFile(t, dir, name+".y4m", header)
+		if _, err := NewY4M(path, ModeEOF); err == nil {
+			t.Errorf("%s: Expected an error", name)
+		}
+	}
+}
+
+func TestRawNV12(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestFile(t, dir, "test.yuv", "")
+
+	s, err := NewRaw(path, prop.Video{Width: testWidth, Height: testHeight, FrameRate: 1000, FrameFormat: frame.FormatNV12}, ModeEOF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	r, err := s.VideoRecord(s.Properties()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < testFrames; i++ {
+		img, _, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ycbcr := img.(*image.YCbCr)
+		if ycbcr.Y[0] != byte(i) || ycbcr.Cb[0] != 0x80 || ycbcr.Cr[0] != 0x80 {
+			t.Errorf("Expected the frame %d to be (%d, 128, 128), got %v", i, i, ycbcr.YCbCrAt(0, 0))
+		}
+	}
+	if _, _, err := r.Read(); err != io.EOF {
+		t.Errorf("Expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestGetUserMedia(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestFile(t, dir, "test.y4m", "YUV4MPEG2 W4 H2 F1000:1\n")
+
+	s, err := NewY4M(path, ModeLoop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := Register(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.GetManager().UnregisterAdapter(s)
+
+	stream, err := mediadevices.GetUserMedia(mediadevices.MediaStreamConstraints{
+		Video: func(c *mediadevices.MediaTrackConstraints) {
+			c.DeviceID = prop.StringExact(id)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks := stream.GetVideoTracks()
+	if len(tracks) != 1 {
+		t.Fatalf("Expected a video track, got %d tracks", len(tracks))
+	}
+	track := tracks[0].(*mediadevices.VideoTrack)
+	defer track.Close()
+
+	img, release, err := track.NewReader(false).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if bounds := img.Bounds(); bounds.Dx() != testWidth || bounds.Dy() != testHeight {
+		t.Errorf("Expected the frames of the file, got %v", bounds)
+	}
+}
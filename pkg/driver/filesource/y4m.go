@@ -0,0 +1,111 @@
+package filesource
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+const (
+	y4mSignature   = "YUV4MPEG2"
+	y4mFrameHeader = "FRAME"
+)
+
+var (
+	errNotY4M             = errors.New("y4m: missing YUV4MPEG2 signature")
+	errInvalidFrameHeader = errors.New("y4m: invalid frame header")
+)
+
+// parseY4MHeader reads the stream header of a YUV4MPEG2 file from r, and returns the properties of
+// its frames. Reference: https://wiki.multimedia.cx/index.php/YUV4MPEG2
+func parseY4MHeader(r *bufio.Reader) (prop.Video, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return prop.Video{}, err
+	}
+
+	params := strings.Fields(line)
+	if len(params) == 0 || params[0] != y4mSignature {
+		return prop.Video{}, errNotY4M
+	}
+
+	p := prop.Video{
+		// The chroma subsampling defaults to 4:2:0
+		FrameFormat: frame.FormatI420,
+	}
+	for _, param := range params[1:] {
+		value := param[1:]
+		switch param[0] {
+		case 'W':
+			p.Width, err = strconv.Atoi(value)
+		case 'H':
+			p.Height, err = strconv.Atoi(value)
+		case 'F':
+			p.FrameRate, err = parseRatio(value)
+		case 'C':
+			// Only the 8-bit 4:2:0 color spaces are supported, which differ only in the chroma siting
+			switch value {
+			case "420", "420jpeg", "420paldv", "420mpeg2":
+			default:
+				err = fmt.Errorf("y4m: unsupported color space %s", value)
+			}
+		case 'I':
+			if value != "p" && value != "?" {
+				err = fmt.Errorf("y4m: unsupported interlacing %s", value)
+			}
+		}
+		if err != nil {
+			return prop.Video{}, err
+		}
+	}
+
+	if p.Width <= 0 || p.Height <= 0 || p.FrameRate <= 0 {
+		return prop.Video{}, fmt.Errorf("y4m: invalid size %dx%d or frame rate %v", p.Width, p.Height, p.FrameRate)
+	}
+	return p, nil
+}
+
+// readY4MFrameHeader reads the header that precedes each of the frames from r. The frame parameters
+// are ignored since they're rarely used.
+func readY4MFrameHeader(r *bufio.Reader) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line != "" {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if !strings.HasPrefix(line, y4mFrameHeader) {
+		return errInvalidFrameHeader
+	}
+	return nil
+}
+
+// parseRatio parses a ratio like 30000:1001
+func parseRatio(s string) (float32, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("y4m: invalid ratio %s", s)
+	}
+	num, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	den, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	if den == 0 {
+		return 0, fmt.Errorf("y4m: invalid ratio %s", s)
+	}
+	return float32(num) / float32(den), nil
+}
@@ -0,0 +1,287 @@
+// Package imagesource provides a video source that turns a directory of PNG/JPEG files or a single
+// still image into video, e.g. for slate screens, "camera off" placeholders and reproducible encoder
+// tests. The images are decoded with the standard library, and the frames are in I420.
+package imagesource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	// Register the decoders of the supported formats
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/google/uuid"
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+const defaultFrameRate = 30
+
+var (
+	errNoImages   = errors.New("imagesource: no PNG or JPEG files in the directory")
+	errMixedSizes = errors.New("imagesource: the images have to be in the same size")
+)
+
+// Config is the configuration of an image source
+type Config struct {
+	// FrameRate is the frame rate of the video. It defaults to 30.
+	FrameRate float32
+	// Loop shows the images of a directory again from the first one once all of them have been shown.
+	// Otherwise, the reader ends with io.EOF. A single image is shown until the source is closed.
+	Loop bool
+}
+
+// images is a sequence of the images of a directory or a single image
+type images struct {
+	paths []string
+	// still is the image in I420 if paths has only one image, which is decoded and converted once
+	still image.Image
+	// size is the size of all the images
+	size image.Point
+}
+
+// loadImages lists the images at path, which is either a directory or an image file. The images of
+// a directory are sorted by their names, and have to be in the same size, since the size of the video
+// can't be changed.
+func loadImages(path string) (*images, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		files, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = paths[:0]
+		for _, file := range files {
+			switch strings.ToLower(filepath.Ext(file.Name())) {
+			case ".png", ".jpg", ".jpeg":
+				paths = append(paths, filepath.Join(path, file.Name()))
+			}
+		}
+		if len(paths) == 0 {
+			return nil, errNoImages
+		}
+		sort.Strings(paths)
+	}
+
+	first, err := decodeImage(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	imgs := &images{
+		paths: paths,
+		size:  first.Bounds().Size(),
+	}
+	if len(paths) == 1 {
+		imgs.still, err = toI420(first)
+		if err != nil {
+			return nil, err
+		}
+		return imgs, nil
+	}
+
+	// Only the headers are read to check the sizes, and the images are decoded when they're shown
+	for _, p := range paths[1:] {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		config, _, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("imagesource: failed to decode %s: %w", p, err)
+		}
+		if config.Width != imgs.size.X || config.Height != imgs.size.Y {
+			return nil, fmt.Errorf("%w: %s is %dx%d, but %s is %dx%d",
+				errMixedSizes, p, config.Width, config.Height, paths[0], imgs.size.X, imgs.size.Y)
+		}
+	}
+	return imgs, nil
+}
+
+// toI420 converts img to I420 like the frames of the sequences
+func toI420(img image.Image) (image.Image, error) {
+	r := video.ToI420(video.ReaderFunc(func() (image.Image, func(), error) {
+		return img, func() {}, nil
+	}))
+	// The converted image isn't released, since it's kept as long as the source
+	converted, _, err := r.Read()
+	return converted, err
+}
+
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("imagesource: failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+func (c *Config) frameRate() float32 {
+	if c.FrameRate <= 0 {
+		return defaultFrameRate
+	}
+	return c.FrameRate
+}
+
+// newReader returns the reader of imgs, which shows the images at the frame rate of c until closed is
+// closed. The returned function stops the reader.
+func newReader(imgs *images, c Config, closed <-chan struct{}) (video.Reader, func()) {
+	tick := time.NewTicker(time.Duration(float32(time.Second) / c.frameRate()))
+	var timestamp time.Time
+	var index int
+
+	// sequence decodes the images of a directory, which are converted every time they're shown
+	sequence := video.ToI420(video.ReaderFunc(func() (image.Image, func(), error) {
+		if index == len(imgs.paths) {
+			if !c.Loop {
+				return nil, func() {}, io.EOF
+			}
+			index = 0
+		}
+		img, err := decodeImage(imgs.paths[index])
+		if err != nil {
+			return nil, func() {}, err
+		}
+		index++
+		return img, func() {}, nil
+	}))
+
+	r := video.ReaderFunc(func() (image.Image, func(), error) {
+		select {
+		case <-closed:
+			return nil, func() {}, io.EOF
+		case timestamp = <-tick.C:
+		}
+
+		if imgs.still != nil {
+			return imgs.still, func() {}, nil
+		}
+		return sequence.Read()
+	})
+
+	return video.WithTimestamp(r, func() time.Time {
+		return timestamp
+	}), tick.Stop
+}
+
+// Source is a camera that shows the images of a directory or a single image
+type Source struct {
+	path   string
+	imgs   *images
+	config Config
+	closed <-chan struct{}
+	cancel func()
+	stop   func()
+}
+
+// New returns a video source of the images at path, which is either a directory of PNG/JPEG files or
+// a single image file. Register it with Register to use it as a driver.
+func New(path string, c Config) (*Source, error) {
+	imgs, err := loadImages(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{path: path, imgs: imgs, config: c}, nil
+}
+
+// Register registers s as a Camera device with driver.RegisterFile, and returns the ID of its driver
+func Register(s *Source) (string, error) {
+	return driver.RegisterFile(s, s.path, driver.Camera)
+}
+
+func (s *Source) Open() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.closed = ctx.Done()
+	s.cancel = cancel
+	return nil
+}
+
+func (s *Source) Close() error {
+	s.cancel()
+	if s.stop != nil {
+		s.stop()
+	}
+	return nil
+}
+
+func (s *Source) VideoRecord(p prop.Media) (video.Reader, error) {
+	r, stop := newReader(s.imgs, s.config, s.closed)
+	s.stop = stop
+	return r, nil
+}
+
+func (s *Source) Properties() []prop.Media {
+	return []prop.Media{
+		{
+			Video: prop.Video{
+				Width:       s.imgs.size.X,
+				Height:      s.imgs.size.Y,
+				FrameFormat: frame.FormatI420,
+				FrameRate:   s.config.frameRate(),
+			},
+		},
+	}
+}
+
+// VideoSource is a mediadevices.VideoSource that shows images like Source
+type VideoSource struct {
+	video.Reader
+	id     string
+	cancel func()
+	stop   func()
+}
+
+// NewVideoSource returns a video source of the images at path like New, which can be used as a
+// mediadevices.VideoSource for mediadevices.NewVideoTrack without registering it.
+func NewVideoSource(path string, c Config) (*VideoSource, error) {
+	imgs, err := loadImages(path)
+	if err != nil {
+		return nil, err
+	}
+	generator, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r, stop := newReader(imgs, c, ctx.Done())
+	return &VideoSource{
+		Reader: r,
+		id:     generator.String(),
+		cancel: cancel,
+		stop:   stop,
+	}, nil
+}
+
+func (s *VideoSource) ID() string {
+	return s.id
+}
+
+func (s *VideoSource) Close() error {
+	s.cancel()
+	s.stop()
+	return nil
+}
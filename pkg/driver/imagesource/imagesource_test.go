@@ -0,0 +1,268 @@
+package imagesource
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/mediadevices"
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+const testWidth, testHeight = 4, 2
+
+// writeTestImage writes a gray image of testWidth x testHeight to dir. The format is selected by the
+// extension of name.
+func writeTestImage(t *testing.T, dir, name string, gray uint8) string {
+	img := image.NewGray(image.Rect(0, 0, testWidth, testHeight))
+	for i := range img.Pix {
+		img.Pix[i] = gray
+	}
+
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if filepath.Ext(name) == ".png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 100})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// assertFrame asserts that img is a frame in I420 of testWidth x testHeight whose luma is around gray,
+// which is blurred by JPEG.
+func assertFrame(t *testing.T, img image.Image, gray uint8) {
+	t.Helper()
+
+	yuv, ok := img.(*image.YCbCr)
+	if !ok || yuv.SubsampleRatio != image.YCbCrSubsampleRatio420 {
+		t.Fatalf("Expected a frame in I420, got %T", img)
+	}
+	if size := yuv.Bounds().Size(); size != image.Pt(testWidth, testHeight) {
+		t.Errorf("Expected the size %dx%d, got %v", testWidth, testHeight, size)
+	}
+	y := color.GrayModel.Convert(yuv.At(0, 0)).(color.Gray).Y
+	if diff := int(y) - int(gray); diff < -2 || diff > 2 {
+		t.Errorf("Expected the luma %d, got %d", gray, y)
+	}
+}
+
+func TestSequence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The images are shown in the order of their names
+	writeTestImage(t, dir, "2.png", 160)
+	writeTestImage(t, dir, "0.png", 32)
+	writeTestImage(t, dir, "1.JPG", 96)
+	if err := ioutil.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]struct {
+		loop     bool
+		expected []uint8
+	}{
+		"Loop": {loop: true, expected: []uint8{32, 96, 160, 32, 96}},
+		"EOF":  {loop: false, expected: []uint8{32, 96, 160}},
+	} {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			s, err := New(dir, Config{FrameRate: 1000, Loop: c.loop})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedProp := prop.Video{Width: testWidth, Height: testHeight, FrameRate: 1000, FrameFormat: frame.FormatI420}
+			if p := s.Properties(); len(p) != 1 || p[0].Video != expectedProp {
+				t.Errorf("Expected properties %v, got %v", expectedProp, p)
+			}
+
+			if err := s.Open(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			r, err := s.VideoRecord(s.Properties()[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, expected := range c.expected {
+				img, _, err := r.Read()
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertFrame(t, img, expected)
+				if video.Timestamp(r).IsZero() {
+					t.Error("Expected the timestamp of the frame")
+				}
+			}
+			if !c.loop {
+				if _, _, err := r.Read(); err != io.EOF {
+					t.Errorf("Expected io.EOF, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := New(dir, Config{}); err != errNoImages {
+		t.Errorf("Expected %v, got %v", errNoImages, err)
+	}
+}
+
+func TestStill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestImage(t, dir, "slate.png", 64)
+
+	s, err := New(path, Config{FrameRate: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	r, err := s.VideoRecord(s.Properties()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A still image is shown until the source is closed
+	for i := 0; i < 3; i++ {
+		img, _, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertFrame(t, img, 64)
+		if img != s.imgs.still {
+			t.Error("Expected the still image to be converted once")
+		}
+	}
+}
+
+func TestMixedSizes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestImage(t, dir, "0.png", 32)
+	f, err := os.Create(filepath.Join(dir, "1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = png.Encode(f, image.NewGray(image.Rect(0, 0, testWidth*2, testHeight)))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(dir, Config{}); !errors.Is(err, errMixedSizes) {
+		t.Errorf("Expected %v, got %v", errMixedSizes, err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestImage(t, dir, "slate.png", 64)
+
+	s, err := New(path, Config{FrameRate: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := Register(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.GetManager().UnregisterAdapter(s)
+
+	stream, err := mediadevices.GetUserMedia(mediadevices.MediaStreamConstraints{
+		Video: func(c *mediadevices.MediaTrackConstraints) {
+			c.DeviceID = prop.StringExact(id)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks := stream.GetVideoTracks()
+	if len(tracks) != 1 {
+		t.Fatalf("Expected a video track, got %d tracks", len(tracks))
+	}
+	track := tracks[0].(*mediadevices.VideoTrack)
+	defer track.Close()
+
+	r := track.NewReader(false)
+	// A still image is shown until the source is closed
+	for i := 0; i < 3; i++ {
+		img, release, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertFrame(t, img, 64)
+		release()
+	}
+}
+
+func TestNewVideoSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestImage(t, dir, "0.png", 32)
+	writeTestImage(t, dir, "1.png", 96)
+
+	s, err := NewVideoSource(dir, Config{FrameRate: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := mediadevices.NewVideoTrack(s, nil)
+	defer track.Close()
+
+	r := track.(*mediadevices.VideoTrack).NewReader(false)
+	for _, expected := range []uint8{32, 96} {
+		img, release, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertFrame(t, img, expected)
+		release()
+	}
+	if _, _, err := r.Read(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
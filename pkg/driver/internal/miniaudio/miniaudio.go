@@ -0,0 +1,117 @@
+// Package miniaudio has the parts of the malgo drivers that are shared by the microphones and the
+// speakers: the malgo context, the host endianness and the rescan of the devices.
+package miniaudio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/gen2brain/malgo"
+	"github.com/pion/logging"
+	"github.com/pion/mediadevices/pkg/driver"
+)
+
+// HostEndian is the byte order of the host, which is the only one used by miniaudio
+var HostEndian binary.ByteOrder
+
+func init() {
+	switch v := *(*uint16)(unsafe.Pointer(&([]byte{0x12, 0x34}[0]))); v {
+	case 0x1234:
+		HostEndian = binary.BigEndian
+	case 0x3412:
+		HostEndian = binary.LittleEndian
+	default:
+		panic(fmt.Sprintf("failed to determine host endianness: %x", v))
+	}
+}
+
+// Devices registers the malgo devices of a type as drivers, and keeps them in sync with the devices
+// that are plugged in or unplugged through driver.Manager.Rescan.
+type Devices struct {
+	// DeviceType is the type of the malgo devices, i.e. malgo.Capture or malgo.Playback
+	DeviceType malgo.DeviceType
+	// DriverType is the type of the drivers
+	DriverType driver.DeviceType
+	// NewAdapter returns the adapter of a device of ctx
+	NewAdapter func(ctx *malgo.AllocatedContext, info malgo.DeviceInfo) driver.Adapter
+	Logger     logging.LeveledLogger
+
+	initOnce sync.Once
+	ctx      *malgo.AllocatedContext
+	// mu serializes the rescans, and guards adapters
+	mu sync.Mutex
+	// adapters are the registered adapters by their device IDs
+	adapters map[string]driver.Adapter
+}
+
+// Initialize initializes the malgo context and registers the rescanner once, then registers the
+// devices that are found. It panics if any of them fails.
+func (d *Devices) Initialize() {
+	d.initOnce.Do(func() {
+		var err error
+		d.ctx, err = malgo.InitContext(nil, malgo.ContextConfig{}, func(message string) {
+			d.Logger.Debugf("%v\n", message)
+		})
+		if err != nil {
+			panic(err)
+		}
+		d.adapters = make(map[string]driver.Adapter)
+		driver.GetManager().RegisterRescanner(driver.RescannerFunc(d.Rescan))
+	})
+
+	if err := d.Rescan(); err != nil {
+		panic(err)
+	}
+}
+
+// Context returns the malgo context, which is nil until Initialize is called
+func (d *Devices) Context() *malgo.AllocatedContext {
+	return d.ctx
+}
+
+// Rescan registers the devices that have been added, and unregisters the ones that have been removed
+func (d *Devices) Rescan() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	devices, err := d.ctx.Devices(d.DeviceType)
+	if err != nil {
+		return err
+	}
+
+	discovered := make(map[string]struct{})
+	for _, device := range devices {
+		id := device.ID.String()
+		discovered[id] = struct{}{}
+		if _, ok := d.adapters[id]; ok {
+			continue
+		}
+
+		info, err := d.ctx.DeviceInfo(d.DeviceType, device.ID, malgo.Shared)
+		if err == nil {
+			priority := driver.PriorityNormal
+			if info.IsDefault > 0 {
+				priority = driver.PriorityHigh
+			}
+			a := d.NewAdapter(d.ctx, info)
+			driver.GetManager().Register(a, driver.Info{
+				Label:      id,
+				DeviceType: d.DriverType,
+				Priority:   priority,
+				Name:       device.Name(),
+				HardwareID: id,
+			})
+			d.adapters[id] = a
+		}
+	}
+
+	for id, a := range d.adapters {
+		if _, ok := discovered[id]; !ok {
+			_ = driver.GetManager().UnregisterAdapter(a)
+			delete(d.adapters, id)
+		}
+	}
+	return nil
+}
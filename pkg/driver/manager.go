@@ -1,6 +1,59 @@
 package driver
 
-import "sync"
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var errDriverNotFound = errors.New("driver is not registered")
+
+// ErrDeviceRemoved is returned by the adapters that detect that their device has been removed,
+// e.g. unplugged. The drivers wrap it in *DeviceRemovedError.
+var ErrDeviceRemoved = errors.New("device has been removed")
+
+// DeviceRemovedError is returned by the readers of a driver whose device has been removed, e.g.
+// unplugged, so the tracks of the device end with it.
+type DeviceRemovedError struct {
+	DeviceID string
+	Label    string
+}
+
+func (e *DeviceRemovedError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Label, e.DeviceID, ErrDeviceRemoved)
+}
+
+func (e *DeviceRemovedError) Unwrap() error {
+	return ErrDeviceRemoved
+}
+
+// DeviceChangeEvent is emitted when the set of the registered drivers has changed, e.g. a device has
+// been plugged in or unplugged.
+// Reference: https://w3c.github.io/mediacapture-main/#event-mediadevices-devicechange
+type DeviceChangeEvent struct {
+	// Added are the drivers that have been registered
+	Added []Driver
+	// Removed are the drivers that have been unregistered
+	Removed []Driver
+}
+
+// Rescanner is implemented by the driver packages that can discover their devices again. Rescan
+// should register the devices that have been added, and unregister the ones that have been removed.
+type Rescanner interface {
+	Rescan() error
+}
+
+// RescannerFunc is a proxy type for Rescanner
+type RescannerFunc func() error
+
+// Rescan implements Rescanner
+func (f RescannerFunc) Rescan() error {
+	return f()
+}
 
 // FilterFn is being used to decide if a driver should be included in the
 // query result.
@@ -22,7 +75,16 @@ func FilterAudioRecorder() FilterFn {
 	}
 }
 
-// FilterID return a filter function to get registered drivers which have given ID
+// FilterAudioPlayer return a filter function to get a list of registered AudioPlayers
+func FilterAudioPlayer() FilterFn {
+	return func(d Driver) bool {
+		_, ok := d.(AudioPlayer)
+		return ok
+	}
+}
+
+// FilterID return a filter function to get registered drivers which have given ID. The IDs are
+// stable across runs as long as the namespace of Manager is the same.
 func FilterID(id string) FilterFn {
 	return func(d Driver) bool {
 		return d.ID() == id
@@ -55,14 +117,42 @@ func FilterNot(filter FilterFn) FilterFn {
 	}
 }
 
+// idSpace is the UUID namespace of the driver IDs
+var idSpace = uuid.MustParse("8a3f4bbe-6d2e-4c41-9d55-0f6a2f1c7e93")
+
+// driverID derives the ID of the driver from namespace and the identity of its device, so the same
+// device gets the same ID across runs. index distinguishes the devices that have the same identity.
+func driverID(namespace string, info Info, index int) string {
+	hardwareID := info.HardwareID
+	if hardwareID == "" {
+		hardwareID = info.Label
+	}
+	name := strings.Join([]string{namespace, string(info.DeviceType), hardwareID}, "\x00")
+	if index > 0 {
+		name += fmt.Sprintf("\x00%d", index)
+	}
+	return uuid.NewSHA1(idSpace, []byte(name)).String()
+}
+
 // Manager is a singleton to manage multiple drivers and their states
 type Manager struct {
-	mu      sync.Mutex
-	drivers map[string]Driver
+	mu         sync.Mutex
+	drivers    map[string]Driver
+	wrappers   map[string]*adapterWrapper
+	rescanners []Rescanner
+	namespace  string
+
+	subscribersMu sync.Mutex
+	subscribers   []*deviceChangeSubscriber
+}
+
+type deviceChangeSubscriber struct {
+	handler func(DeviceChangeEvent)
 }
 
 var manager = &Manager{
-	drivers: make(map[string]Driver),
+	drivers:  make(map[string]Driver),
+	wrappers: make(map[string]*adapterWrapper),
 }
 
 // GetManager gets manager singleton instance
@@ -70,15 +160,169 @@ func GetManager() *Manager {
 	return manager
 }
 
-// Register registers adapter to be discoverable by Query
+// Register registers adapter to be discoverable by Query. The ID of the driver is derived from
+// info, so the device keeps its ID across runs. If another driver of the same device has been
+// registered, the ID depends on the order of the registration.
 func (m *Manager) Register(a Adapter, info Info) error {
+	m.register(a, info)
+	return nil
+}
+
+// RegisterAdapter registers a like Register, and returns the ID of its driver, which can be used as
+// the DeviceID constraint of GetUserMedia.
+func (m *Manager) RegisterAdapter(a Adapter, info Info) (string, error) {
+	return m.register(a, info), nil
+}
+
+func (m *Manager) register(a Adapter, info Info) string {
+	w := newAdapterWrapper(a, info)
+	d := w.driver()
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	d := wrapAdapter(a, info)
-	m.drivers[d.ID()] = d
+	for {
+		w.id = driverID(m.namespace, info, w.idIndex)
+		if _, ok := m.drivers[w.id]; !ok {
+			break
+		}
+		w.idIndex++
+	}
+	m.drivers[w.id] = d
+	m.wrappers[w.id] = w
+	id := w.id
+	m.mu.Unlock()
+
+	m.emit(DeviceChangeEvent{Added: []Driver{d}})
+	return id
+}
+
+// RegisterFile registers a, which reads or writes the file at path, as a device of deviceType with
+// Manager.RegisterAdapter. The device is labeled by the file name, and has the low priority, so the
+// real devices are preferred unless it's asked for by its ID.
+func RegisterFile(a Adapter, path string, deviceType DeviceType) (string, error) {
+	return GetManager().RegisterAdapter(a, Info{
+		Label:      filepath.Base(path),
+		DeviceType: deviceType,
+		Priority:   PriorityLow,
+		HardwareID: "file://" + path,
+	})
+}
+
+// Unregister removes the driver that has id, so it's no longer discoverable by Query. If the driver
+// is open, it's closed, and its readers return *DeviceRemovedError, so the tracks of the device end.
+func (m *Manager) Unregister(id string) error {
+	m.mu.Lock()
+	d, ok := m.drivers[id]
+	w := m.wrappers[id]
+	delete(m.drivers, id)
+	delete(m.wrappers, id)
+	m.mu.Unlock()
+	if !ok {
+		return errDriverNotFound
+	}
+
+	w.remove()
+	m.emit(DeviceChangeEvent{Removed: []Driver{d}})
 	return nil
 }
 
+// UnregisterAdapter unregisters the driver of a, which has been registered by Register. It's for
+// the driver packages that don't know the IDs of their drivers. See Unregister for the details.
+func (m *Manager) UnregisterAdapter(a Adapter) error {
+	id, err := m.AdapterID(a)
+	if err != nil {
+		return err
+	}
+	return m.Unregister(id)
+}
+
+// AdapterID returns the ID of the driver of a, which has been registered by Register
+func (m *Manager) AdapterID(a Adapter) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, w := range m.wrappers {
+		if w.Adapter == a {
+			return id, nil
+		}
+	}
+	return "", errDriverNotFound
+}
+
+// SetNamespace salts the IDs of the drivers with namespace, like browsers do with the origin, so
+// the IDs can't be used to track the devices across applications. The IDs of the drivers that
+// have already been registered are changed, so it should be called before the drivers are used.
+func (m *Manager) SetNamespace(namespace string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.namespace = namespace
+	drivers := make(map[string]Driver, len(m.drivers))
+	wrappers := make(map[string]*adapterWrapper, len(m.wrappers))
+	for id, w := range m.wrappers {
+		newID := driverID(namespace, w.info, w.idIndex)
+		w.setID(newID)
+		drivers[newID] = m.drivers[id]
+		wrappers[newID] = w
+	}
+	m.drivers = drivers
+	m.wrappers = wrappers
+}
+
+// RegisterRescanner adds r to be called by Rescan. It's called by the driver packages that can
+// discover their devices again.
+func (m *Manager) RegisterRescanner(r Rescanner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rescanners = append(m.rescanners, r)
+}
+
+// Rescan asks all the driver packages that support it to discover their devices again. The changes
+// are emitted as DeviceChangeEvents. All of the rescanners are called even if some of them fail, and
+// the first error is returned.
+func (m *Manager) Rescan() error {
+	m.mu.Lock()
+	rescanners := append([]Rescanner(nil), m.rescanners...)
+	m.mu.Unlock()
+
+	var rescanErr error
+	for _, r := range rescanners {
+		if err := r.Rescan(); err != nil && rescanErr == nil {
+			rescanErr = err
+		}
+	}
+	return rescanErr
+}
+
+// Subscribe registers handler to receive DeviceChangeEvents, and returns a function to unsubscribe.
+// The handlers are called synchronously from the goroutine that changed the drivers, e.g. the
+// hotplug watcher of a driver package, so they shouldn't block.
+func (m *Manager) Subscribe(handler func(DeviceChangeEvent)) (unsubscribe func()) {
+	m.subscribersMu.Lock()
+	defer m.subscribersMu.Unlock()
+
+	subscriber := &deviceChangeSubscriber{handler: handler}
+	m.subscribers = append(m.subscribers, subscriber)
+
+	return func() {
+		m.subscribersMu.Lock()
+		defer m.subscribersMu.Unlock()
+		for i, s := range m.subscribers {
+			if s == subscriber {
+				m.subscribers = append(m.subscribers[:i:i], m.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (m *Manager) emit(event DeviceChangeEvent) {
+	m.subscribersMu.Lock()
+	subscribers := m.subscribers
+	m.subscribersMu.Unlock()
+
+	for _, s := range subscribers {
+		s.handler(event)
+	}
+}
+
 // Query queries by using f to filter drivers, and simply return the filtered results.
 func (m *Manager) Query(f FilterFn) []Driver {
 	m.mu.Lock()
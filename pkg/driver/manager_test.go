@@ -1,11 +1,15 @@
 package driver
 
 import (
+	"errors"
+	"image"
+	"io"
+	"testing"
+
 	"github.com/pion/mediadevices/pkg/io/audio"
 	"github.com/pion/mediadevices/pkg/io/video"
 	"github.com/pion/mediadevices/pkg/prop"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func filterTrue(_ Driver) bool {
@@ -112,3 +116,162 @@ func TestQuerySync(t *testing.T) {
 	// write while reading
 	assert.NoError(t, m.Register(&fakeVideoAdapter{}, Info{}))
 }
+
+// closableVideoAdapter records frames until it's closed
+type closableVideoAdapter struct {
+	closed chan struct{}
+}
+
+func (a *closableVideoAdapter) Open() error {
+	a.closed = make(chan struct{})
+	return nil
+}
+
+func (a *closableVideoAdapter) Close() error {
+	close(a.closed)
+	return nil
+}
+
+func (a *closableVideoAdapter) Properties() []prop.Media { return []prop.Media{{}} }
+
+func (a *closableVideoAdapter) VideoRecord(_ prop.Media) (video.Reader, error) {
+	return video.ReaderFunc(func() (image.Image, func(), error) {
+		select {
+		case <-a.closed:
+			return nil, func() {}, io.EOF
+		default:
+			return image.NewGray(image.Rect(0, 0, 1, 1)), func() {}, nil
+		}
+	}), nil
+}
+
+func TestUnregister(t *testing.T) {
+	m := GetManager()
+
+	var events []DeviceChangeEvent
+	unsubscribe := m.Subscribe(func(e DeviceChangeEvent) {
+		events = append(events, e)
+	})
+	defer unsubscribe()
+
+	a := &closableVideoAdapter{}
+	assert.NoError(t, m.Register(a, Info{Label: "unplugged"}))
+	drivers := m.Query(FilterFn(func(d Driver) bool { return d.Info().Label == "unplugged" }))
+	if len(drivers) != 1 {
+		t.Fatalf("Expected the driver to be registered, got %d drivers", len(drivers))
+	}
+	d := drivers[0]
+	if len(events) != 1 || len(events[0].Added) != 1 || events[0].Added[0] != d {
+		t.Errorf("Expected an event of the added driver, got %v", events)
+	}
+
+	assert.NoError(t, d.Open())
+	r, err := d.(VideoRecorder).VideoRecord(prop.Media{})
+	assert.NoError(t, err)
+	_, _, err = r.Read()
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Unregister(d.ID()))
+	if n := len(m.Query(FilterID(d.ID()))); n != 0 {
+		t.Errorf("Expected the driver to be unregistered, got %d drivers", n)
+	}
+	if len(events) != 2 || len(events[1].Removed) != 1 || events[1].Removed[0] != d {
+		t.Errorf("Expected an event of the removed driver, got %v", events)
+	}
+	if d.Status() != StateClosed {
+		t.Errorf("Expected the driver to be closed, got %v", d.Status())
+	}
+
+	var removed *DeviceRemovedError
+	if _, _, err := r.Read(); !errors.As(err, &removed) || removed.DeviceID != d.ID() {
+		t.Errorf("Expected the reader to fail with DeviceRemovedError, got %v", err)
+	}
+	if err := d.Open(); !errors.Is(err, ErrDeviceRemoved) {
+		t.Errorf("Expected the removed driver not to be opened, got %v", err)
+	}
+	assert.NoError(t, d.Close(), "closing the removed driver again should do nothing")
+
+	assert.Error(t, m.Unregister(d.ID()), "should not unregister the driver twice")
+	assert.Error(t, m.UnregisterAdapter(a), "should not unregister the adapter twice")
+}
+
+func TestRescan(t *testing.T) {
+	m := GetManager()
+
+	var called int
+	m.RegisterRescanner(RescannerFunc(func() error {
+		called++
+		return nil
+	}))
+	errRescan := errors.New("failed to rescan")
+	m.RegisterRescanner(RescannerFunc(func() error {
+		called++
+		return errRescan
+	}))
+
+	if err := m.Rescan(); err != errRescan {
+		t.Errorf("Expected %v, got %v", errRescan, err)
+	}
+	if called != 2 {
+		t.Errorf("Expected all the rescanners to be called, got %d", called)
+	}
+}
+
+func TestDriverID(t *testing.T) {
+	m := GetManager()
+	info := Info{Label: "stable", DeviceType: Camera, HardwareID: "/dev/v4l/by-path/stable"}
+	idOf := func(a Adapter) string {
+		id, err := m.AdapterID(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	a1, a2 := &closableVideoAdapter{}, &closableVideoAdapter{}
+	assert.NoError(t, m.Register(a1, info))
+	assert.NoError(t, m.Register(a2, info))
+	id1, id2 := idOf(a1), idOf(a2)
+	if id1 != driverID("", info, 0) {
+		t.Errorf("Expected the ID to be derived from the info, got %s", id1)
+	}
+	if id1 == id2 {
+		t.Error("Expected the drivers of the same device to have different IDs")
+	}
+
+	assert.NoError(t, m.UnregisterAdapter(a1))
+	assert.NoError(t, m.Register(a1, info))
+	if id := idOf(a1); id != id1 {
+		t.Errorf("Expected the re-registered driver to have the same ID %s, got %s", id1, id)
+	}
+
+	m.SetNamespace("origin")
+	defer m.SetNamespace("")
+	id := idOf(a1)
+	if id == id1 {
+		t.Error("Expected the ID to be changed by the namespace")
+	}
+	if drivers := m.Query(FilterID(id)); len(drivers) != 1 || drivers[0].ID() != id {
+		t.Error("Expected the driver to be queried by the new ID")
+	}
+
+	assert.NoError(t, m.UnregisterAdapter(a1))
+	assert.NoError(t, m.UnregisterAdapter(a2))
+}
+
+func TestRegisterFile(t *testing.T) {
+	m := GetManager()
+	a := &fakeVideoAdapter{}
+	id, err := RegisterFile(a, "/tmp/slate.y4m", Camera)
+	assert.NoError(t, err)
+	defer m.UnregisterAdapter(a)
+
+	drivers := m.Query(FilterID(id))
+	if len(drivers) != 1 {
+		t.Fatalf("Expected the driver to be queried by the returned ID, got %d drivers", len(drivers))
+	}
+	expected := Info{Label: "slate.y4m", DeviceType: Camera, Priority: PriorityLow, HardwareID: "file:///tmp/slate.y4m"}
+	if info := drivers[0].Info(); info != expected {
+		t.Errorf("Expected %v, got %v", expected, info)
+	}
+}
@@ -4,15 +4,14 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
-	"fmt"
 	"io"
 	"sync"
 	"time"
-	"unsafe"
 
 	"github.com/gen2brain/malgo"
 	"github.com/pion/mediadevices/internal/logging"
 	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/driver/internal/miniaudio"
 	"github.com/pion/mediadevices/pkg/io/audio"
 	"github.com/pion/mediadevices/pkg/prop"
 	"github.com/pion/mediadevices/pkg/wave"
@@ -26,71 +25,52 @@ const (
 )
 
 var logger = logging.NewLogger("mediadevices/driver/microphone")
-var ctx *malgo.AllocatedContext
-var hostEndian binary.ByteOrder
 var (
 	errUnsupportedFormat = errors.New("the provided audio format is not supported")
 )
 
 type microphone struct {
 	malgo.DeviceInfo
-	chunkChan       chan []byte
+	ctx             *malgo.AllocatedContext
+	chunkChan       chan capturedChunk
 	deviceCloseFunc func()
 }
 
+// capturedChunk is a raw audio chunk with the capture time of its first sample
+type capturedChunk struct {
+	data      []byte
+	timestamp time.Time
+}
+
+// devices are the registered microphones
+var devices = &miniaudio.Devices{
+	DeviceType: malgo.Capture,
+	DriverType: driver.Microphone,
+	NewAdapter: func(ctx *malgo.AllocatedContext, info malgo.DeviceInfo) driver.Adapter {
+		return newMicrophone(ctx, info)
+	},
+	Logger: logger,
+}
+
 func init() {
 	Initialize()
 }
 
-// Initialize finds and registers active playback or capture devices. This is part of an experimental API.
+// Initialize finds and registers active playback or capture devices. The devices that are plugged in or
+// unplugged later can be discovered again by driver.Manager.Rescan. This is part of an experimental API.
 func Initialize() {
-	var err error
-	ctx, err = malgo.InitContext(nil, malgo.ContextConfig{}, func(message string) {
-		logger.Debugf("%v\n", message)
-	})
-	if err != nil {
-		panic(err)
-	}
-
-	devices, err := ctx.Devices(malgo.Capture)
-	if err != nil {
-		panic(err)
-	}
-
-	for _, device := range devices {
-		info, err := ctx.DeviceInfo(malgo.Capture, device.ID, malgo.Shared)
-		if err == nil {
-			priority := driver.PriorityNormal
-			if info.IsDefault > 0 {
-				priority = driver.PriorityHigh
-			}
-			driver.GetManager().Register(newMicrophone(info), driver.Info{
-				Label:      device.ID.String(),
-				DeviceType: driver.Microphone,
-				Priority:   priority,
-			})
-		}
-	}
-
-	// Decide which endian
-	switch v := *(*uint16)(unsafe.Pointer(&([]byte{0x12, 0x34}[0]))); v {
-	case 0x1234:
-		hostEndian = binary.BigEndian
-	case 0x3412:
-		hostEndian = binary.LittleEndian
-	default:
-		panic(fmt.Sprintf("failed to determine host endianness: %x", v))
-	}
+	devices.Initialize()
 }
 
-func newMicrophone(info malgo.DeviceInfo) *microphone {
+func newMicrophone(ctx *malgo.AllocatedContext, info malgo.DeviceInfo) *microphone {
 	return &microphone{
 		DeviceInfo: info,
+		ctx:        ctx,
 	}
 }
 
 func (m *microphone) Open() error {
-	m.chunkChan = make(chan []byte, 1)
+	m.chunkChan = make(chan capturedChunk, 1)
 	return nil
 }
 
@@ -131,14 +111,17 @@ func (m *microphone) AudioRecord(inputProp prop.Media) (audio.Reader, error) {
 
 	cancelCtx, cancel := context.WithCancel(context.Background())
 	onRecvChunk := func(_, chunk []byte, framecount uint32) {
+		// The callback is called when the last sample of the chunk has been captured
+		duration := time.Duration(framecount) * time.Second / time.Duration(inputProp.SampleRate)
+		captured := capturedChunk{data: chunk, timestamp: time.Now().Add(-duration)}
 		select {
 		case <-cancelCtx.Done():
-		case m.chunkChan <- chunk:
+		case m.chunkChan <- captured:
 		}
 	}
 	callbacks.Data = onRecvChunk
 
-	device, err := malgo.InitDevice(ctx.Context, config, callbacks)
+	device, err := malgo.InitDevice(m.ctx.Context, config, callbacks)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -163,14 +146,16 @@ func (m *microphone) AudioRecord(inputProp prop.Media) (audio.Reader, error) {
 		})
 	}
 
+	var timestamp time.Time
 	var reader audio.Reader = audio.ReaderFunc(func() (wave.Audio, func(), error) {
 		chunk, ok := <-m.chunkChan
 		if !ok {
 			m.deviceCloseFunc()
 			return nil, func() {}, io.EOF
 		}
+		timestamp = chunk.timestamp
 
-		decodedChunk, err := decoder.Decode(hostEndian, chunk, inputProp.ChannelCount)
+		decodedChunk, err := decoder.Decode(miniaudio.HostEndian, chunk.data, inputProp.ChannelCount)
 		// FIXME: the decoder should also fill this information
 		switch decodedChunk := decodedChunk.(type) {
 		case *wave.Float32Interleaved:
@@ -183,7 +168,9 @@ func (m *microphone) AudioRecord(inputProp prop.Media) (audio.Reader, error) {
 		return decodedChunk, func() {}, err
 	})
 
-	return reader, nil
+	return audio.WithTimestamp(reader, func() time.Time {
+		return timestamp
+	}), nil
 }
 
 func (m *microphone) Properties() []prop.Media {
@@ -192,7 +179,7 @@ func (m *microphone) Properties() []prop.Media {
 
 	var isBigEndian bool
 	// miniaudio only uses the host endian
-	if hostEndian == binary.BigEndian {
+	if miniaudio.HostEndian == binary.BigEndian {
 		isBigEndian = true
 	}
 
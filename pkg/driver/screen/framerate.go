@@ -0,0 +1,19 @@
+package screen
+
+import "github.com/pion/mediadevices/pkg/prop"
+
+// frameRates are the frame rates that the screens can be captured at
+var frameRates = []float32{5, 10, 15, 20, 24, 25, 30, 50, 60}
+
+// frameRateProperties returns p without the frame rate followed by p at each of the frame rates. The
+// property without the frame rate is selected when the frame rate isn't constrained, so the screen is
+// captured at its default frame rate.
+func frameRateProperties(p prop.Media) []prop.Media {
+	props := make([]prop.Media, 0, len(frameRates)+1)
+	props = append(props, p)
+	for _, frameRate := range frameRates {
+		p.FrameRate = frameRate
+		props = append(props, p)
+	}
+	return props
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"image"
 	"io"
+	"time"
 
 	"github.com/kbinani/screenshot"
 	"github.com/pion/mediadevices/pkg/driver"
@@ -17,6 +18,7 @@ import (
 type screen struct {
 	displayIndex int
 	doneCh       chan struct{}
+	tick         *time.Ticker
 }
 
 func init() {
@@ -55,22 +57,44 @@ func (s *screen) Open() error {
 
 func (s *screen) Close() error {
 	close(s.doneCh)
+	if s.tick != nil {
+		s.tick.Stop()
+	}
 	return nil
 }
 
 func (s *screen) VideoRecord(selectedProp prop.Media) (video.Reader, error) {
+	// The screen is captured as fast as possible by default
+	var tick *time.Ticker
+	if selectedProp.FrameRate != 0 {
+		tick = time.NewTicker(time.Duration(float32(time.Second) / selectedProp.FrameRate))
+		s.tick = tick
+	}
+
+	var timestamp time.Time
 	r := video.ReaderFunc(func() (img image.Image, release func(), err error) {
-		select {
-		case <-s.doneCh:
-			return nil, nil, io.EOF
-		default:
+		if tick != nil {
+			select {
+			case <-s.doneCh:
+				return nil, nil, io.EOF
+			case <-tick.C:
+			}
+		} else {
+			select {
+			case <-s.doneCh:
+				return nil, nil, io.EOF
+			default:
+			}
 		}
 
+		timestamp = time.Now()
 		img, err = screenshot.CaptureDisplay(s.displayIndex)
 		release = func() {}
 		return
 	})
-	return r, nil
+	return video.WithTimestamp(r, func() time.Time {
+		return timestamp
+	}), nil
 }
 
 func (s *screen) Properties() []prop.Media {
@@ -82,5 +106,5 @@ func (s *screen) Properties() []prop.Media {
 			FrameFormat: frame.FormatRGBA,
 		},
 	}
-	return []prop.Media{supportedProp}
+	return frameRateProperties(supportedProp)
 }
@@ -11,6 +11,10 @@ import (
 	"github.com/pion/mediadevices/pkg/prop"
 )
 
+// defaultFrameRate is the frame rate that the screens are captured at when the frame rate isn't
+// constrained
+const defaultFrameRate = 10
+
 type screen struct {
 	num    int
 	reader *reader
@@ -66,32 +70,33 @@ func (s *screen) Close() error {
 
 func (s *screen) VideoRecord(p prop.Media) (video.Reader, error) {
 	if p.FrameRate == 0 {
-		p.FrameRate = 10
+		p.FrameRate = defaultFrameRate
 	}
 	s.tick = time.NewTicker(time.Duration(float32(time.Second) / p.FrameRate))
 
 	var dst image.RGBA
 	reader := s.reader
+	var timestamp time.Time
 
 	r := video.ReaderFunc(func() (image.Image, func(), error) {
-		<-s.tick.C
+		timestamp = <-s.tick.C
 		return reader.Read().ToRGBA(&dst), func() {}, nil
 	})
-	return r, nil
+	return video.WithTimestamp(r, func() time.Time {
+		return timestamp
+	}), nil
 }
 
 func (s *screen) Properties() []prop.Media {
 	rect := s.reader.img.Bounds()
 	w := rect.Dx()
 	h := rect.Dy()
-	return []prop.Media{
-		{
-			DeviceID: deviceID(s.num),
-			Video: prop.Video{
-				Width:       w,
-				Height:      h,
-				FrameFormat: frame.FormatRGBA,
-			},
+	return frameRateProperties(prop.Media{
+		DeviceID: deviceID(s.num),
+		Video: prop.Video{
+			Width:       w,
+			Height:      h,
+			FrameFormat: frame.FormatRGBA,
 		},
-	}
+	})
 }
@@ -0,0 +1,227 @@
+// Package speaker provides the audio output devices, which render audio.Reader with malgo.
+package speaker
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gen2brain/malgo"
+	"github.com/pion/mediadevices/internal/logging"
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/driver/internal/miniaudio"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// bufferedChunks is the number of the chunks that are buffered ahead of the playback
+const bufferedChunks = 4
+
+// defaultSampleRates are the sample rates of the formats whose sample rate isn't reported by the device
+var defaultSampleRates = []int{48000, 44100, 32000, 24000, 16000, 8000}
+
+var logger = logging.NewLogger("mediadevices/driver/speaker")
+var (
+	errUnsupportedFormat = errors.New("the provided audio format is not supported")
+)
+
+type speaker struct {
+	malgo.DeviceInfo
+	ctx    *malgo.AllocatedContext
+	closed <-chan struct{}
+	cancel func()
+}
+
+// devices are the registered speakers
+var devices = &miniaudio.Devices{
+	DeviceType: malgo.Playback,
+	DriverType: driver.Speaker,
+	NewAdapter: func(ctx *malgo.AllocatedContext, info malgo.DeviceInfo) driver.Adapter {
+		return newSpeaker(ctx, info)
+	},
+	Logger: logger,
+}
+
+func init() {
+	Initialize()
+}
+
+// Initialize finds and registers active playback devices. The devices that are plugged in or unplugged
+// later can be discovered again by driver.Manager.Rescan. This is part of an experimental API.
+func Initialize() {
+	devices.Initialize()
+}
+
+func newSpeaker(ctx *malgo.AllocatedContext, info malgo.DeviceInfo) *speaker {
+	return &speaker{
+		DeviceInfo: info,
+		ctx:        ctx,
+	}
+}
+
+func (s *speaker) Open() error {
+	closeCtx, cancel := context.WithCancel(context.Background())
+	s.closed = closeCtx.Done()
+	s.cancel = cancel
+	return nil
+}
+
+func (s *speaker) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+func (s *speaker) AudioPlay(r audio.Reader, p prop.Media) error {
+	encoder, err := wave.NewEncoder(&wave.RawFormat{
+		SampleSize:  p.SampleSize,
+		IsFloat:     p.IsFloat,
+		Interleaved: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	config := malgo.DefaultDeviceConfig(malgo.Playback)
+	config.PerformanceProfile = malgo.LowLatency
+	config.Playback.Channels = uint32(p.ChannelCount)
+	config.SampleRate = uint32(p.SampleRate)
+	config.PeriodSizeInMilliseconds = uint32(p.Latency.Milliseconds())
+	config.Playback.DeviceID = s.ID.Pointer()
+	if p.SampleSize == 4 && p.IsFloat {
+		config.Playback.Format = malgo.FormatF32
+	} else if p.SampleSize == 2 && !p.IsFloat {
+		config.Playback.Format = malgo.FormatS16
+	} else {
+		return errUnsupportedFormat
+	}
+
+	// The encoded chunks are sent to the device through chunks, which is closed once r has ended.
+	// drained is closed once the device has played all of them.
+	chunks := make(chan []byte, bufferedChunks)
+	drained := make(chan struct{})
+	var pending []byte
+	var drainOnce sync.Once
+	onSendChunk := func(out, _ []byte, _ uint32) {
+		for len(out) > 0 {
+			if len(pending) == 0 {
+				var ok bool
+				select {
+				case pending, ok = <-chunks:
+					if !ok {
+						drainOnce.Do(func() { close(drained) })
+					}
+				default:
+				}
+				if len(pending) == 0 {
+					// Fill the rest with silence on underrun
+					for i := range out {
+						out[i] = 0
+					}
+					return
+				}
+			}
+			n := copy(out, pending)
+			out, pending = out[n:], pending[n:]
+		}
+	}
+
+	device, err := malgo.InitDevice(s.ctx.Context, config, malgo.DeviceCallbacks{Data: onSendChunk})
+	if err != nil {
+		return err
+	}
+	defer device.Uninit()
+
+	if err := device.Start(); err != nil {
+		return err
+	}
+
+	closed := s.closed
+	for {
+		chunk, release, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		info := chunk.ChunkInfo()
+		if info.Channels != p.ChannelCount || (info.SamplingRate != 0 && info.SamplingRate != p.SampleRate) {
+			release()
+			return fmt.Errorf("%w: expected %d channels at %d Hz, got %d channels at %d Hz",
+				errUnsupportedFormat, p.ChannelCount, p.SampleRate, info.Channels, info.SamplingRate)
+		}
+		data, err := encoder.Encode(miniaudio.HostEndian, nil, chunk)
+		release()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case chunks <- data:
+		case <-closed:
+			return nil
+		}
+	}
+
+	close(chunks)
+	select {
+	case <-drained:
+		// Wait for the last period to be played
+		time.Sleep(p.Latency)
+	case <-closed:
+	}
+	return nil
+}
+
+func (s *speaker) Properties() []prop.Media {
+	var supportedProps []prop.Media
+	logger.Debug("Querying properties")
+
+	var isBigEndian bool
+	// miniaudio only uses the host endian
+	if miniaudio.HostEndian == binary.BigEndian {
+		isBigEndian = true
+	}
+
+	for _, format := range s.Formats {
+		supportedProp := prop.Media{
+			Audio: prop.Audio{
+				ChannelCount: int(format.Channels),
+				IsBigEndian:  isBigEndian,
+				// miniaudio only supports interleaved at the moment
+				IsInterleaved: true,
+				Latency:       time.Millisecond * 20,
+			},
+		}
+
+		switch malgo.FormatType(format.Format) {
+		case malgo.FormatF32:
+			supportedProp.SampleSize = 4
+			supportedProp.IsFloat = true
+		case malgo.FormatS16:
+			supportedProp.SampleSize = 2
+			supportedProp.IsFloat = false
+		default:
+			continue
+		}
+
+		// The device converts the other sample rates if it doesn't tell its own one
+		sampleRates := defaultSampleRates
+		if format.SampleRate != 0 {
+			sampleRates = []int{int(format.SampleRate)}
+		}
+		for _, sampleRate := range sampleRates {
+			supportedProp.SampleRate = sampleRate
+			supportedProps = append(supportedProps, supportedProp)
+		}
+	}
+	return supportedProps
+}
@@ -0,0 +1,157 @@
+package speaker
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gen2brain/malgo"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// nullBackend is the null backend of miniaudio, which plays audio in real time without any device.
+// malgo.BackendNull is off by one since malgo's enumeration misses ma_backend_custom.
+const nullBackend = malgo.BackendNull + 1
+
+// newNullSpeaker returns the speaker of the null backend, and a function to free its context
+func newNullSpeaker(t *testing.T) (*speaker, func()) {
+	nullCtx, err := malgo.InitContext([]malgo.Backend{nullBackend}, malgo.ContextConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	free := func() {
+		_ = nullCtx.Uninit()
+		nullCtx.Free()
+	}
+
+	devices, err := nullCtx.Devices(malgo.Playback)
+	if err != nil {
+		free()
+		t.Fatal(err)
+	}
+	if len(devices) == 0 {
+		free()
+		t.Fatal("Expected the null backend to have a playback device")
+	}
+	info, err := nullCtx.DeviceInfo(malgo.Playback, devices[0].ID, malgo.Shared)
+	if err != nil {
+		free()
+		t.Fatal(err)
+	}
+	return newSpeaker(nullCtx, info), free
+}
+
+func TestAudioPlay(t *testing.T) {
+	s, free := newNullSpeaker(t)
+	defer free()
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	p := prop.Media{
+		Audio: prop.Audio{
+			ChannelCount:  2,
+			SampleRate:    48000,
+			SampleSize:    2,
+			IsInterleaved: true,
+			Latency:       20 * time.Millisecond,
+		},
+	}
+
+	const nChunks = 5
+	var read int
+	r := audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		if read == nChunks {
+			return nil, func() {}, io.EOF
+		}
+		read++
+		return wave.NewFloat32Interleaved(wave.ChunkInfo{Len: 960, Channels: 2, SamplingRate: 48000}), func() {}, nil
+	})
+
+	start := time.Now()
+	if err := s.AudioPlay(r, p); err != nil {
+		t.Fatal(err)
+	}
+	if read != nChunks {
+		t.Errorf("Expected all the %d chunks to be played, got %d", nChunks, read)
+	}
+	// The chunks beyond the buffer are played in real time
+	if elapsed := time.Since(start); elapsed < (nChunks-bufferedChunks)*p.Latency {
+		t.Errorf("Expected the playback to take at least %v, got %v", (nChunks-bufferedChunks)*p.Latency, elapsed)
+	}
+}
+
+func TestAudioPlayFormatMismatch(t *testing.T) {
+	s, free := newNullSpeaker(t)
+	defer free()
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	p := prop.Media{
+		Audio: prop.Audio{ChannelCount: 2, SampleRate: 48000, SampleSize: 4, IsFloat: true, Latency: 20 * time.Millisecond},
+	}
+	r := audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		return wave.NewFloat32Interleaved(wave.ChunkInfo{Len: 960, Channels: 1, SamplingRate: 48000}), func() {}, nil
+	})
+	if err := s.AudioPlay(r, p); err == nil {
+		t.Error("Expected an error of the mismatched channels")
+	}
+}
+
+func TestAudioPlayClose(t *testing.T) {
+	s, free := newNullSpeaker(t)
+	defer free()
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := prop.Media{
+		Audio: prop.Audio{ChannelCount: 1, SampleRate: 48000, SampleSize: 2, Latency: 20 * time.Millisecond},
+	}
+	r := audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		return wave.NewInt16Interleaved(wave.ChunkInfo{Len: 960, Channels: 1, SamplingRate: 48000}), func() {}, nil
+	})
+
+	done := make(chan error)
+	go func() { done <- s.AudioPlay(r, p) }()
+	time.Sleep(50 * time.Millisecond)
+	s.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the playback to end without an error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the playback to end once the speaker has been closed")
+	}
+}
+
+func TestProperties(t *testing.T) {
+	s := newSpeaker(nil, malgo.DeviceInfo{
+		Formats: []malgo.DataFormat{
+			{Format: malgo.FormatF32, Channels: 2, SampleRate: 44100},
+			{Format: malgo.FormatS16, Channels: 1},
+			{Format: malgo.FormatU8, Channels: 1, SampleRate: 8000},
+		},
+	})
+
+	props := s.Properties()
+	if len(props) != 1+len(defaultSampleRates) {
+		t.Fatalf("Expected %d properties, got %v", 1+len(defaultSampleRates), props)
+	}
+	if p := props[0].Audio; p.SampleRate != 44100 || p.ChannelCount != 2 || p.SampleSize != 4 || !p.IsFloat {
+		t.Errorf("Expected 2 channels of float32 at 44100 Hz, got %+v", p)
+	}
+	// The sample rate of the format isn't reported, so the default ones are
+	for i, sampleRate := range defaultSampleRates {
+		if p := props[i+1].Audio; p.SampleRate != sampleRate || p.ChannelCount != 1 || p.SampleSize != 2 {
+			t.Errorf("Expected 1 channel of int16 at %d Hz, got %+v", sampleRate, p)
+		}
+	}
+}
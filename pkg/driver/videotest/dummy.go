@@ -3,6 +3,7 @@ package videotest
 
 import (
 	"context"
+	"fmt"
 	"image"
 	"io"
 	"math/rand"
@@ -16,19 +17,77 @@ import (
 
 func init() {
 	driver.GetManager().Register(
-		newVideoTest(),
+		newVideoTest(Config{}),
 		driver.Info{Label: "VideoTest", DeviceType: driver.Camera},
 	)
 }
 
+// frameRates are the frame rates that the test video can be generated at. The first one is the default,
+// which is selected when the frame rate isn't constrained.
+var frameRates = []float32{30, 15, 60}
+
+// Config is the configuration of a test video, which is generated as color bars with noise
+type Config struct {
+	// Label is the label of the driver. It defaults to "VideoTest".
+	Label string
+	// Width and Height are the size of the frames, which defaults to 640x480. They have to be even.
+	Width, Height int
+	// FrameRates are the frame rates that the video can be generated at. The first one is the
+	// default. It defaults to 30, 15 and 60.
+	FrameRates []float32
+
+	// MovingBox draws a white box that moves by 4 pixels every frame, so dropped and duplicated frames
+	// are visible.
+	MovingBox bool
+	// FrameCounter embeds the number of each frame as a barcode at the top of the frame, which can be
+	// read by DecodeFrameCounter to count dropped and duplicated frames.
+	FrameCounter bool
+	// Timestamp embeds the capture time of each frame as a barcode below the frame counter, which can
+	// be read by DecodeTimestamp to measure the latency.
+	Timestamp bool
+	// ResolutionChanges change the size of the frames in the middle of the stream. The frames are
+	// generated in the size of the last change that has been reached.
+	ResolutionChanges []ResolutionChange
+}
+
+// ResolutionChange changes the size of the frames to Width x Height from the Frame-th frame
+type ResolutionChange struct {
+	Frame         int
+	Width, Height int
+}
+
+// Register registers a test video of c as a Camera device, and returns the ID of its driver
+func Register(c Config) (string, error) {
+	if c.Label == "" {
+		c.Label = "VideoTest"
+	}
+	if c.Width < 0 || c.Height < 0 || c.Width%2 != 0 || c.Height%2 != 0 {
+		return "", fmt.Errorf("videotest: the size has to be even, got %dx%d", c.Width, c.Height)
+	}
+	for _, change := range c.ResolutionChanges {
+		if change.Width <= 0 || change.Height <= 0 || change.Width%2 != 0 || change.Height%2 != 0 {
+			return "", fmt.Errorf("videotest: the size has to be even, got %dx%d", change.Width, change.Height)
+		}
+	}
+
+	return driver.GetManager().RegisterAdapter(newVideoTest(c), driver.Info{Label: c.Label, DeviceType: driver.Camera})
+}
+
 type dummy struct {
+	config Config
 	closed <-chan struct{}
 	cancel func()
 	tick   *time.Ticker
 }
 
-func newVideoTest() *dummy {
-	return &dummy{}
+func newVideoTest(c Config) *dummy {
+	if c.Width == 0 || c.Height == 0 {
+		c.Width, c.Height = 640, 480
+	}
+	if len(c.FrameRates) == 0 {
+		c.FrameRates = frameRates
+	}
+	return &dummy{config: c}
 }
 
 func (d *dummy) Open() error {
@@ -46,7 +105,15 @@ func (d *dummy) Close() error {
 	return nil
 }
 
-func (d *dummy) VideoRecord(p prop.Media) (video.Reader, error) {
+// colorBars is the base pattern of the frames of a size
+type colorBars struct {
+	width, height               int
+	yy, cb, cr                  []byte
+	yyBase, cbBase, crBase      []byte
+	hColorBarEnd, wGradationEnd int
+}
+
+func newColorBars(width, height int) *colorBars {
 	colors := [][3]byte{
 		{235, 128, 128},
 		{210, 16, 146},
@@ -57,47 +124,81 @@ func (d *dummy) VideoRecord(p prop.Media) (video.Reader, error) {
 		{41, 240, 110},
 	}
 
-	yi := p.Width * p.Height
+	yi := width * height
 	ci := yi / 2
-	yy := make([]byte, yi)
-	cb := make([]byte, ci)
-	cr := make([]byte, ci)
-	yyBase := make([]byte, yi)
-	cbBase := make([]byte, ci)
-	crBase := make([]byte, ci)
-	hColorBarEnd := p.Height * 3 / 4
-	wGradationEnd := p.Width * 5 / 7
-	for y := 0; y < hColorBarEnd; y++ {
-		yi := p.Width * y
-		ci := p.Width * y / 2
+	b := &colorBars{
+		width:         width,
+		height:        height,
+		yy:            make([]byte, yi),
+		cb:            make([]byte, ci),
+		cr:            make([]byte, ci),
+		yyBase:        make([]byte, yi),
+		cbBase:        make([]byte, ci),
+		crBase:        make([]byte, ci),
+		hColorBarEnd:  height * 3 / 4,
+		wGradationEnd: width * 5 / 7,
+	}
+	for y := 0; y < b.hColorBarEnd; y++ {
+		yi := width * y
+		ci := width * y / 2
 		// Color bar
-		for x := 0; x < p.Width; x++ {
-			c := x * 7 / p.Width
-			yyBase[yi+x] = uint8(uint16(colors[c][0]) * 75 / 100)
-			cbBase[ci+x/2] = colors[c][1]
-			crBase[ci+x/2] = colors[c][2]
+		for x := 0; x < width; x++ {
+			c := x * 7 / width
+			b.yyBase[yi+x] = uint8(uint16(colors[c][0]) * 75 / 100)
+			b.cbBase[ci+x/2] = colors[c][1]
+			b.crBase[ci+x/2] = colors[c][2]
 		}
 	}
-	for y := hColorBarEnd; y < p.Height; y++ {
-		yi := p.Width * y
-		ci := p.Width * y / 2
-		for x := 0; x < wGradationEnd; x++ {
+	for y := b.hColorBarEnd; y < height; y++ {
+		yi := width * y
+		ci := width * y / 2
+		for x := 0; x < b.wGradationEnd; x++ {
 			// Gray gradation
-			yyBase[yi+x] = uint8(x * 255 / wGradationEnd)
-			cbBase[ci+x/2] = 128
-			crBase[ci+x/2] = 128
+			b.yyBase[yi+x] = uint8(x * 255 / b.wGradationEnd)
+			b.cbBase[ci+x/2] = 128
+			b.crBase[ci+x/2] = 128
 		}
-		for x := wGradationEnd; x < p.Width; x++ {
+		for x := b.wGradationEnd; x < width; x++ {
 			// Noise area
-			cbBase[ci+x/2] = 128
-			crBase[ci+x/2] = 128
+			b.cbBase[ci+x/2] = 128
+			b.crBase[ci+x/2] = 128
+		}
+	}
+	return b
+}
+
+// draw draws the base pattern with noise, and returns the frame, which shares the buffers of b
+func (b *colorBars) draw(random *rand.Rand) *image.YCbCr {
+	copy(b.yy, b.yyBase)
+	copy(b.cb, b.cbBase)
+	copy(b.cr, b.crBase)
+	for y := b.hColorBarEnd; y < b.height; y++ {
+		yi := b.width * y
+		for x := b.wGradationEnd; x < b.width; x++ {
+			// Noise
+			b.yy[yi+x] = uint8(random.Int31n(2) * 255)
 		}
 	}
+	return &image.YCbCr{
+		Y:              b.yy,
+		YStride:        b.width,
+		Cb:             b.cb,
+		Cr:             b.cr,
+		CStride:        b.width / 2,
+		SubsampleRatio: image.YCbCrSubsampleRatio422,
+		Rect:           image.Rect(0, 0, b.width, b.height),
+	}
+}
+
+func (d *dummy) VideoRecord(p prop.Media) (video.Reader, error) {
+	bars := newColorBars(p.Width, p.Height)
 	random := rand.New(rand.NewSource(0))
 
 	tick := time.NewTicker(time.Duration(float32(time.Second) / p.FrameRate))
 	d.tick = tick
 	closed := d.closed
+	var timestamp time.Time
+	var frameCount uint64
 
 	r := video.ReaderFunc(func() (image.Image, func(), error) {
 		select {
@@ -106,41 +207,44 @@ func (d *dummy) VideoRecord(p prop.Media) (video.Reader, error) {
 		default:
 		}
 
-		<-tick.C
+		timestamp = <-tick.C
 
-		copy(yy, yyBase)
-		copy(cb, cbBase)
-		copy(cr, crBase)
-		for y := hColorBarEnd; y < p.Height; y++ {
-			yi := p.Width * y
-			for x := wGradationEnd; x < p.Width; x++ {
-				// Noise
-				yy[yi+x] = uint8(random.Int31n(2) * 255)
+		for _, change := range d.config.ResolutionChanges {
+			if frameCount == uint64(change.Frame) && (change.Width != bars.width || change.Height != bars.height) {
+				bars = newColorBars(change.Width, change.Height)
 			}
 		}
-		return &image.YCbCr{
-			Y:              yy,
-			YStride:        p.Width,
-			Cb:             cb,
-			Cr:             cr,
-			CStride:        p.Width / 2,
-			SubsampleRatio: image.YCbCrSubsampleRatio422,
-			Rect:           image.Rect(0, 0, p.Width, p.Height),
-		}, func() {}, nil
+
+		img := bars.draw(random)
+		if d.config.MovingBox {
+			drawMovingBox(img, frameCount)
+		}
+		if d.config.FrameCounter {
+			drawBarcode(img, 0, frameCount, frameCounterBits)
+		}
+		if d.config.Timestamp {
+			drawBarcode(img, 1, uint64(timestamp.UnixNano()), timestampBits)
+		}
+		frameCount++
+		return img, func() {}, nil
 	})
 
-	return r, nil
+	return video.WithTimestamp(r, func() time.Time {
+		return timestamp
+	}), nil
 }
 
 func (d dummy) Properties() []prop.Media {
-	return []prop.Media{
-		{
+	props := make([]prop.Media, 0, len(d.config.FrameRates))
+	for _, frameRate := range d.config.FrameRates {
+		props = append(props, prop.Media{
 			Video: prop.Video{
-				Width:       640,
-				Height:      480,
+				Width:       d.config.Width,
+				Height:      d.config.Height,
 				FrameFormat: frame.FormatYUYV,
-				FrameRate:   30,
+				FrameRate:   frameRate,
 			},
-		},
+		})
 	}
+	return props
 }
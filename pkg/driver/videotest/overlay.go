@@ -0,0 +1,107 @@
+package videotest
+
+import (
+	"image"
+	"image/color"
+	"time"
+)
+
+const (
+	// barcodeAspect is the ratio of the width of the frames to the height of each barcode, so the
+	// barcodes are scaled with the frames
+	barcodeAspect = 80
+	// frameCounterBits and timestampBits are the numbers of the bits of the barcodes
+	frameCounterBits = 32
+	timestampBits    = 64
+
+	boxSize = 32
+	// boxSpeed is the number of the pixels that the moving box moves by every frame
+	boxSpeed = 4
+
+	black = 16
+	white = 235
+)
+
+// barcodeHeight returns the height of each barcode in the frames of width w
+func barcodeHeight(w int) int {
+	if h := w / barcodeAspect; h > 0 {
+		return h
+	}
+	return 1
+}
+
+// drawBarcode draws v as a barcode of n bits in the band-th band of the rows at the top of img. The
+// bits are drawn from the most significant one, and each of them is a black or white block.
+func drawBarcode(img *image.YCbCr, band int, v uint64, n int) {
+	w := img.Rect.Dx()
+	h := barcodeHeight(w)
+	for y := band * h; y < (band+1)*h && y < img.Rect.Dy(); y++ {
+		for i := 0; i < n; i++ {
+			level := uint8(black)
+			if v&(1<<uint(n-1-i)) != 0 {
+				level = white
+			}
+			for x := w * i / n; x < w*(i+1)/n; x++ {
+				img.Y[img.YOffset(x, y)] = level
+				img.Cb[img.COffset(x, y)] = 128
+				img.Cr[img.COffset(x, y)] = 128
+			}
+		}
+	}
+}
+
+// decodeBarcode reads the barcode of n bits in the band-th band of img. It samples the center of
+// each block, so the barcode can be read after the frame has been scaled or encoded.
+func decodeBarcode(img image.Image, band int, n int) (uint64, bool) {
+	bounds := img.Bounds()
+	y := bounds.Min.Y + (2*band+1)*barcodeHeight(bounds.Dx())/2
+	if bounds.Dx() < n || y >= bounds.Max.Y {
+		return 0, false
+	}
+
+	var v uint64
+	for i := 0; i < n; i++ {
+		x := bounds.Min.X + bounds.Dx()*(2*i+1)/(2*n)
+		v <<= 1
+		if color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y >= 128 {
+			v |= 1
+		}
+	}
+	return v, true
+}
+
+// DecodeFrameCounter reads the number of the frame from img, which has been generated with
+// Config.FrameCounter.
+func DecodeFrameCounter(img image.Image) (uint32, bool) {
+	v, ok := decodeBarcode(img, 0, frameCounterBits)
+	return uint32(v), ok
+}
+
+// DecodeTimestamp reads the capture time of the frame from img, which has been generated with
+// Config.Timestamp. The latency is the difference between the time that img has been received at
+// and the timestamp.
+func DecodeTimestamp(img image.Image) (time.Time, bool) {
+	v, ok := decodeBarcode(img, 1, timestampBits)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(v)), true
+}
+
+// drawMovingBox draws a white box that moves by boxSpeed pixels every frame, and wraps around at the
+// right edge. Dropped and duplicated frames are visible as jumps and stops of the box.
+func drawMovingBox(img *image.YCbCr, frameCount uint64) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w <= boxSize || h <= boxSize {
+		return
+	}
+	x0 := int(frameCount * boxSpeed % uint64(w-boxSize))
+	y0 := (h - boxSize) / 2
+	for y := y0; y < y0+boxSize; y++ {
+		for x := x0; x < x0+boxSize; x++ {
+			img.Y[img.YOffset(x, y)] = white
+			img.Cb[img.COffset(x, y)] = 128
+			img.Cr[img.COffset(x, y)] = 128
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package videotest
+
+import (
+	"image"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/video"
+)
+
+func TestOverlays(t *testing.T) {
+	id, err := Register(Config{
+		Label:        "overlay-unittest",
+		FrameRates:   []float32{1000},
+		MovingBox:    true,
+		FrameCounter: true,
+		Timestamp:    true,
+		ResolutionChanges: []ResolutionChange{
+			{Frame: 2, Width: 320, Height: 240},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := driver.GetManager().Query(driver.FilterID(id))[0]
+	defer driver.GetManager().Unregister(id)
+
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	r, err := d.(driver.VideoRecorder).VideoRecord(d.Properties()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaled := video.Scale(160, 120, nil)(r)
+
+	expectedSizes := []image.Point{{640, 480}, {160, 120}, {320, 240}, {160, 120}}
+	for i, expectedSize := range expectedSizes {
+		// Every other frame is read through the scaler to check that the barcodes can be read after scaling
+		reader := r
+		if i%2 == 1 {
+			reader = scaled
+		}
+		img, _, err := reader.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := img.Bounds().Size(); size != expectedSize {
+			t.Errorf("Expected the frame %d to be %v, got %v", i, expectedSize, size)
+		}
+		checkBarcodes(t, img, uint32(i), r)
+	}
+}
+
+func checkBarcodes(t *testing.T, img image.Image, expectedCount uint32, r video.Reader) {
+	t.Helper()
+
+	if count, ok := DecodeFrameCounter(img); !ok || count != expectedCount {
+		t.Errorf("Expected the frame counter %d, got %d", expectedCount, count)
+	}
+	if timestamp, ok := DecodeTimestamp(img); !ok || !timestamp.Equal(video.Timestamp(r)) {
+		t.Errorf("Expected the timestamp %v, got %v", video.Timestamp(r), timestamp)
+	}
+}
+
+func TestRegisterInvalidSize(t *testing.T) {
+	if _, err := Register(Config{Width: 641, Height: 480}); err == nil {
+		t.Error("Expected an error of the odd size")
+	}
+	if _, err := Register(Config{ResolutionChanges: []ResolutionChange{{Frame: 1}}}); err == nil {
+		t.Error("Expected an error of the empty size")
+	}
+}
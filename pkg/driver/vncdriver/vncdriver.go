@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pion/mediadevices/pkg/driver"
 	"github.com/pion/mediadevices/pkg/driver/vncdriver/vnc"
 
 	"github.com/pion/mediadevices/pkg/frame"
@@ -18,6 +19,13 @@ import (
 	"github.com/pion/mediadevices/pkg/prop"
 )
 
+// defaultFrameRate is the frame rate that the frame buffer is captured at when the frame rate isn't
+// constrained
+const defaultFrameRate = 30
+
+// frameRates are the frame rates that the frame buffer can be captured at
+var frameRates = []float32{5, 10, 15, 20, 24, 25, 30, 50, 60}
+
 type vncDevice struct {
 	closed   <-chan struct{}
 	cancel   func()
@@ -32,6 +40,17 @@ type vncDevice struct {
 func NewVnc(vncAddr string) *vncDevice {
 	return &vncDevice{vncAddr: vncAddr}
 }
+
+// Info returns the driver.Info to register the device of the VNC server at vncAddr with. The device is
+// identified by the address, so it keeps its ID across runs.
+func Info(vncAddr string) driver.Info {
+	return driver.Info{
+		Label:      "VNC",
+		DeviceType: driver.Camera,
+		Priority:   driver.PriorityLow,
+		HardwareID: "vnc://" + vncAddr,
+	}
+}
 func (d *vncDevice) PointerEvent(mask uint8, x, y uint16) {
 	if d.vClient != nil {
 		d.vClient.PointerEvent(vnc.ButtonMask(mask), x, y)
@@ -43,6 +62,11 @@ func (d *vncDevice) KeyEvent(keysym uint32, down bool) {
 	}
 }
 func (d *vncDevice) Open() error {
+	return d.OpenContext(context.Background())
+}
+
+// OpenContext connects to the VNC server. The connection and the handshake are interrupted when ctx is done.
+func (d *vncDevice) OpenContext(openCtx context.Context) error {
 	if d.vClient != nil {
 		return nil
 	}
@@ -59,14 +83,22 @@ func (d *vncDevice) Open() error {
 	}
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
-	conn, err := net.Dial("tcp", d.vncAddr)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(openCtx, "tcp", d.vncAddr)
 	if err != nil {
 		return err
 	}
-	d.vClient, err = vnc.Client(conn, &conf)
+	stopInterrupt := interruptOnDone(openCtx, conn)
+	client, err := vnc.Client(conn, &conf)
+	stopInterrupt()
+	if err == nil {
+		err = openCtx.Err()
+	}
 	if err != nil {
+		conn.Close()
 		return err
 	}
+	d.vClient = client
 	d.vClient.SetEncodings([]vnc.Encoding{
 		&vnc.ZlibEncoding{},
 		&vnc.RawEncoding{},
@@ -126,6 +158,24 @@ func (d *vncDevice) Open() error {
 	return nil
 }
 
+// interruptOnDone closes conn when ctx is done, until the returned function is called
+func interruptOnDone(ctx context.Context, conn net.Conn) (stop func()) {
+	stopped := make(chan struct{})
+	interrupted := make(chan struct{})
+	go func() {
+		defer close(interrupted)
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stopped:
+		}
+	}()
+	return func() {
+		close(stopped)
+		<-interrupted
+	}
+}
+
 func (d *vncDevice) Close() error {
 	d.cancel()
 	if d.tick != nil {
@@ -142,12 +192,13 @@ func (d *vncDevice) Close() error {
 
 func (d *vncDevice) VideoRecord(p prop.Media) (video.Reader, error) {
 	if p.FrameRate == 0 {
-		p.FrameRate = 30
+		p.FrameRate = defaultFrameRate
 	}
 
 	tick := time.NewTicker(time.Duration(float32(time.Second) / p.FrameRate))
 	d.tick = tick
 	closed := d.closed
+	var timestamp time.Time
 	r := video.ReaderFunc(func() (image.Image, func(), error) {
 		select {
 		case <-closed:
@@ -156,7 +207,7 @@ func (d *vncDevice) VideoRecord(p prop.Media) (video.Reader, error) {
 		default:
 		}
 
-		<-tick.C
+		timestamp = <-tick.C
 		return &image.RGBA{
 			Pix:    d.rawPixel,
 			Stride: 4,
@@ -164,17 +215,24 @@ func (d *vncDevice) VideoRecord(p prop.Media) (video.Reader, error) {
 		}, func() {}, nil
 	})
 
-	return r, nil
+	return video.WithTimestamp(r, func() time.Time {
+		return timestamp
+	}), nil
 }
 
 func (d *vncDevice) Properties() []prop.Media {
-	return []prop.Media{
-		{
+	// The property without the frame rate comes first, and it's selected when the frame rate isn't
+	// constrained
+	props := make([]prop.Media, 0, len(frameRates)+1)
+	for _, frameRate := range append([]float32{0}, frameRates...) {
+		props = append(props, prop.Media{
 			Video: prop.Video{
 				Width:       d.w,
 				Height:      d.h,
 				FrameFormat: frame.FormatRGBA,
+				FrameRate:   frameRate,
 			},
-		},
+		})
 	}
+	return props
 }
@@ -0,0 +1,154 @@
+package wavfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+var (
+	errSinkClosed        = errors.New("wav sink is closed")
+	errUnsupportedFormat = errors.New("the provided audio format is not supported")
+)
+
+// Sink is an audio output device that writes the played audio to a WAV file
+type Sink struct {
+	path string
+	p    prop.Audio
+
+	mu       sync.Mutex
+	file     *os.File
+	dataSize uint32
+}
+
+// NewSink returns a Sink that writes the played audio to a WAV file at path. The file is created
+// when the device is opened, and completed when it's closed. p is the format of the file, whose
+// SampleSize has to be 2 for int16 samples or 4 for float32 samples. Register it with
+// driver.RegisterFile as a driver.Speaker device.
+func NewSink(path string, p prop.Audio) *Sink {
+	p.IsInterleaved = true
+	p.IsBigEndian = false
+	return &Sink{path: path, p: p}
+}
+
+func (s *Sink) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Create(s.path)
+	if err != nil {
+		return err
+	}
+	// The header is completed once the size of the samples is known
+	if err := s.header().write(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.dataSize = 0
+	return nil
+}
+
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	f := s.file
+	s.file = nil
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	if err := s.header().write(f, s.dataSize); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *Sink) header() header {
+	format := uint16(formatPCM)
+	if s.p.IsFloat {
+		format = formatFloat
+	}
+	return header{
+		format:        format,
+		channels:      uint16(s.p.ChannelCount),
+		sampleRate:    uint32(s.p.SampleRate),
+		bitsPerSample: uint16(s.p.SampleSize * 8),
+	}
+}
+
+func (s *Sink) AudioPlay(r audio.Reader, p prop.Media) error {
+	if p.ChannelCount != s.p.ChannelCount || p.SampleRate != s.p.SampleRate ||
+		p.SampleSize != s.p.SampleSize || p.IsFloat != s.p.IsFloat {
+		return fmt.Errorf("%w: expected %d channels of %d-byte samples at %d Hz, got %d channels of %d-byte samples at %d Hz",
+			errUnsupportedFormat, s.p.ChannelCount, s.p.SampleSize, s.p.SampleRate, p.ChannelCount, p.SampleSize, p.SampleRate)
+	}
+
+	encoder, err := wave.NewEncoder(&wave.RawFormat{
+		SampleSize:  s.p.SampleSize,
+		IsFloat:     s.p.IsFloat,
+		Interleaved: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	for {
+		chunk, release, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		info := chunk.ChunkInfo()
+		if info.Channels != s.p.ChannelCount || (info.SamplingRate != 0 && info.SamplingRate != s.p.SampleRate) {
+			release()
+			return fmt.Errorf("%w: expected %d channels at %d Hz, got %d channels at %d Hz",
+				errUnsupportedFormat, s.p.ChannelCount, s.p.SampleRate, info.Channels, info.SamplingRate)
+		}
+		data, err = encoder.Encode(binary.LittleEndian, data[:0], chunk)
+		release()
+		if err != nil {
+			return err
+		}
+
+		if err := s.write(data); err != nil {
+			if err == errSinkClosed {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (s *Sink) write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return errSinkClosed
+	}
+	n, err := s.file.Write(data)
+	s.dataSize += uint32(n)
+	return err
+}
+
+func (s *Sink) Properties() []prop.Media {
+	return []prop.Media{{Audio: s.p}}
+}
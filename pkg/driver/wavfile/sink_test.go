@@ -0,0 +1,122 @@
+package wavfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+func TestSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.wav")
+
+	a := NewSink(path, prop.Audio{ChannelCount: 2, SampleRate: 48000, SampleSize: 2})
+	if err := driver.GetManager().Register(a, driver.Info{Label: "wav-sink", DeviceType: driver.Speaker}); err != nil {
+		t.Fatal(err)
+	}
+	defer driver.GetManager().UnregisterAdapter(a)
+
+	drivers := driver.GetManager().Query(driver.FilterAudioPlayer())
+	if len(drivers) != 1 {
+		t.Fatalf("Expected the sink to be registered as an audio player, got %d players", len(drivers))
+	}
+	d := drivers[0]
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := []wave.Audio{
+		&wave.Int16Interleaved{Data: []int16{1, 2, 3, 4}, Size: wave.ChunkInfo{Len: 2, Channels: 2}},
+		&wave.Float32Interleaved{Data: []float32{0.5, -0.5}, Size: wave.ChunkInfo{Len: 1, Channels: 2}},
+	}
+	r := audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		if len(chunks) == 0 {
+			return nil, func() {}, io.EOF
+		}
+		chunk := chunks[0]
+		chunks = chunks[1:]
+		return chunk, func() {}, nil
+	})
+	if err := d.(driver.AudioPlayer).AudioPlay(r, d.Properties()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected bytes.Buffer
+	header := header{format: formatPCM, channels: 2, sampleRate: 48000, bitsPerSample: 16}
+	if err := header.write(&expected, 12); err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range []int16{1, 2, 3, 4, 16383, -16383} {
+		binary.Write(&expected, binary.LittleEndian, sample)
+	}
+	if !bytes.Equal(expected.Bytes(), b) {
+		t.Errorf("Expected\n%v\ngot\n%v", expected.Bytes(), b)
+	}
+	if string(b[:4]) != "RIFF" || string(b[8:16]) != "WAVEfmt " || binary.LittleEndian.Uint32(b[40:]) != 12 {
+		t.Errorf("Expected a WAV file with 12 bytes of samples, got %v", b[:headerSize])
+	}
+}
+
+func TestSinkUnsupportedFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewSink(filepath.Join(dir, "out.wav"), prop.Audio{ChannelCount: 2, SampleRate: 48000, SampleSize: 2})
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for name, c := range map[string]struct {
+		p     prop.Audio
+		chunk wave.Audio
+	}{
+		"SampleRate": {
+			p:     prop.Audio{ChannelCount: 2, SampleRate: 44100, SampleSize: 2},
+			chunk: &wave.Int16Interleaved{Data: []int16{1, 2}, Size: wave.ChunkInfo{Len: 1, Channels: 2, SamplingRate: 44100}},
+		},
+		"SampleSize": {
+			p:     prop.Audio{ChannelCount: 2, SampleRate: 48000, SampleSize: 4, IsFloat: true},
+			chunk: &wave.Int16Interleaved{Data: []int16{1, 2}, Size: wave.ChunkInfo{Len: 1, Channels: 2, SamplingRate: 48000}},
+		},
+		"ChunkSamplingRate": {
+			p:     prop.Audio{ChannelCount: 2, SampleRate: 48000, SampleSize: 2},
+			chunk: &wave.Int16Interleaved{Data: []int16{1, 2}, Size: wave.ChunkInfo{Len: 1, Channels: 2, SamplingRate: 44100}},
+		},
+	} {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			r := audio.ReaderFunc(func() (wave.Audio, func(), error) {
+				return c.chunk, func() {}, nil
+			})
+			if err := s.AudioPlay(r, prop.Media{Audio: c.p}); !errors.Is(err, errUnsupportedFormat) {
+				t.Errorf("Expected %v, got %v", errUnsupportedFormat, err)
+			}
+		})
+	}
+}
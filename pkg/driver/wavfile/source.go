@@ -0,0 +1,233 @@
+package wavfile
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// latencies are the latencies that the chunks can be sized by. The first one is the default, which is
+// selected when the latency isn't constrained.
+var latencies = []time.Duration{
+	20 * time.Millisecond,
+	10 * time.Millisecond,
+	40 * time.Millisecond,
+	60 * time.Millisecond,
+}
+
+// Source is a microphone that reads the samples from a WAV file
+type Source struct {
+	path       string
+	h          header
+	dataOffset int64
+	// dataSize is the size of the samples, or -1 if it's unknown
+	dataSize int64
+	realtime bool
+
+	file   *os.File
+	closed <-chan struct{}
+	cancel func()
+}
+
+// NewSource returns a microphone that reads the PCM WAV file at path. The samples can be int16, int24
+// or float32, and any number of channels and sample rate are supported. int24 samples are read as
+// float32. If realtime is true, the chunks are read at the pace of the playback, otherwise they're
+// read as fast as possible. The reader ends with io.EOF once all the samples have been read.
+func NewSource(path string, realtime bool) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h, dataOffset, dataSize, err := readHeader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case h.format == formatPCM && (h.bitsPerSample == 16 || h.bitsPerSample == 24):
+	case h.format == formatFloat && h.bitsPerSample == 32:
+	default:
+		return nil, fmt.Errorf("wav: unsupported format %d with %d bits per sample", h.format, h.bitsPerSample)
+	}
+	if h.channels == 0 || h.sampleRate == 0 {
+		return nil, fmt.Errorf("wav: invalid %d channels at %d Hz", h.channels, h.sampleRate)
+	}
+
+	return &Source{
+		path:       path,
+		h:          h,
+		dataOffset: dataOffset,
+		dataSize:   dataSize,
+		realtime:   realtime,
+	}, nil
+}
+
+// Register registers s as a Microphone device with driver.RegisterFile, and returns the ID of its driver
+func Register(s *Source) (string, error) {
+	return driver.RegisterFile(s, s.path, driver.Microphone)
+}
+
+func (s *Source) Open() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.file = f
+	s.closed = ctx.Done()
+	s.cancel = cancel
+	return nil
+}
+
+func (s *Source) Close() error {
+	s.cancel()
+	return s.file.Close()
+}
+
+func (s *Source) AudioRecord(p prop.Media) (audio.Reader, error) {
+	decode, err := s.decoder()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.file.Seek(s.dataOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if p.Latency == 0 {
+		p.Latency = latencies[0]
+	}
+	sampleRate := int(s.h.sampleRate)
+	channels := int(s.h.channels)
+	blockAlign := channels * int(s.h.bitsPerSample) / 8
+	nSample := int(uint64(sampleRate) * uint64(p.Latency) / uint64(time.Second))
+	if nSample == 0 {
+		nSample = 1
+	}
+
+	buf := make([]byte, nSample*blockAlign)
+	remaining := s.dataSize
+	start := time.Now()
+	var position int64
+	var timestamp time.Time
+
+	closed := s.closed
+	reader := audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		select {
+		case <-closed:
+			return nil, func() {}, io.EOF
+		default:
+		}
+
+		n := len(buf)
+		if remaining >= 0 && remaining < int64(n) {
+			n = int(remaining)
+		}
+		n, err := io.ReadFull(s.file, buf[:n])
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		// The trailing partial sample is ignored
+		n -= n % blockAlign
+		if err == nil && n == 0 {
+			err = io.EOF
+		}
+		if err != nil {
+			return nil, func() {}, err
+		}
+		if remaining >= 0 {
+			remaining -= int64(n)
+		}
+
+		timestamp = start.Add(time.Duration(position) * time.Second / time.Duration(sampleRate))
+		position += int64(n / blockAlign)
+		if s.realtime {
+			time.Sleep(time.Until(timestamp))
+		}
+
+		chunk, err := decode(buf[:n], channels)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		return chunk, func() {}, nil
+	})
+
+	return audio.WithTimestamp(reader, func() time.Time {
+		return timestamp
+	}), nil
+}
+
+// decoder returns the function to decode the samples of the file
+func (s *Source) decoder() (func(chunk []byte, channels int) (wave.Audio, error), error) {
+	sampleRate := int(s.h.sampleRate)
+	if s.h.bitsPerSample == 24 {
+		return func(chunk []byte, channels int) (wave.Audio, error) {
+			decoded := wave.NewFloat32Interleaved(wave.ChunkInfo{
+				Len:          len(chunk) / 3 / channels,
+				Channels:     channels,
+				SamplingRate: sampleRate,
+			})
+			for i := range decoded.Data {
+				b := chunk[i*3:]
+				v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+				decoded.Data[i] = float32(v) / (1 << 23)
+			}
+			return decoded, nil
+		}, nil
+	}
+
+	decoder, err := wave.NewDecoder(&wave.RawFormat{
+		SampleSize:  int(s.h.bitsPerSample) / 8,
+		IsFloat:     s.h.format == formatFloat,
+		Interleaved: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func(chunk []byte, channels int) (wave.Audio, error) {
+		decoded, err := decoder.Decode(binary.LittleEndian, chunk, channels)
+		if err != nil {
+			return nil, err
+		}
+		switch decoded := decoded.(type) {
+		case *wave.Int16Interleaved:
+			decoded.Size.SamplingRate = sampleRate
+		case *wave.Float32Interleaved:
+			decoded.Size.SamplingRate = sampleRate
+		}
+		return decoded, nil
+	}, nil
+}
+
+func (s *Source) Properties() []prop.Media {
+	sampleSize := int(s.h.bitsPerSample) / 8
+	isFloat := s.h.format == formatFloat
+	if s.h.bitsPerSample == 24 {
+		// int24 samples are read as float32
+		sampleSize, isFloat = 4, true
+	}
+
+	props := make([]prop.Media, 0, len(latencies))
+	for _, latency := range latencies {
+		props = append(props, prop.Media{
+			Audio: prop.Audio{
+				ChannelCount:  int(s.h.channels),
+				SampleRate:    int(s.h.sampleRate),
+				SampleSize:    sampleSize,
+				IsFloat:       isFloat,
+				IsInterleaved: true,
+				Latency:       latency,
+			},
+		})
+	}
+	return props
+}
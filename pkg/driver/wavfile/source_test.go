@@ -0,0 +1,255 @@
+package wavfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices"
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// writeWAV writes a WAV file of h with samples to dir. extraChunk is written before the data chunk.
+func writeWAV(t *testing.T, dir, name string, h header, extraChunk []byte, samples interface{}) string {
+	var data bytes.Buffer
+	if err := binary.Write(&data, binary.LittleEndian, samples); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := h.write(&b, uint32(data.Len())); err != nil {
+		t.Fatal(err)
+	}
+	file := b.Bytes()
+	if extraChunk != nil {
+		// Insert extraChunk between the fmt chunk and the data chunk
+		file = append(append(append([]byte(nil), file[:36]...), extraChunk...), file[36:]...)
+	}
+	file = append(file, data.Bytes()...)
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readAll reads all the chunks of s with p
+func readAll(t *testing.T, s *Source, p prop.Media) []wave.Audio {
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	r, err := s.AudioRecord(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []wave.Audio
+	for {
+		chunk, _, err := r.Read()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A LIST chunk of an odd size, which is padded
+	listChunk := []byte{'L', 'I', 'S', 'T', 3, 0, 0, 0, 'a', 'b', 'c', 0}
+
+	testCases := map[string]struct {
+		h          header
+		extraChunk []byte
+		samples    interface{}
+		expected   []wave.Audio
+	}{
+		"Int16Stereo": {
+			h:       header{format: formatPCM, channels: 2, sampleRate: 200, bitsPerSample: 16},
+			samples: []int16{1, -1, 2, -2, 3, -3, 4, -4, 5, -5},
+			expected: []wave.Audio{
+				&wave.Int16Interleaved{Data: []int16{1, -1, 2, -2, 3, -3, 4, -4}, Size: wave.ChunkInfo{Len: 4, Channels: 2, SamplingRate: 200}},
+				&wave.Int16Interleaved{Data: []int16{5, -5}, Size: wave.ChunkInfo{Len: 1, Channels: 2, SamplingRate: 200}},
+			},
+		},
+		"Int24Mono": {
+			h:          header{format: formatPCM, channels: 1, sampleRate: 100, bitsPerSample: 24},
+			extraChunk: listChunk,
+			samples:    []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0x80, 0x00, 0x00, 0x00},
+			expected: []wave.Audio{
+				&wave.Float32Interleaved{Data: []float32{0.5, -1}, Size: wave.ChunkInfo{Len: 2, Channels: 1, SamplingRate: 100}},
+				&wave.Float32Interleaved{Data: []float32{0}, Size: wave.ChunkInfo{Len: 1, Channels: 1, SamplingRate: 100}},
+			},
+		},
+		"Float32Mono": {
+			h:       header{format: formatFloat, channels: 1, sampleRate: 50, bitsPerSample: 32},
+			samples: []float32{0.25, -0.25},
+			expected: []wave.Audio{
+				&wave.Float32Interleaved{Data: []float32{0.25}, Size: wave.ChunkInfo{Len: 1, Channels: 1, SamplingRate: 50}},
+				&wave.Float32Interleaved{Data: []float32{-0.25}, Size: wave.ChunkInfo{Len: 1, Channels: 1, SamplingRate: 50}},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			path := writeWAV(t, dir, name+".wav", testCase.h, testCase.extraChunk, testCase.samples)
+			s, err := NewSource(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 20ms of the sample rates are 4, 2 and 1 samples
+			chunks := readAll(t, s, prop.Media{Audio: prop.Audio{Latency: 20 * time.Millisecond}})
+			if !reflect.DeepEqual(testCase.expected, chunks) {
+				t.Errorf("Expected %v, got %v", testCase.expected, chunks)
+			}
+		})
+	}
+}
+
+func TestSourceUnsupportedFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeWAV(t, dir, "int8.wav", header{format: formatPCM, channels: 1, sampleRate: 8000, bitsPerSample: 8}, nil, []byte{0})
+	if _, err := NewSource(path, false); err == nil {
+		t.Error("Expected an error of the unsupported format")
+	}
+
+	path = filepath.Join(dir, "text.wav")
+	if err := ioutil.WriteFile(path, []byte("not a wav file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSource(path, false); err == nil {
+		t.Error("Expected an error of the invalid file")
+	}
+}
+
+func TestSourceRealtime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 3 chunks of 20ms
+	path := writeWAV(t, dir, "test.wav", header{format: formatPCM, channels: 1, sampleRate: 1000, bitsPerSample: 16}, nil, make([]int16, 60))
+	s, err := NewSource(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if chunks := readAll(t, s, prop.Media{Audio: prop.Audio{Latency: 20 * time.Millisecond}}); len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	// The first chunk is read immediately
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected the chunks to be read in real time, got %v", elapsed)
+	}
+}
+
+func TestSinkToSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.wav")
+
+	sink := NewSink(path, prop.Audio{ChannelCount: 2, SampleRate: 48000, SampleSize: 4, IsFloat: true})
+	if err := sink.Open(); err != nil {
+		t.Fatal(err)
+	}
+	played := &wave.Float32Interleaved{Data: []float32{0.5, -0.5, 0.25, -0.25}, Size: wave.ChunkInfo{Len: 2, Channels: 2, SamplingRate: 48000}}
+	var read bool
+	r := audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		if read {
+			return nil, func() {}, io.EOF
+		}
+		read = true
+		return played, func() {}, nil
+	})
+	if err := sink.AudioPlay(r, sink.Properties()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSource(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks := readAll(t, s, s.Properties()[0]); !reflect.DeepEqual([]wave.Audio{played}, chunks) {
+		t.Errorf("Expected %v, got %v", played, chunks)
+	}
+}
+
+func TestSourceGetUserMedia(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeWAV(t, dir, "test.wav", header{format: formatPCM, channels: 1, sampleRate: 8000, bitsPerSample: 16}, nil, make([]int16, 800))
+
+	s, err := NewSource(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := Register(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.GetManager().UnregisterAdapter(s)
+
+	stream, err := mediadevices.GetUserMedia(mediadevices.MediaStreamConstraints{
+		Audio: func(c *mediadevices.MediaTrackConstraints) {
+			c.DeviceID = prop.StringExact(id)
+			c.Latency = prop.DurationExact(10 * time.Millisecond)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks := stream.GetAudioTracks()
+	if len(tracks) != 1 {
+		t.Fatalf("Expected an audio track, got %d tracks", len(tracks))
+	}
+	track := tracks[0].(*mediadevices.AudioTrack)
+	defer track.Close()
+
+	chunk, release, err := track.NewReader(false).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if info := chunk.ChunkInfo(); info.Len != 80 || info.SamplingRate != 8000 {
+		t.Errorf("Expected chunks of 10ms at 8000 Hz, got %v", info)
+	}
+}
@@ -0,0 +1,168 @@
+// Package wavfile provides the drivers of WAV files.
+package wavfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// headerSize is the size of the RIFF header, the fmt chunk and the header of the data chunk
+	headerSize = 44
+
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xfffe
+
+	// unknownDataSize is the size of the data chunk of the WAV files that are being streamed
+	unknownDataSize = 0xffffffff
+)
+
+var (
+	errNotWAV     = errors.New("wav: missing RIFF/WAVE signature")
+	errNoFmtChunk = errors.New("wav: missing fmt chunk")
+)
+
+// header is the format of a WAV file
+type header struct {
+	format        uint16
+	channels      uint16
+	sampleRate    uint32
+	bitsPerSample uint16
+}
+
+// write writes the header of a WAV file that has dataSize bytes of samples to w
+func (h header) write(w io.Writer, dataSize uint32) error {
+	blockAlign := h.channels * h.bitsPerSample / 8
+	fields := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(headerSize - 8 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16),
+		h.format,
+		h.channels,
+		h.sampleRate,
+		h.sampleRate * uint32(blockAlign),
+		blockAlign,
+		h.bitsPerSample,
+
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+	for _, field := range fields {
+		if err := binary.Write(w, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readHeader reads the header of a WAV file from r, and returns it with the offset and the size of
+// the samples in the file. The chunks other than fmt and data are skipped.
+// Reference: http://soundfile.sapp.org/doc/WaveFormat/
+func readHeader(r io.ReadSeeker) (h header, dataOffset, dataSize int64, err error) {
+	var riff struct {
+		ID   [4]byte
+		Size uint32
+		Type [4]byte
+	}
+	if err := binary.Read(r, binary.LittleEndian, &riff); err != nil {
+		return header{}, 0, 0, err
+	}
+	if string(riff.ID[:]) != "RIFF" || string(riff.Type[:]) != "WAVE" {
+		return header{}, 0, 0, errNotWAV
+	}
+
+	var hasFmt bool
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &chunk); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return header{}, 0, 0, err
+		}
+
+		switch string(chunk.ID[:]) {
+		case "fmt ":
+			if h, err = readFmtChunk(r, chunk.Size); err != nil {
+				return header{}, 0, 0, err
+			}
+			hasFmt = true
+			continue
+
+		case "data":
+			if !hasFmt {
+				return header{}, 0, 0, errNoFmtChunk
+			}
+			offset, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return header{}, 0, 0, err
+			}
+			size := int64(chunk.Size)
+			if chunk.Size == unknownDataSize {
+				size = -1
+			}
+			return h, offset, size, nil
+		}
+
+		// Chunks are padded to even sizes
+		if _, err := r.Seek(int64(chunk.Size+chunk.Size&1), io.SeekCurrent); err != nil {
+			return header{}, 0, 0, err
+		}
+	}
+}
+
+// readFmtChunk reads the fmt chunk of size bytes from r
+func readFmtChunk(r io.ReadSeeker, size uint32) (header, error) {
+	if size < 16 {
+		return header{}, fmt.Errorf("wav: invalid fmt chunk size %d", size)
+	}
+	var fmtChunk struct {
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &fmtChunk); err != nil {
+		return header{}, err
+	}
+	read := uint32(16)
+
+	h := header{
+		format:        fmtChunk.Format,
+		channels:      fmtChunk.Channels,
+		sampleRate:    fmtChunk.SampleRate,
+		bitsPerSample: fmtChunk.BitsPerSample,
+	}
+	if h.format == formatExtensible && size >= 40 {
+		// The actual format is the first 2 bytes of the sub format GUID, which follows cbSize,
+		// wValidBitsPerSample and dwChannelMask
+		var extension struct {
+			Size          uint16
+			ValidBits     uint16
+			ChannelMask   uint32
+			SubFormat     uint16
+			SubFormatRest [14]byte
+		}
+		if err := binary.Read(r, binary.LittleEndian, &extension); err != nil {
+			return header{}, err
+		}
+		read += 24
+		h.format = extension.SubFormat
+	}
+
+	if _, err := r.Seek(int64(size-read+size&1), io.SeekCurrent); err != nil {
+		return header{}, err
+	}
+	return h, nil
+}
@@ -1,44 +1,68 @@
 package driver
 
 import (
-	"github.com/google/uuid"
+	"context"
+	"errors"
+	"image"
+	"sync"
+	"time"
+
 	"github.com/pion/mediadevices/pkg/io/audio"
 	"github.com/pion/mediadevices/pkg/io/video"
 	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
 )
 
 func wrapAdapter(a Adapter, info Info) Driver {
-	generator, err := uuid.NewRandom()
-	if err != nil {
-		panic(err)
-	}
+	w := newAdapterWrapper(a, info)
+	w.id = driverID("", info, 0)
+	return w.driver()
+}
 
-	id := generator.String()
-	d := &adapterWrapper{
-		Adapter: a,
-		id:      id,
-		info:    info,
-		state:   StateClosed,
+func newAdapterWrapper(a Adapter, info Info) *adapterWrapper {
+	return &adapterWrapper{
+		Adapter:  a,
+		info:     info,
+		state:    StateClosed,
+		openLock: make(chan struct{}, 1),
+		useLock:  make(chan struct{}, 1),
 	}
+}
 
-	switch v := a.(type) {
+// driver returns the Driver of the wrapper, which only exposes Driver, OpenerContext, LockerContext and
+// one of VideoRecorder, AudioRecorder and AudioPlayer
+func (w *adapterWrapper) driver() Driver {
+	switch v := w.Adapter.(type) {
 	case VideoRecorder:
 		// Only expose Driver and VideoRecorder interfaces
-		d.VideoRecorder = v
+		w.VideoRecorder = v
 		r := &struct {
 			Driver
+			OpenerContext
+			LockerContext
 			VideoRecorder
-		}{d, d}
+		}{w, w, w, w}
 		return r
 	case AudioRecorder:
 		// Only expose Driver and AudioRecorder interfaces
-		d.AudioRecorder = v
+		w.AudioRecorder = v
 		return &struct {
 			Driver
+			OpenerContext
+			LockerContext
 			AudioRecorder
-		}{d, d}
+		}{w, w, w, w}
+	case AudioPlayer:
+		// Only expose Driver and AudioPlayer interfaces
+		w.AudioPlayer = v
+		return &struct {
+			Driver
+			OpenerContext
+			LockerContext
+			AudioPlayer
+		}{w, w, w, w}
 	default:
-		panic("adapter has to be either VideoRecorder/AudioRecorder")
+		panic("adapter has to be either VideoRecorder/AudioRecorder/AudioPlayer")
 	}
 }
 
@@ -46,61 +70,256 @@ type adapterWrapper struct {
 	Adapter
 	VideoRecorder
 	AudioRecorder
-	id    string
-	info  Info
+	AudioPlayer
+	// id is derived from info by Manager, and idIndex distinguishes the devices that have the same
+	// identity. id is changed by Manager.SetNamespace.
+	idMu    sync.RWMutex
+	id      string
+	idIndex int
+	info    Info
+	// mu guards state and removed
+	mu    sync.Mutex
 	state State
+	// removed is set once the driver has been unregistered
+	removed bool
+	// openLock serializes opening the adapter. An Open that has been abandoned by OpenContext keeps
+	// holding it until the adapter has been closed again, so the abandoned adapter can't close the
+	// adapter that has been opened by another caller.
+	openLock chan struct{}
+	// useLock is the lock of LockerContext
+	useLock chan struct{}
 }
 
 func (w *adapterWrapper) ID() string {
+	w.idMu.RLock()
+	defer w.idMu.RUnlock()
 	return w.id
 }
 
+func (w *adapterWrapper) setID(id string) {
+	w.idMu.Lock()
+	defer w.idMu.Unlock()
+	w.id = id
+}
+
 func (w *adapterWrapper) Info() Info {
 	return w.info
 }
 
+// LockContext implements LockerContext
+func (w *adapterWrapper) LockContext(ctx context.Context) (func(), error) {
+	select {
+	case w.useLock <- struct{}{}:
+		return func() { <-w.useLock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 func (w *adapterWrapper) Status() State {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	return w.state
 }
 
+// updateState updates the state to next with f like State.Update while holding the lock
+func (w *adapterWrapper) updateState(next State, f func() error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state.Update(next, f)
+}
+
 func (w *adapterWrapper) Open() error {
-	return w.state.Update(StateOpened, w.Adapter.Open)
+	return w.OpenContext(context.Background())
+}
+
+// OpenContext opens the driver like Open, but returns ctx.Err() when ctx is done before the driver
+// has been opened. If the adapter doesn't implement OpenerContext, Open keeps running in the
+// background, and the adapter is closed once it has been opened.
+func (w *adapterWrapper) OpenContext(ctx context.Context) error {
+	if w.isRemoved() {
+		return w.removedError()
+	}
+
+	select {
+	case w.openLock <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	abandoned := false
+	defer func() {
+		if !abandoned {
+			<-w.openLock
+		}
+	}()
+
+	// The adapter is opened without holding the lock, since Open may block. Only the transition is
+	// checked beforehand, and the opening is serialized by openLock.
+	state := w.Status()
+	if err := state.Update(StateOpened, func() error { return nil }); err != nil {
+		return err
+	}
+
+	var err error
+	if opener, ok := w.Adapter.(OpenerContext); ok {
+		err = opener.OpenContext(ctx)
+	} else {
+		abandoned, err = w.openAbandonable(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	err = w.updateState(StateOpened, func() error {
+		// The driver might have been unregistered while the adapter was being opened
+		if w.removed {
+			_ = w.Adapter.Close()
+			return w.removedError()
+		}
+		return nil
+	})
+	return err
 }
 
+// openAbandonable opens the adapter in the background, and stops waiting for it when ctx is done.
+// The abandoned adapter is closed once it has been opened, and openLock is released afterwards.
+func (w *adapterWrapper) openAbandonable(ctx context.Context) (abandoned bool, err error) {
+	if ctx.Done() == nil {
+		return false, w.Adapter.Open()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Adapter.Open()
+	}()
+
+	select {
+	case err := <-done:
+		return false, err
+	case <-ctx.Done():
+		go func() {
+			if err := <-done; err == nil {
+				_ = w.Adapter.Close()
+			}
+			<-w.openLock
+		}()
+		return true, ctx.Err()
+	}
+}
+
+// Close closes the adapter. Closing a closed driver does nothing, since the driver might have been
+// closed by Manager.Unregister while it's still used by a track.
 func (w *adapterWrapper) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state == StateClosed {
+		return nil
+	}
 	return w.state.Update(StateClosed, w.Adapter.Close)
 }
 
+// remove marks the driver removed, and closes it to stop the readers
+func (w *adapterWrapper) remove() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.removed {
+		return
+	}
+	w.removed = true
+	if w.state != StateClosed {
+		_ = w.state.Update(StateClosed, w.Adapter.Close)
+	}
+}
+
+func (w *adapterWrapper) isRemoved() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.removed
+}
+
+func (w *adapterWrapper) removedError() error {
+	return &DeviceRemovedError{DeviceID: w.ID(), Label: w.info.Label}
+}
+
+// readError converts err of the readers to *DeviceRemovedError if the device has been removed
+func (w *adapterWrapper) readError(err error) error {
+	if err != nil && (w.isRemoved() || errors.Is(err, ErrDeviceRemoved)) {
+		return w.removedError()
+	}
+	return err
+}
+
 func (w *adapterWrapper) Properties() []prop.Media {
-	if w.state == StateClosed {
+	if w.Status() == StateClosed {
 		return nil
 	}
 
+	id := w.ID()
 	p := w.Adapter.Properties()
 	for i := range p {
-		p[i].DeviceID = w.id
+		p[i].DeviceID = id
 	}
 	return p
 }
 
 func (w *adapterWrapper) VideoRecord(p prop.Media) (r video.Reader, err error) {
-	err = w.state.Update(StateRunning, func() error {
+	err = w.updateState(StateRunning, func() error {
 		r, err = w.VideoRecorder.VideoRecord(p)
 		return err
 	})
 	if err != nil {
 		_ = w.Close()
+		return nil, w.readError(err)
+	}
+	if r == nil {
+		return
 	}
-	return
+
+	recorded := r
+	return video.WithTimestamp(video.ReaderFunc(func() (image.Image, func(), error) {
+		img, release, err := recorded.Read()
+		return img, release, w.readError(err)
+	}), func() time.Time {
+		return video.Timestamp(recorded)
+	}), nil
 }
 
 func (w *adapterWrapper) AudioRecord(p prop.Media) (r audio.Reader, err error) {
-	err = w.state.Update(StateRunning, func() error {
+	err = w.updateState(StateRunning, func() error {
 		r, err = w.AudioRecorder.AudioRecord(p)
 		return err
 	})
 	if err != nil {
 		_ = w.Close()
+		return nil, w.readError(err)
+	}
+	if r == nil {
+		return
+	}
+
+	recorded := r
+	return audio.WithTimestamp(audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		chunk, release, err := recorded.Read()
+		return chunk, release, w.readError(err)
+	}), func() time.Time {
+		return audio.Timestamp(recorded)
+	}), nil
+}
+
+func (w *adapterWrapper) AudioPlay(r audio.Reader, p prop.Media) error {
+	// AudioPlay blocks until the playback has finished, so the driver is marked running beforehand
+	if err := w.updateState(StateRunning, func() error { return nil }); err != nil {
+		return w.readError(err)
+	}
+	err := w.AudioPlayer.AudioPlay(r, p)
+	// The driver can play another reader once the playback has finished, unless it has been closed
+	w.mu.Lock()
+	if w.state == StateRunning {
+		w.state = StateOpened
+	}
+	removed := w.removed
+	w.mu.Unlock()
+	if removed {
+		return w.removedError()
 	}
-	return
+	return w.readError(err)
 }
@@ -1,8 +1,12 @@
 package driver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pion/mediadevices/pkg/io/audio"
 	"github.com/pion/mediadevices/pkg/io/video"
@@ -136,3 +140,133 @@ func TestAudioWrapperWithBrokenRecorderState(t *testing.T) {
 		t.Errorf("expected the status to be %v, but got %v", StateClosed, d.Status())
 	}
 }
+
+type videoAdapterHangingMock struct {
+	videoAdapterMock
+	release chan struct{}
+	closed  chan struct{}
+}
+
+func (a *videoAdapterHangingMock) Open() error {
+	<-a.release
+	return nil
+}
+
+func (a *videoAdapterHangingMock) Close() error {
+	close(a.closed)
+	return nil
+}
+
+func TestWrapperOpenContext(t *testing.T) {
+	a := &videoAdapterHangingMock{release: make(chan struct{}), closed: make(chan struct{})}
+	d := wrapAdapter(a, Info{})
+	opener := d.(OpenerContext)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := opener.OpenContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, but got %v", context.DeadlineExceeded, err)
+	}
+	if d.Status() != StateClosed {
+		t.Errorf("expected the status to be %v, but got %v", StateClosed, d.Status())
+	}
+
+	// The driver can't be opened again until the abandoned adapter has been closed
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := opener.OpenContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v while the abandoned Open is running, but got %v", context.DeadlineExceeded, err)
+	}
+
+	// The abandoned adapter should be closed once it has been opened
+	close(a.release)
+	select {
+	case <-a.closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the abandoned adapter to be closed")
+	}
+
+	a.closed = make(chan struct{})
+	if err := opener.OpenContext(context.Background()); err != nil {
+		t.Errorf("expected to successfully open, but got %v", err)
+	}
+	if d.Status() != StateOpened {
+		t.Errorf("expected the status to be %v, but got %v", StateOpened, d.Status())
+	}
+}
+
+func TestWrapperLockContext(t *testing.T) {
+	d := wrapAdapter(&videoAdapterMock{}, Info{})
+	locker := d.(LockerContext)
+
+	unlock, err := locker.LockContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locker.LockContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v while the driver is locked, but got %v", context.DeadlineExceeded, err)
+	}
+
+	unlock()
+	unlock, err = locker.LockContext(context.Background())
+	if err != nil {
+		t.Errorf("expected to lock the unlocked driver, but got %v", err)
+	}
+	unlock()
+}
+
+type audioPlayerMock struct {
+	adapterMock
+	playing chan struct{}
+}
+
+func (a *audioPlayerMock) AudioPlay(r audio.Reader, p prop.Media) error {
+	<-a.playing
+	return nil
+}
+
+func TestWrapperRemoveConcurrently(t *testing.T) {
+	a := &audioPlayerMock{playing: make(chan struct{})}
+	w := newAdapterWrapper(a, Info{})
+	d := w.driver()
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	played := make(chan error)
+	go func() {
+		played <- d.(AudioPlayer).AudioPlay(nil, prop.Media{})
+	}()
+	for d.Status() != StateRunning {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Manager.Unregister removes the driver while it's still used and closed by the tracks
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			w.remove()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = d.Close()
+		}()
+	}
+	wg.Wait()
+	close(a.playing)
+
+	var removedErr *DeviceRemovedError
+	if err := <-played; !errors.As(err, &removedErr) {
+		t.Errorf("expected %v, but got %v", ErrDeviceRemoved, err)
+	}
+	if d.Status() != StateClosed {
+		t.Errorf("expected the status to be %v, but got %v", StateClosed, d.Status())
+	}
+	if err := d.Open(); !errors.As(err, &removedErr) {
+		t.Errorf("expected %v, but got %v", ErrDeviceRemoved, err)
+	}
+}
@@ -2,6 +2,7 @@ package audio
 
 import (
 	"errors"
+	"time"
 
 	"github.com/pion/mediadevices/pkg/io"
 	"github.com/pion/mediadevices/pkg/wave"
@@ -27,9 +28,7 @@ func NewBroadcaster(source Reader, config *BroadcasterConfig) *Broadcaster {
 		coreConfig = config.Core
 	}
 
-	broadcaster := io.NewBroadcaster(io.ReaderFunc(func() (interface{}, func(), error) {
-		return source.Read()
-	}), coreConfig)
+	broadcaster := io.NewBroadcaster(toIOReader(source), coreConfig)
 
 	return &Broadcaster{broadcaster}
 }
@@ -50,27 +49,51 @@ func (broadcaster *Broadcaster) NewReader(copyChunk bool) Reader {
 		}
 	}
 
-	reader := broadcaster.ioBroadcaster.NewReader(copyFn)
-	return ReaderFunc(func() (wave.Audio, func(), error) {
-		data, _, err := reader.Read()
-		chunk, _ := data.(wave.Audio)
-		return chunk, func() {}, err
-	})
+	return fromIOReader(broadcaster.ioBroadcaster.NewReader(copyFn))
+}
+
+// Stats returns the statistics of the broadcaster. The statistics of each reader can be retrieved
+// with Stats. This operation is thread safe.
+func (broadcaster *Broadcaster) Stats() io.BroadcasterStats {
+	return broadcaster.ioBroadcaster.Stats()
 }
 
 // ReplaceSource replaces the underlying source. This operation is thread safe.
 func (broadcaster *Broadcaster) ReplaceSource(source Reader) error {
-	return broadcaster.ioBroadcaster.ReplaceSource(io.ReaderFunc(func() (interface{}, func(), error) {
-		return source.Read()
-	}))
+	return broadcaster.ioBroadcaster.ReplaceSource(toIOReader(source))
 }
 
 // Source retrieves the underlying source. This operation is thread safe.
 func (broadcaster *Broadcaster) Source() Reader {
-	source := broadcaster.ioBroadcaster.Source()
-	return ReaderFunc(func() (wave.Audio, func(), error) {
-		data, _, err := source.Read()
-		img, _ := data.(wave.Audio)
-		return img, func() {}, err
+	return fromIOReader(broadcaster.ioBroadcaster.Source())
+}
+
+// toIOReader converts source to a generic reader while keeping its capture time
+func toIOReader(source Reader) io.Reader {
+	reader := io.ReaderFunc(func() (interface{}, func(), error) {
+		return source.Read()
+	})
+	return io.WithTimestamp(reader, func() time.Time {
+		return Timestamp(source)
 	})
 }
+
+// fromIOReader converts a generic reader back to a Reader while keeping its capture time and statistics
+func fromIOReader(r io.Reader) Reader {
+	reader := ReaderFunc(func() (wave.Audio, func(), error) {
+		data, _, err := r.Read()
+		chunk, _ := data.(wave.Audio)
+		return chunk, func() {}, err
+	})
+	timestamp := io.TimestampFunc(func() time.Time {
+		return io.Timestamp(r)
+	})
+	if statsReporter, ok := r.(io.StatsReporter); ok {
+		return &struct {
+			Reader
+			io.TimestampFunc
+			io.StatsFunc
+		}{reader, timestamp, statsReporter.Stats}
+	}
+	return WithTimestamp(reader, timestamp)
+}
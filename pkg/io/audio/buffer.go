@@ -2,6 +2,7 @@ package audio
 
 import (
 	"errors"
+	"time"
 
 	"github.com/pion/mediadevices/pkg/wave"
 )
@@ -11,9 +12,12 @@ var errUnsupported = errors.New("unsupported audio format")
 // NewBuffer creates audio transform to buffer signal to have exact nSample samples.
 func NewBuffer(nSamples int) TransformFunc {
 	var inBuff wave.Audio
+	// inTimestamp is the capture time of the first buffered sample, and outTimestamp is the capture time
+	// of the latest output chunk.
+	var inTimestamp, outTimestamp time.Time
 
 	return func(r Reader) Reader {
-		return ReaderFunc(func() (wave.Audio, func(), error) {
+		return WithTimestamp(ReaderFunc(func() (wave.Audio, func(), error) {
 			for {
 				if inBuff != nil && inBuff.ChunkInfo().Len >= nSamples {
 					break
@@ -23,6 +27,9 @@ func NewBuffer(nSamples int) TransformFunc {
 				if err != nil {
 					return nil, func() {}, err
 				}
+				if inBuff == nil || inBuff.ChunkInfo().Len == 0 {
+					inTimestamp = Timestamp(r)
+				}
 				switch b := buff.(type) {
 				case *wave.Float32Interleaved:
 					ib, ok := inBuff.(*wave.Float32Interleaved)
@@ -62,6 +69,13 @@ func NewBuffer(nSamples int) TransformFunc {
 					return nil, func() {}, errUnsupported
 				}
 			}
+
+			outTimestamp = inTimestamp
+			if !inTimestamp.IsZero() {
+				samplingRate := inBuff.ChunkInfo().SamplingRate
+				inTimestamp = inTimestamp.Add(time.Duration(nSamples) * time.Second / time.Duration(samplingRate))
+			}
+
 			switch ib := inBuff.(type) {
 			case *wave.Int16Interleaved:
 				ibCopy := *ib
@@ -84,6 +98,8 @@ func NewBuffer(nSamples int) TransformFunc {
 				return &ibCopy, func() {}, nil
 			}
 			return nil, func() {}, errUnsupported
+		}), func() time.Time {
+			return outTimestamp
 		})
 	}
 }
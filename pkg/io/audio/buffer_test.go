@@ -4,6 +4,7 @@ import (
 	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/pion/mediadevices/pkg/wave"
 )
@@ -70,3 +71,30 @@ func TestBuffer(t *testing.T) {
 		}
 	}
 }
+
+func TestBufferTimestamp(t *testing.T) {
+	start := time.Unix(100, 0)
+	var timestamp time.Time
+	var nSent int
+	r := NewBuffer(3)(WithTimestamp(ReaderFunc(func() (wave.Audio, func(), error) {
+		// 2 samples are captured every 2 seconds
+		timestamp = start.Add(time.Duration(nSent) * time.Second)
+		nSent += 2
+		return &wave.Int16Interleaved{
+			Size: wave.ChunkInfo{Len: 2, Channels: 1, SamplingRate: 1},
+			Data: []int16{1, 2},
+		}, func() {}, nil
+	}), func() time.Time {
+		return timestamp
+	}))
+
+	for i := 0; i < 4; i++ {
+		if _, _, err := r.Read(); err != nil {
+			t.Fatal(err)
+		}
+		expected := start.Add(time.Duration(i*3) * time.Second)
+		if actual := Timestamp(r); !actual.Equal(expected) {
+			t.Errorf("Expected timestamp of chunk[%d] to be %v, got %v", i, expected, actual)
+		}
+	}
+}
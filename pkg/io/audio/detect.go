@@ -13,7 +13,7 @@ func DetectChanges(interval time.Duration, onChange func(prop.Media)) TransformF
 	return func(r Reader) Reader {
 		var currentProp prop.Media
 		var chunkCount uint
-		return ReaderFunc(func() (wave.Audio, func(), error) {
+		return passTimestamp(r, ReaderFunc(func() (wave.Audio, func(), error) {
 			var dirty bool
 
 			chunk, _, err := r.Read()
@@ -50,6 +50,6 @@ func DetectChanges(interval time.Duration, onChange func(prop.Media)) TransformF
 
 			chunkCount++
 			return chunk, func() {}, nil
-		})
+		}))
 	}
 }
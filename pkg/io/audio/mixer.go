@@ -8,7 +8,7 @@ import (
 // NewChannelMixer creates audio transform to mix audio channels.
 func NewChannelMixer(channels int, mixer mixer.ChannelMixer) TransformFunc {
 	return func(r Reader) Reader {
-		return ReaderFunc(func() (wave.Audio, func(), error) {
+		return passTimestamp(r, ReaderFunc(func() (wave.Audio, func(), error) {
 			buff, _, err := r.Read()
 			if err != nil {
 				return nil, func() {}, err
@@ -35,6 +35,6 @@ func NewChannelMixer(channels int, mixer mixer.ChannelMixer) TransformFunc {
 				return nil, func() {}, err
 			}
 			return mixed, func() {}, nil
-		})
+		}))
 	}
 }
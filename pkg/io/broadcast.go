@@ -2,6 +2,7 @@ package io
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -17,9 +18,22 @@ const (
 var errEmptySource = fmt.Errorf("Source can't be nil")
 
 type broadcasterData struct {
-	data  interface{}
-	count uint32
-	err   error
+	data      interface{}
+	count     uint32
+	err       error
+	timestamp time.Time
+}
+
+// broadcasterBuffer keeps the latest data from the source, and lets readers wait for new data
+type broadcasterBuffer interface {
+	// acquire returns a function to push the data with count if the caller is the first reader
+	// that reaches count. Otherwise, nil is returned, and the caller should get the data instead.
+	acquire(count uint32) func(*broadcasterData)
+	// get returns the data with count, or the oldest data after count if the data has already been
+	// overwritten. If the data is being read from the source, get waits until it's pushed.
+	get(count uint32) *broadcasterData
+	// lastCount returns the count of the latest data
+	lastCount() uint32
 }
 
 type broadcasterRing struct {
@@ -81,21 +95,106 @@ func (ring *broadcasterRing) lastCount() uint32 {
 	return uint32(atomic.LoadUint64(&ring.state)) - 1
 }
 
+// broadcasterNotifyRing is a ring buffer that wakes up the waiting readers as soon as new data is
+// pushed, instead of letting them poll.
+type broadcasterNotifyRing struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	next    uint32
+	reading bool
+	buffer  []*broadcasterData
+}
+
+func newBroadcasterNotifyRing(size uint) *broadcasterNotifyRing {
+	ring := &broadcasterNotifyRing{buffer: make([]*broadcasterData, size)}
+	ring.cond = sync.NewCond(&ring.mu)
+	return ring
+}
+
+func (ring *broadcasterNotifyRing) index(count uint32) int {
+	return int(count) % len(ring.buffer)
+}
+
+func (ring *broadcasterNotifyRing) acquire(count uint32) func(*broadcasterData) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	// Same as broadcasterRing, only 1 reader is allowed to read from the source
+	if ring.reading || ring.next != count {
+		return nil
+	}
+
+	ring.reading = true
+	return func(data *broadcasterData) {
+		ring.mu.Lock()
+		ring.buffer[ring.index(count)] = data
+		ring.next = count + 1
+		ring.reading = false
+		ring.mu.Unlock()
+		ring.cond.Broadcast()
+	}
+}
+
+func (ring *broadcasterNotifyRing) get(count uint32) *broadcasterData {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	for {
+		for ring.reading && ring.next == count {
+			ring.cond.Wait()
+		}
+
+		data := ring.buffer[ring.index(count)]
+		if data != nil && data.count == count {
+			return data
+		}
+
+		count++
+	}
+}
+
+func (ring *broadcasterNotifyRing) lastCount() uint32 {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	// ring.next always keeps track the next count, so we need to subtract it by 1 to get the
+	// last count
+	return ring.next - 1
+}
+
 // Broadcaster is a generic pull-based broadcaster. Broadcaster is unique in a sense that
 // readers can come and go at anytime, and readers don't need to close or notify broadcaster.
 type Broadcaster struct {
-	source atomic.Value
-	buffer *broadcasterRing
+	// IMPORTANT: 64 bits counters have to be the first elements in struct, otherwise atomic operations
+	//            will panic in 32 bits systems due to unallignment
+	produced  uint64
+	delivered uint64
+	skipped   uint64
+	source    atomic.Value
+	buffer    broadcasterBuffer
 }
 
+// BroadcasterMode selects how readers wait for new data to come
+type BroadcasterMode int
+
+const (
+	// BroadcasterPoll lets readers poll the ring buffer every PollDuration. It's lockless, but readers
+	// can be late up to PollDuration. This is the default mode.
+	BroadcasterPoll BroadcasterMode = iota
+	// BroadcasterNotify lets readers sleep until new data is pushed to the ring buffer, so they get the
+	// data as soon as it comes.
+	BroadcasterNotify
+)
+
 // BroadcasterConfig is a config to control broadcaster behaviour
 type BroadcasterConfig struct {
 	// BufferSize configures the underlying ring buffer size that's being used
 	// to avoid data lost for late readers. The default value is 32.
 	BufferSize uint
 	// PollDuration configures the sleep duration in waiting for new data to come.
-	// The default value is 33 ms.
+	// The default value is 33 ms. It's only used by BroadcasterPoll.
 	PollDuration time.Duration
+	// Mode configures how readers wait for new data to come. The default value is BroadcasterPoll.
+	Mode BroadcasterMode
 }
 
 // NewBroadcaster creates a new broadcaster. Source is expected to drop frames
@@ -103,6 +202,7 @@ type BroadcasterConfig struct {
 func NewBroadcaster(source Reader, config *BroadcasterConfig) *Broadcaster {
 	pollDuration := defaultBroadcasterRingPollDuration
 	var bufferSize uint = defaultBroadcasterRingSize
+	mode := BroadcasterPoll
 	if config != nil {
 		if config.PollDuration != 0 {
 			pollDuration = config.PollDuration
@@ -111,10 +211,17 @@ func NewBroadcaster(source Reader, config *BroadcasterConfig) *Broadcaster {
 		if config.BufferSize != 0 {
 			bufferSize = config.BufferSize
 		}
+
+		mode = config.Mode
 	}
 
 	var broadcaster Broadcaster
-	broadcaster.buffer = newBroadcasterRing(bufferSize, pollDuration)
+	switch mode {
+	case BroadcasterNotify:
+		broadcaster.buffer = newBroadcasterNotifyRing(bufferSize)
+	default:
+		broadcaster.buffer = newBroadcasterRing(bufferSize, pollDuration)
+	}
 	broadcaster.ReplaceSource(source)
 
 	return &broadcaster
@@ -124,28 +231,24 @@ func NewBroadcaster(source Reader, config *BroadcasterConfig) *Broadcaster {
 // copyFn is used to copy the data from the source to individual readers. Broadcaster uses a small ring
 // buffer, this means that slow readers might miss some data if they're really late and the data is no longer
 // in the ring buffer.
+// The returned reader reports the capture time of the data if the source implements Timestamper, and
+// implements StatsReporter to tell how far it's behind the source.
 func (broadcaster *Broadcaster) NewReader(copyFn func(interface{}) interface{}) Reader {
-	currentCount := broadcaster.buffer.lastCount()
-
-	return ReaderFunc(func() (data interface{}, release func(), err error) {
-		currentCount++
-		if push := broadcaster.buffer.acquire(currentCount); push != nil {
-			data, _, err = broadcaster.source.Load().(Reader).Read()
-			push(&broadcasterData{
-				data:  data,
-				err:   err,
-				count: currentCount,
-			})
-		} else {
-			ringData := broadcaster.buffer.get(currentCount)
-			data, err, currentCount = ringData.data, ringData.err, ringData.count
-		}
+	r := &broadcasterReader{
+		broadcaster:  broadcaster,
+		copyFn:       copyFn,
+		currentCount: broadcaster.buffer.lastCount(),
+	}
+	return r
+}
 
-		if data != nil { // data is nil if an error occurred during reading
-			data = copyFn(data)
-		}
-		return
-	})
+// Stats returns the statistics of the broadcaster. This operation is thread safe.
+func (broadcaster *Broadcaster) Stats() BroadcasterStats {
+	return BroadcasterStats{
+		Produced:  atomic.LoadUint64(&broadcaster.produced),
+		Delivered: atomic.LoadUint64(&broadcaster.delivered),
+		Skipped:   atomic.LoadUint64(&broadcaster.skipped),
+	}
 }
 
 // ReplaceSource replaces the underlying source. This operation is thread safe.
@@ -162,3 +265,65 @@ func (broadcaster *Broadcaster) ReplaceSource(source Reader) error {
 func (broadcaster *Broadcaster) Source() Reader {
 	return broadcaster.source.Load().(Reader)
 }
+
+type broadcasterReader struct {
+	// IMPORTANT: 64 bits counters have to be the first elements in struct, otherwise atomic operations
+	//            will panic in 32 bits systems due to unallignment
+	delivered    uint64
+	skipped      uint64
+	currentCount uint32
+	broadcaster  *Broadcaster
+	copyFn       func(interface{}) interface{}
+	timestamp    time.Time
+}
+
+func (r *broadcasterReader) Read() (data interface{}, release func(), err error) {
+	broadcaster := r.broadcaster
+	count := atomic.LoadUint32(&r.currentCount) + 1
+	if push := broadcaster.buffer.acquire(count); push != nil {
+		source := broadcaster.source.Load().(Reader)
+		data, _, err = source.Read()
+		r.timestamp = Timestamp(source)
+		push(&broadcasterData{
+			data:      data,
+			err:       err,
+			count:     count,
+			timestamp: r.timestamp,
+		})
+		atomic.AddUint64(&broadcaster.produced, 1)
+	} else {
+		ringData := broadcaster.buffer.get(count)
+		if skipped := uint64(ringData.count - count); skipped > 0 {
+			atomic.AddUint64(&r.skipped, skipped)
+			atomic.AddUint64(&broadcaster.skipped, skipped)
+		}
+		data, err, count, r.timestamp = ringData.data, ringData.err, ringData.count, ringData.timestamp
+	}
+	atomic.StoreUint32(&r.currentCount, count)
+	atomic.AddUint64(&r.delivered, 1)
+	atomic.AddUint64(&broadcaster.delivered, 1)
+
+	if data != nil { // data is nil if an error occurred during reading
+		data = r.copyFn(data)
+	}
+	release = func() {}
+	return
+}
+
+// Timestamp implements Timestamper.
+func (r *broadcasterReader) Timestamp() time.Time {
+	return r.timestamp
+}
+
+// Stats implements StatsReporter. It's safe to call Stats while the reader is being read.
+func (r *broadcasterReader) Stats() ReaderStats {
+	stats := ReaderStats{
+		Delivered: atomic.LoadUint64(&r.delivered),
+		Skipped:   atomic.LoadUint64(&r.skipped),
+	}
+	// The reader might move forward after the last count is loaded
+	if lag := int32(r.broadcaster.buffer.lastCount() - atomic.LoadUint32(&r.currentCount)); lag > 0 {
+		stats.Lag = uint64(lag)
+	}
+	return stats
+}
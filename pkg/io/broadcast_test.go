@@ -9,6 +9,13 @@ import (
 	"time"
 )
 
+type broadcastPauseCond struct {
+	src          bool
+	dst          bool
+	expectedFPS  float64
+	expectedDrop float64
+}
+
 func TestBroadcast(t *testing.T) {
 	// https://github.com/pion/mediadevices/issues/198
 	if runtime.GOOS == "darwin" {
@@ -19,12 +26,7 @@ func TestBroadcast(t *testing.T) {
 		frames[i] = i
 	}
 
-	routinePauseConds := []struct {
-		src          bool
-		dst          bool
-		expectedFPS  float64
-		expectedDrop float64
-	}{
+	routinePauseConds := []broadcastPauseCond{
 		{
 			src:         false,
 			dst:         false,
@@ -44,6 +46,20 @@ func TestBroadcast(t *testing.T) {
 		},
 	}
 
+	modes := map[string]BroadcasterMode{
+		"Poll":   BroadcasterPoll,
+		"Notify": BroadcasterNotify,
+	}
+
+	for modeName, mode := range modes {
+		mode := mode
+		t.Run(modeName, func(t *testing.T) {
+			testBroadcast(t, mode, frames, routinePauseConds)
+		})
+	}
+}
+
+func testBroadcast(t *testing.T, mode BroadcasterMode, frames []int, routinePauseConds []broadcastPauseCond) {
 	for _, pauseCond := range routinePauseConds {
 		pauseCond := pauseCond
 		t.Run(fmt.Sprintf("SrcPause-%v/DstPause-%v", pauseCond.src, pauseCond.dst), func(t *testing.T) {
@@ -76,7 +92,7 @@ func TestBroadcast(t *testing.T) {
 						frameSent++
 						return frame, func() {}, nil
 					})
-					broadcaster := NewBroadcaster(src, nil)
+					broadcaster := NewBroadcaster(src, &BroadcasterConfig{Mode: mode})
 					var done uint32
 					duration := time.Second * 3
 					fpsChan := make(chan []float64)
@@ -148,3 +164,114 @@ func TestBroadcast(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkBroadcaster(b *testing.B) {
+	modes := []struct {
+		name string
+		mode BroadcasterMode
+	}{
+		{"Poll", BroadcasterPoll},
+		{"Notify", BroadcasterNotify},
+	}
+
+	for _, mode := range modes {
+		mode := mode
+		for n := 1; n <= 64; n *= 4 {
+			n := n
+			b.Run(fmt.Sprintf("%s/Readers-%d", mode.name, n), func(b *testing.B) {
+				benchmarkBroadcaster(b, mode.mode, n)
+			})
+		}
+	}
+}
+
+// benchmarkBroadcaster measures how long it takes for n readers to read b.N frames from a 1 kHz
+// source, and the average latency between the capture and the read.
+func benchmarkBroadcaster(b *testing.B, mode BroadcasterMode, n int) {
+	var frameCount int
+	var timestamp time.Time
+	src := WithTimestamp(ReaderFunc(func() (interface{}, func(), error) {
+		time.Sleep(time.Millisecond)
+		timestamp = time.Now()
+		frameCount++
+		return frameCount, func() {}, nil
+	}), func() time.Time {
+		return timestamp
+	})
+	broadcaster := NewBroadcaster(src, &BroadcasterConfig{Mode: mode})
+
+	var totalLatency int64
+	var totalReads int64
+	var wg sync.WaitGroup
+	wg.Add(n)
+	b.ResetTimer()
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			reader := broadcaster.NewReader(func(src interface{}) interface{} { return src })
+			for {
+				frame, _, err := reader.Read()
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				atomic.AddInt64(&totalLatency, int64(time.Since(Timestamp(reader))))
+				atomic.AddInt64(&totalReads, 1)
+				if frame.(int) >= b.N {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	b.StopTimer()
+
+	b.ReportMetric(float64(totalLatency)/float64(totalReads)/float64(time.Microsecond), "us-latency/read")
+}
+
+func TestBroadcasterStats(t *testing.T) {
+	for _, mode := range []BroadcasterMode{BroadcasterPoll, BroadcasterNotify} {
+		var count int
+		src := ReaderFunc(func() (interface{}, func(), error) {
+			count++
+			return count, func() {}, nil
+		})
+		broadcaster := NewBroadcaster(src, &BroadcasterConfig{BufferSize: 4, Mode: mode})
+		fast := broadcaster.NewReader(func(src interface{}) interface{} { return src })
+		slow := broadcaster.NewReader(func(src interface{}) interface{} { return src })
+
+		for i := 0; i < 10; i++ {
+			if _, _, err := fast.Read(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		assertStats := func(r Reader, expected ReaderStats) {
+			t.Helper()
+			stats, ok := Stats(r)
+			if !ok {
+				t.Fatal("Expected the reader to keep track of its statistics")
+			}
+			if stats != expected {
+				t.Errorf("Expected %+v, got %+v", expected, stats)
+			}
+		}
+		assertStats(fast, ReaderStats{Delivered: 10})
+		assertStats(slow, ReaderStats{Lag: 10})
+
+		// The first 6 data have already been overwritten in the ring buffer
+		data, _, err := slow.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.(int) != 7 {
+			t.Errorf("Expected the oldest data in the ring buffer, got %v", data)
+		}
+		assertStats(slow, ReaderStats{Delivered: 1, Skipped: 6, Lag: 3})
+
+		expected := BroadcasterStats{Produced: 10, Delivered: 11, Skipped: 6}
+		if stats := broadcaster.Stats(); stats != expected {
+			t.Errorf("Expected %+v, got %+v", expected, stats)
+		}
+	}
+}
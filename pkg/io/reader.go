@@ -1,5 +1,7 @@
 package io
 
+import "time"
+
 // Reader is a generic data reader. In the future, interface{} should be replaced by a generic type
 // to provide strong type.
 type Reader interface {
@@ -21,3 +23,34 @@ func (f ReaderFunc) Read() (data interface{}, release func(), err error) {
 	data, release, err = f()
 	return
 }
+
+// Timestamper is an optional interface for readers that know when the data was captured.
+type Timestamper interface {
+	// Timestamp returns the capture time of the data that was returned by the latest Read call.
+	// Zero time means that the capture time is unknown.
+	Timestamp() time.Time
+}
+
+// Timestamp returns the capture time of the latest data read from r. If r doesn't keep track of
+// the capture time, zero time is returned.
+func Timestamp(r interface{}) time.Time {
+	if t, ok := r.(Timestamper); ok {
+		return t.Timestamp()
+	}
+	return time.Time{}
+}
+
+// TimestampFunc is a proxy type for Timestamper
+type TimestampFunc func() time.Time
+
+func (f TimestampFunc) Timestamp() time.Time {
+	return f()
+}
+
+// WithTimestamp returns a Reader that reads from r, and reports the capture time with timestamp.
+func WithTimestamp(r Reader, timestamp TimestampFunc) Reader {
+	return &struct {
+		Reader
+		TimestampFunc
+	}{r, timestamp}
+}
@@ -0,0 +1,43 @@
+package io
+
+// ReaderStats is a snapshot of the statistics of a broadcaster reader.
+type ReaderStats struct {
+	// Delivered is the number of data that have been delivered to the reader.
+	Delivered uint64
+	// Skipped is the number of data that the reader has missed because it was too slow, and
+	// the data had already been overwritten in the ring buffer.
+	Skipped uint64
+	// Lag is the number of data that the source has produced after the latest data delivered
+	// to the reader.
+	Lag uint64
+}
+
+// BroadcasterStats is a snapshot of the statistics of a broadcaster.
+type BroadcasterStats struct {
+	// Produced is the number of data that have been read from the source.
+	Produced uint64
+	// Delivered is the total number of data that have been delivered to the readers.
+	Delivered uint64
+	// Skipped is the total number of data that the readers have missed.
+	Skipped uint64
+}
+
+// StatsReporter is an optional interface for readers that keep track of their statistics.
+type StatsReporter interface {
+	Stats() ReaderStats
+}
+
+// Stats returns the statistics of r. ok is false if r doesn't keep track of its statistics.
+func Stats(r interface{}) (stats ReaderStats, ok bool) {
+	if s, ok := r.(StatsReporter); ok {
+		return s.Stats(), true
+	}
+	return ReaderStats{}, false
+}
+
+// StatsFunc is a proxy type for StatsReporter
+type StatsFunc func() ReaderStats
+
+func (f StatsFunc) Stats() ReaderStats {
+	return f()
+}
@@ -5,6 +5,7 @@ import (
 	"image"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestBroadcast(t *testing.T) {
@@ -66,3 +67,35 @@ func TestBroadcastWithCopyOnReadError(t *testing.T) {
 		t.Fatal("Expected error to be the same")
 	}
 }
+
+func TestBroadcastTimestamp(t *testing.T) {
+	var timestamp time.Time
+	source := WithTimestamp(ReaderFunc(func() (image.Image, func(), error) {
+		timestamp = timestamp.Add(time.Second)
+		return image.NewGray(image.Rect(0, 0, 4, 4)), func() {}, nil
+	}), func() time.Time {
+		return timestamp
+	})
+
+	broadcaster := NewBroadcaster(source, nil)
+	// Transforms should report the capture time of the frame that they've transformed
+	reader := Merge(ToI420, Scale(2, 2, nil))(broadcaster.NewReader(false))
+
+	for i := 1; i <= 3; i++ {
+		if _, _, err := reader.Read(); err != nil {
+			t.Fatal(err)
+		}
+		expected := time.Time{}.Add(time.Duration(i) * time.Second)
+		if actual := Timestamp(reader); !actual.Equal(expected) {
+			t.Errorf("Expected timestamp %v, got %v", expected, actual)
+		}
+	}
+
+	if stats, ok := Stats(broadcaster.NewReader(false)); !ok || stats.Lag != 0 {
+		t.Errorf("Expected a new reader to be up to date, got %+v", stats)
+	}
+
+	if actual := Timestamp(ReaderFunc(source.Read)); !actual.IsZero() {
+		t.Errorf("Expected zero timestamp from a reader without capture time, got %v", actual)
+	}
+}
@@ -84,7 +84,7 @@ func ToI420(r Reader) Reader {
 		return dst
 	}
 
-	return ReaderFunc(func() (image.Image, func(), error) {
+	return passTimestamp(r, ReaderFunc(func() (image.Image, func(), error) {
 		img, _, err := r.Read()
 		if err != nil {
 			return nil, func() {}, err
@@ -116,7 +116,7 @@ func ToI420(r Reader) Reader {
 		}
 
 		return &yuvImg, releaseFunc, nil
-	})
+	}))
 }
 
 // imageToRGBA converts src to *image.RGBA and store it to dst
@@ -162,7 +162,7 @@ func imageToRGBA(dst *image.RGBA, src image.Image) {
 // ToRGBA converts r to a new reader that will output images in RGBA format
 func ToRGBA(r Reader) Reader {
 	var dst image.RGBA
-	return ReaderFunc(func() (image.Image, func(), error) {
+	return passTimestamp(r, ReaderFunc(func() (image.Image, func(), error) {
 		img, _, err := r.Read()
 		if err != nil {
 			return nil, func() {}, err
@@ -170,5 +170,5 @@ func ToRGBA(r Reader) Reader {
 
 		imageToRGBA(&dst, img)
 		return &dst, func() {}, nil
-	})
+	}))
 }
@@ -0,0 +1,52 @@
+package video
+
+import (
+	"errors"
+	"image"
+)
+
+var (
+	errCropUnsupportedImageType = errors.New("cropping: unsupported image type")
+	errCropOutOfBounds          = errors.New("cropping: the rectangle is out of the bounds of the image")
+)
+
+// Crop returns video cropping transform. rect is relative to the top-left corner of incoming image,
+// and the cropped image starts at (0, 0). For YCbCr, the top-left corner of rect is rounded down to
+// even coordinates to keep the chroma samples aligned.
+//
+// The cropped image shares the pixels with incoming image, so no pixel is copied.
+func Crop(rect image.Rectangle) TransformFunc {
+	rect = rect.Canon()
+	return func(r Reader) Reader {
+		return passTimestamp(r, ReaderFunc(func() (image.Image, func(), error) {
+			img, release, err := r.Read()
+			if err != nil {
+				return nil, func() {}, err
+			}
+
+			cropRect := rect.Add(img.Bounds().Min)
+			if _, ok := img.(*image.YCbCr); ok {
+				cropRect = cropRect.Sub(image.Pt(cropRect.Min.X&1, cropRect.Min.Y&1))
+			}
+			if !cropRect.In(img.Bounds()) {
+				return nil, func() {}, errCropOutOfBounds
+			}
+			size := image.Rect(0, 0, cropRect.Dx(), cropRect.Dy())
+
+			switch v := img.(type) {
+			case *image.RGBA:
+				cropped := *(v.SubImage(cropRect).(*image.RGBA))
+				cropped.Rect = size
+				return &cropped, release, nil
+
+			case *image.YCbCr:
+				cropped := *(v.SubImage(cropRect).(*image.YCbCr))
+				cropped.Rect = size
+				return &cropped, release, nil
+
+			default:
+				return nil, func() {}, errCropUnsupportedImageType
+			}
+		}))
+	}
+}
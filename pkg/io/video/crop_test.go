@@ -0,0 +1,69 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCrop(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	rgba.Set(2, 1, color.RGBA{R: 255, A: 255})
+
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, 8, 4), image.YCbCrSubsampleRatio420)
+	ycbcr.Y[ycbcr.YOffset(2, 2)] = 200
+	ycbcr.Cb[ycbcr.COffset(2, 2)] = 100
+
+	cases := map[string]struct {
+		src   image.Image
+		rect  image.Rectangle
+		check func(t *testing.T, img image.Image)
+	}{
+		"RGBA": {
+			src:  rgba,
+			rect: image.Rect(2, 1, 6, 3),
+			check: func(t *testing.T, img image.Image) {
+				if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xffff {
+					t.Errorf("Expected the top-left pixel to be red, got %v", img.At(0, 0))
+				}
+			},
+		},
+		"YCbCr": {
+			// The top-left corner is rounded down to (2, 2) to keep the chroma samples aligned
+			src:  ycbcr,
+			rect: image.Rect(3, 3, 7, 5),
+			check: func(t *testing.T, img image.Image) {
+				c := img.(*image.YCbCr).YCbCrAt(0, 0)
+				if c.Y != 200 || c.Cb != 100 {
+					t.Errorf("Expected the top-left pixel to be (200, 100), got %v", c)
+				}
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			r := Crop(c.rect)(ReaderFunc(func() (image.Image, func(), error) {
+				return c.src, func() {}, nil
+			}))
+			img, _, err := r.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bounds := img.Bounds(); bounds != image.Rect(0, 0, c.rect.Dx(), c.rect.Dy()) {
+				t.Errorf("Expected the bounds to be %v, got %v", image.Rect(0, 0, c.rect.Dx(), c.rect.Dy()), bounds)
+			}
+			c.check(t, img)
+		})
+	}
+}
+
+func TestCropOutOfBounds(t *testing.T) {
+	r := Crop(image.Rect(0, 0, 16, 16))(ReaderFunc(func() (image.Image, func(), error) {
+		return image.NewRGBA(image.Rect(0, 0, 8, 8)), func() {}, nil
+	}))
+	if _, _, err := r.Read(); err != errCropOutOfBounds {
+		t.Errorf("Expected %v, got %v", errCropOutOfBounds, err)
+	}
+}
@@ -15,7 +15,7 @@ func DetectChanges(interval time.Duration, fpsDiffTolerance float64, onChange fu
 		var currentProp prop.Media
 		var lastTaken time.Time
 		var frames uint
-		return ReaderFunc(func() (image.Image, func(), error) {
+		return passTimestamp(r, ReaderFunc(func() (image.Image, func(), error) {
 			var dirty bool
 
 			img, _, err := r.Read()
@@ -55,6 +55,6 @@ func DetectChanges(interval time.Duration, fpsDiffTolerance float64, onChange fu
 
 			frames++
 			return img, func() {}, nil
-		})
+		}))
 	}
 }
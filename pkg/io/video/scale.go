@@ -156,7 +156,7 @@ func Scale(width, height int, scaler Scaler) TransformFunc {
 			}
 		}
 
-		return ReaderFunc(func() (image.Image, func(), error) {
+		return passTimestamp(r, ReaderFunc(func() (image.Image, func(), error) {
 			img, _, err := r.Read()
 			if err != nil {
 				return nil, func() {}, err
@@ -189,6 +189,6 @@ func Scale(width, height int, scaler Scaler) TransformFunc {
 			default:
 				return nil, func() {}, errUnsupportedImageType
 			}
-		})
+		}))
 	}
 }
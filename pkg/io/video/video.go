@@ -2,6 +2,9 @@ package video
 
 import (
 	"image"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/io"
 )
 
 type Reader interface {
@@ -23,6 +26,34 @@ func (rf ReaderFunc) Read() (img image.Image, release func(), err error) {
 	return
 }
 
+// Timestamp returns the capture time of the latest frame read from r. If r doesn't keep track of
+// the capture time, zero time is returned.
+func Timestamp(r Reader) time.Time {
+	return io.Timestamp(r)
+}
+
+// WithTimestamp returns a Reader that reads from r, and reports the capture time with timestamp.
+func WithTimestamp(r Reader, timestamp io.TimestampFunc) Reader {
+	return &struct {
+		Reader
+		io.TimestampFunc
+	}{r, timestamp}
+}
+
+// Stats returns the statistics of r if r is created by Broadcaster.NewReader. ok is false if r
+// doesn't keep track of its statistics.
+func Stats(r Reader) (stats io.ReaderStats, ok bool) {
+	return io.Stats(r)
+}
+
+// passTimestamp returns a Reader that reads from r, and reports the capture time of upstream.
+// It's used by transforms that produce a frame from the latest frame of upstream.
+func passTimestamp(upstream Reader, r Reader) Reader {
+	return WithTimestamp(r, func() time.Time {
+		return Timestamp(upstream)
+	})
+}
+
 // TransformFunc produces a new Reader that will produces a transformed video
 type TransformFunc func(r Reader) Reader
 
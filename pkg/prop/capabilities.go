@@ -0,0 +1,142 @@
+package prop
+
+import (
+	"time"
+
+	"github.com/pion/mediadevices/pkg/frame"
+)
+
+// IntRange represents the range of supported int values. Zero values mean the range is unknown.
+type IntRange struct {
+	Min, Max int
+}
+
+func (r *IntRange) add(v int) {
+	if v == 0 {
+		return
+	}
+	if r.Min == 0 || v < r.Min {
+		r.Min = v
+	}
+	if v > r.Max {
+		r.Max = v
+	}
+}
+
+// FloatRange represents the range of supported float values. Zero values mean the range is unknown.
+type FloatRange struct {
+	Min, Max float32
+}
+
+func (r *FloatRange) add(v float32) {
+	if v == 0 {
+		return
+	}
+	if r.Min == 0 || v < r.Min {
+		r.Min = v
+	}
+	if v > r.Max {
+		r.Max = v
+	}
+}
+
+// DurationRange represents the range of supported durations. Zero values mean the range is unknown.
+type DurationRange struct {
+	Min, Max time.Duration
+}
+
+func (r *DurationRange) add(v time.Duration) {
+	if v == 0 {
+		return
+	}
+	if r.Min == 0 || v < r.Min {
+		r.Min = v
+	}
+	if v > r.Max {
+		r.Max = v
+	}
+}
+
+// MediaCapabilities represents the ranges and the sets of media properties that are supported.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediatrackcapabilities
+type MediaCapabilities struct {
+	DeviceID string
+	VideoCapabilities
+	AudioCapabilities
+}
+
+// VideoCapabilities represents the video properties that are supported
+type VideoCapabilities struct {
+	Width, Height IntRange
+	AspectRatio   FloatRange
+	FrameRate     FloatRange
+	FrameFormat   []frame.Format
+	// ResizeMode is the set of ResizeModeNone and ResizeModeCropAndScale that are supported
+	ResizeMode []string
+}
+
+// AudioCapabilities represents the audio properties that are supported
+type AudioCapabilities struct {
+	ChannelCount IntRange
+	Latency      DurationRange
+	SampleRate   IntRange
+	SampleSize   IntRange
+}
+
+// NewMediaCapabilities returns the capabilities that cover all of the given properties.
+// The properties that are zero, i.e. unknown, are ignored.
+func NewMediaCapabilities(props ...Media) MediaCapabilities {
+	var c MediaCapabilities
+	for _, p := range props {
+		c.Add(p)
+	}
+	return c
+}
+
+// Add extends the capabilities to cover p
+func (c *MediaCapabilities) Add(p Media) {
+	if c.DeviceID == "" {
+		c.DeviceID = p.DeviceID
+	}
+
+	c.Width.add(p.Width)
+	c.Height.add(p.Height)
+	c.AspectRatio.add(p.VideoAspectRatio())
+	c.FrameRate.add(p.FrameRate)
+	if p.FrameFormat != "" && !c.hasFrameFormat(p.FrameFormat) {
+		c.FrameFormat = append(c.FrameFormat, p.FrameFormat)
+	}
+	if p.VideoAspectRatio() != 0 {
+		// The resize mode only matters to the video, whose size is known
+		c.AddResizeMode(p.VideoResizeMode())
+	}
+
+	c.ChannelCount.add(p.ChannelCount)
+	c.Latency.add(p.Latency)
+	c.SampleRate.add(p.SampleRate)
+	c.SampleSize.add(p.SampleSize)
+}
+
+func (c *MediaCapabilities) hasFrameFormat(f frame.Format) bool {
+	for _, format := range c.FrameFormat {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// AddResizeMode extends the capabilities to support mode, which is either ResizeModeNone or
+// ResizeModeCropAndScale
+func (c *MediaCapabilities) AddResizeMode(mode string) {
+	for _, m := range c.ResizeMode {
+		if m == mode {
+			return
+		}
+	}
+	c.ResizeMode = append(c.ResizeMode, mode)
+}
+
+func (c *MediaCapabilities) String() string {
+	return prettifyStruct(c)
+}
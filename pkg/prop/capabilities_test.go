@@ -0,0 +1,52 @@
+package prop
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/frame"
+)
+
+func TestNewMediaCapabilities(t *testing.T) {
+	t.Run("Video", func(t *testing.T) {
+		c := NewMediaCapabilities(
+			Media{DeviceID: "camera", Video: Video{Width: 640, Height: 480, FrameRate: 30, FrameFormat: frame.FormatYUYV}},
+			Media{DeviceID: "camera", Video: Video{Width: 1920, Height: 1080, FrameFormat: frame.FormatMJPEG}},
+			Media{DeviceID: "camera", Video: Video{Width: 320, Height: 240, FrameRate: 60, FrameFormat: frame.FormatYUYV}},
+			Media{DeviceID: "camera", Video: Video{Width: 320, Height: 180, FrameFormat: frame.FormatYUYV, ResizeMode: ResizeModeCropAndScale}},
+		)
+		expected := MediaCapabilities{
+			DeviceID: "camera",
+			VideoCapabilities: VideoCapabilities{
+				Width:       IntRange{Min: 320, Max: 1920},
+				Height:      IntRange{Min: 180, Max: 1080},
+				AspectRatio: FloatRange{Min: 4.0 / 3, Max: 16.0 / 9},
+				FrameRate:   FloatRange{Min: 30, Max: 60},
+				FrameFormat: []frame.Format{frame.FormatYUYV, frame.FormatMJPEG},
+				ResizeMode:  []string{ResizeModeNone, ResizeModeCropAndScale},
+			},
+		}
+		if !reflect.DeepEqual(c, expected) {
+			t.Errorf("Expected\n%s\n\ngot\n%s", &expected, &c)
+		}
+	})
+
+	t.Run("Audio", func(t *testing.T) {
+		c := NewMediaCapabilities(
+			Media{Audio: Audio{SampleRate: 48000, Latency: 20 * time.Millisecond, ChannelCount: 1}},
+			Media{Audio: Audio{SampleRate: 44100, Latency: 10 * time.Millisecond, ChannelCount: 2, SampleSize: 16}},
+		)
+		expected := MediaCapabilities{
+			AudioCapabilities: AudioCapabilities{
+				ChannelCount: IntRange{Min: 1, Max: 2},
+				Latency:      DurationRange{Min: 10 * time.Millisecond, Max: 20 * time.Millisecond},
+				SampleRate:   IntRange{Min: 44100, Max: 48000},
+				SampleSize:   IntRange{Min: 16, Max: 16},
+			},
+		}
+		if !reflect.DeepEqual(c, expected) {
+			t.Errorf("Expected\n%s\n\ngot\n%s", &expected, &c)
+		}
+	})
+}
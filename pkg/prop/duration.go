@@ -75,6 +75,33 @@ func (d DurationOneOf) String() string {
 	return fmt.Sprintf("%s (one of values)", strings.Join(opts, ","))
 }
 
+// DurationIdealOneOf specifies list of ideal duration values.
+// Any value may be selected, but the values in the list take priority.
+type DurationIdealOneOf []time.Duration
+
+// Compare implements DurationConstraint.
+func (d DurationIdealOneOf) Compare(a time.Duration) (float64, bool) {
+	for _, v := range d {
+		if v == a {
+			return 0.0, true
+		}
+	}
+	return 1.0, true
+}
+
+// Value implements DurationConstraint.
+func (DurationIdealOneOf) Value() (time.Duration, bool) { return 0, false }
+
+// String implements Stringify
+func (d DurationIdealOneOf) String() string {
+	var opts []string
+	for _, v := range d {
+		opts = append(opts, fmt.Sprint(v))
+	}
+
+	return fmt.Sprintf("%s (one of ideal values)", strings.Join(opts, ","))
+}
+
 // DurationRanged specifies range of expected duration value.
 // If Ideal is non-zero, closest value to Ideal takes priority.
 type DurationRanged struct {
@@ -74,6 +74,33 @@ func (f FloatOneOf) String() string {
 	return fmt.Sprintf("%s (one of values)", strings.Join(opts, ","))
 }
 
+// FloatIdealOneOf specifies list of ideal float values.
+// Any value may be selected, but the values in the list take priority.
+type FloatIdealOneOf []float32
+
+// Compare implements FloatConstraint.
+func (f FloatIdealOneOf) Compare(a float32) (float64, bool) {
+	for _, v := range f {
+		if v == a {
+			return 0.0, true
+		}
+	}
+	return 1.0, true
+}
+
+// Value implements FloatConstraint.
+func (FloatIdealOneOf) Value() (float32, bool) { return 0, false }
+
+// String implements Stringify
+func (f FloatIdealOneOf) String() string {
+	var opts []string
+	for _, v := range f {
+		opts = append(opts, fmt.Sprintf("%.2f", v))
+	}
+
+	return fmt.Sprintf("%s (one of ideal values)", strings.Join(opts, ","))
+}
+
 // FloatRanged specifies range of expected float value.
 // If Ideal is non-zero, closest value to Ideal takes priority.
 type FloatRanged struct {
@@ -76,3 +76,30 @@ func (f FrameFormatOneOf) String() string {
 
 	return fmt.Sprintf("%s (one of values)", strings.Join(opts, ","))
 }
+
+// FrameFormatIdealOneOf specifies list of ideal frame format values.
+// Any value may be selected, but the values in the list take priority.
+type FrameFormatIdealOneOf []frame.Format
+
+// Compare implements FrameFormatConstraint.
+func (f FrameFormatIdealOneOf) Compare(a frame.Format) (float64, bool) {
+	for _, v := range f {
+		if v == a {
+			return 0.0, true
+		}
+	}
+	return 1.0, true
+}
+
+// Value implements FrameFormatConstraint.
+func (FrameFormatIdealOneOf) Value() (frame.Format, bool) { return "", false }
+
+// String implements Stringify
+func (f FrameFormatIdealOneOf) String() string {
+	var opts []string
+	for _, v := range f {
+		opts = append(opts, fmt.Sprint(v))
+	}
+
+	return fmt.Sprintf("%s (one of ideal values)", strings.Join(opts, ","))
+}
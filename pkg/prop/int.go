@@ -74,6 +74,33 @@ func (i IntOneOf) String() string {
 	return fmt.Sprintf("%s (one of values)", strings.Join(opts, ","))
 }
 
+// IntIdealOneOf specifies list of ideal int values.
+// Any value may be selected, but the values in the list take priority.
+type IntIdealOneOf []int
+
+// Compare implements IntConstraint.
+func (i IntIdealOneOf) Compare(a int) (float64, bool) {
+	for _, v := range i {
+		if v == a {
+			return 0.0, true
+		}
+	}
+	return 1.0, true
+}
+
+// Value implements IntConstraint.
+func (IntIdealOneOf) Value() (int, bool) { return 0, false }
+
+// String implements Stringify
+func (i IntIdealOneOf) String() string {
+	var opts []string
+	for _, v := range i {
+		opts = append(opts, fmt.Sprint(v))
+	}
+
+	return fmt.Sprintf("%s (one of ideal values)", strings.Join(opts, ","))
+}
+
 // IntRanged specifies range of expected int value.
 // If Ideal is non-zero, closest value to Ideal takes priority.
 type IntRanged struct {
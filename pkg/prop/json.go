@@ -0,0 +1,534 @@
+package prop
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/frame"
+)
+
+// The constraints are (de)serialized in the same shape as MediaTrackConstraints of the browsers,
+// e.g. {"width": {"min": 640, "ideal": 1280}, "frameRate": {"max": 30}, "deviceId": {"exact": "id"}}.
+// A bare value is an ideal value. A list of exact values is a one of match, e.g. IntOneOf, and a bare
+// list or a list of ideal values is a preference, e.g. IntIdealOneOf. The durations are in seconds,
+// as latency of the browsers is.
+//
+// The ranged constraints omit their zero bounds, since zero means that the bound isn't specified, so
+// a ranged constraint without any bound, e.g. IntRanged{}, is serialized to {} and parsed back to nil.
+// Both of them accept any value.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediatrackconstraints
+
+var errRangeUnsupported = errors.New("min and max aren't supported by the constraint")
+
+// constraintJSON is a constraint in the object form. The fields are kept raw since their types
+// depend on the constraint.
+type constraintJSON struct {
+	Exact json.RawMessage `json:"exact"`
+	Ideal json.RawMessage `json:"ideal"`
+	Min   json.RawMessage `json:"min"`
+	Max   json.RawMessage `json:"max"`
+}
+
+// parseConstraint parses b into the object form. A bare value is parsed as an ideal value. If b is
+// null or an empty object, which means there's no constraint, false is returned.
+func parseConstraint(b []byte) (constraintJSON, bool, error) {
+	var c constraintJSON
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || b[0] != '{' {
+		if bytes.Equal(b, []byte("null")) {
+			return c, false, nil
+		}
+		c.Ideal = b
+		return c, true, nil
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, false, err
+	}
+	return c, c.Exact != nil || c.Ideal != nil || c.Min != nil || c.Max != nil, nil
+}
+
+// oneOf returns the list of the values if the constraint is a list. exact tells whether the list is
+// a one of match, or ideal values.
+func (c *constraintJSON) oneOf() (values json.RawMessage, exact, ok bool) {
+	values, exact = c.Exact, true
+	if values == nil {
+		values, exact = c.Ideal, false
+	}
+	if values == nil || c.isRange() {
+		return nil, false, false
+	}
+	values = bytes.TrimSpace(values)
+	return values, exact, len(values) > 0 && values[0] == '['
+}
+
+func (c *constraintJSON) isRange() bool {
+	return c.Exact == nil && (c.Min != nil || c.Max != nil)
+}
+
+// decodeRange decodes the range into min, max, and ideal. The fields that are missing are left zero.
+func (c *constraintJSON) decodeRange(min, max, ideal interface{}) error {
+	for _, field := range []struct {
+		raw   json.RawMessage
+		value interface{}
+	}{{c.Min, min}, {c.Max, max}, {c.Ideal, ideal}} {
+		if field.raw == nil {
+			continue
+		}
+		if err := json.Unmarshal(field.raw, field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seconds is a duration in seconds
+type seconds float64
+
+func toSeconds(d time.Duration) seconds {
+	return seconds(d.Seconds())
+}
+
+func (s seconds) duration() time.Duration {
+	return time.Duration(math.Round(float64(s) * float64(time.Second)))
+}
+
+func durationsToSeconds(durations []time.Duration) []seconds {
+	values := make([]seconds, 0, len(durations))
+	for _, d := range durations {
+		values = append(values, toSeconds(d))
+	}
+	return values
+}
+
+// exactJSON is the object form of an exact or one of match
+type exactJSON struct {
+	Exact interface{} `json:"exact"`
+}
+
+// idealJSON is the object form of an ideal value
+type idealJSON struct {
+	Ideal interface{} `json:"ideal"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i Int) MarshalJSON() ([]byte, error) { return json.Marshal(idealJSON{int(i)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (i IntExact) MarshalJSON() ([]byte, error) { return json.Marshal(exactJSON{int(i)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (i IntOneOf) MarshalJSON() ([]byte, error) { return json.Marshal(exactJSON{[]int(i)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (i IntIdealOneOf) MarshalJSON() ([]byte, error) { return json.Marshal(idealJSON{[]int(i)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (i IntRanged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Min   int `json:"min,omitempty"`
+		Max   int `json:"max,omitempty"`
+		Ideal int `json:"ideal,omitempty"`
+	}{i.Min, i.Max, i.Ideal})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f Float) MarshalJSON() ([]byte, error) { return json.Marshal(idealJSON{float32(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f FloatExact) MarshalJSON() ([]byte, error) { return json.Marshal(exactJSON{float32(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f FloatOneOf) MarshalJSON() ([]byte, error) { return json.Marshal(exactJSON{[]float32(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f FloatIdealOneOf) MarshalJSON() ([]byte, error) { return json.Marshal(idealJSON{[]float32(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f FloatRanged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Min   float32 `json:"min,omitempty"`
+		Max   float32 `json:"max,omitempty"`
+		Ideal float32 `json:"ideal,omitempty"`
+	}{f.Min, f.Max, f.Ideal})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(idealJSON{toSeconds(time.Duration(d))})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DurationExact) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exactJSON{toSeconds(time.Duration(d))})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DurationOneOf) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exactJSON{durationsToSeconds(d)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DurationIdealOneOf) MarshalJSON() ([]byte, error) {
+	return json.Marshal(idealJSON{durationsToSeconds(d)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DurationRanged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Min   seconds `json:"min,omitempty"`
+		Max   seconds `json:"max,omitempty"`
+		Ideal seconds `json:"ideal,omitempty"`
+	}{toSeconds(d.Min), toSeconds(d.Max), toSeconds(d.Ideal)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f FrameFormat) MarshalJSON() ([]byte, error) { return json.Marshal(idealJSON{frame.Format(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f FrameFormatExact) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exactJSON{frame.Format(f)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f FrameFormatOneOf) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exactJSON{[]frame.Format(f)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f FrameFormatIdealOneOf) MarshalJSON() ([]byte, error) {
+	return json.Marshal(idealJSON{[]frame.Format(f)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f String) MarshalJSON() ([]byte, error) { return json.Marshal(idealJSON{string(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f StringExact) MarshalJSON() ([]byte, error) { return json.Marshal(exactJSON{string(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f StringOneOf) MarshalJSON() ([]byte, error) { return json.Marshal(exactJSON{[]string(f)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (f StringIdealOneOf) MarshalJSON() ([]byte, error) {
+	return json.Marshal(idealJSON{[]string(f)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b Bool) MarshalJSON() ([]byte, error) { return json.Marshal(idealJSON{bool(b)}) }
+
+// MarshalJSON implements json.Marshaler.
+func (b BoolExact) MarshalJSON() ([]byte, error) { return json.Marshal(exactJSON{bool(b)}) }
+
+// unmarshalIntConstraint parses an IntConstraint from JSON. If b is null or an empty object,
+// nil is returned.
+func unmarshalIntConstraint(b []byte) (IntConstraint, error) {
+	c, ok, err := parseConstraint(b)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	if values, exact, ok := c.oneOf(); ok {
+		var v []int
+		if err := json.Unmarshal(values, &v); err != nil {
+			return nil, err
+		}
+		if !exact {
+			return IntIdealOneOf(v), nil
+		}
+		return IntOneOf(v), nil
+	}
+
+	var v IntRanged
+	switch {
+	case c.Exact != nil:
+		var exact int
+		if err := json.Unmarshal(c.Exact, &exact); err != nil {
+			return nil, err
+		}
+		return IntExact(exact), nil
+	case c.isRange():
+		if err := c.decodeRange(&v.Min, &v.Max, &v.Ideal); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		if err := json.Unmarshal(c.Ideal, &v.Ideal); err != nil {
+			return nil, err
+		}
+		return Int(v.Ideal), nil
+	}
+}
+
+// unmarshalFloatConstraint parses a FloatConstraint from JSON. If b is null or an empty object,
+// nil is returned.
+func unmarshalFloatConstraint(b []byte) (FloatConstraint, error) {
+	c, ok, err := parseConstraint(b)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	if values, exact, ok := c.oneOf(); ok {
+		var v []float32
+		if err := json.Unmarshal(values, &v); err != nil {
+			return nil, err
+		}
+		if !exact {
+			return FloatIdealOneOf(v), nil
+		}
+		return FloatOneOf(v), nil
+	}
+
+	var v FloatRanged
+	switch {
+	case c.Exact != nil:
+		var exact float32
+		if err := json.Unmarshal(c.Exact, &exact); err != nil {
+			return nil, err
+		}
+		return FloatExact(exact), nil
+	case c.isRange():
+		if err := c.decodeRange(&v.Min, &v.Max, &v.Ideal); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		if err := json.Unmarshal(c.Ideal, &v.Ideal); err != nil {
+			return nil, err
+		}
+		return Float(v.Ideal), nil
+	}
+}
+
+// unmarshalDurationConstraint parses a DurationConstraint in seconds from JSON. If b is null or
+// an empty object, nil is returned.
+func unmarshalDurationConstraint(b []byte) (DurationConstraint, error) {
+	c, ok, err := parseConstraint(b)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	if values, exact, ok := c.oneOf(); ok {
+		var v []seconds
+		if err := json.Unmarshal(values, &v); err != nil {
+			return nil, err
+		}
+		durations := make([]time.Duration, 0, len(v))
+		for _, s := range v {
+			durations = append(durations, s.duration())
+		}
+		if !exact {
+			return DurationIdealOneOf(durations), nil
+		}
+		return DurationOneOf(durations), nil
+	}
+
+	var min, max, ideal seconds
+	switch {
+	case c.Exact != nil:
+		var exact seconds
+		if err := json.Unmarshal(c.Exact, &exact); err != nil {
+			return nil, err
+		}
+		return DurationExact(exact.duration()), nil
+	case c.isRange():
+		if err := c.decodeRange(&min, &max, &ideal); err != nil {
+			return nil, err
+		}
+		return DurationRanged{Min: min.duration(), Max: max.duration(), Ideal: ideal.duration()}, nil
+	default:
+		if err := json.Unmarshal(c.Ideal, &ideal); err != nil {
+			return nil, err
+		}
+		return Duration(ideal.duration()), nil
+	}
+}
+
+// unmarshalFrameFormatConstraint parses a FrameFormatConstraint from JSON. If b is null or
+// an empty object, nil is returned.
+func unmarshalFrameFormatConstraint(b []byte) (FrameFormatConstraint, error) {
+	c, ok, err := parseConstraint(b)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	if values, exact, ok := c.oneOf(); ok {
+		var v []frame.Format
+		if err := json.Unmarshal(values, &v); err != nil {
+			return nil, err
+		}
+		if !exact {
+			return FrameFormatIdealOneOf(v), nil
+		}
+		return FrameFormatOneOf(v), nil
+	}
+
+	var v frame.Format
+	switch {
+	case c.isRange():
+		return nil, errRangeUnsupported
+	case c.Exact != nil:
+		if err := json.Unmarshal(c.Exact, &v); err != nil {
+			return nil, err
+		}
+		return FrameFormatExact(v), nil
+	default:
+		if err := json.Unmarshal(c.Ideal, &v); err != nil {
+			return nil, err
+		}
+		return FrameFormat(v), nil
+	}
+}
+
+// unmarshalStringConstraint parses a StringConstraint from JSON. If b is null or an empty object,
+// nil is returned.
+func unmarshalStringConstraint(b []byte) (StringConstraint, error) {
+	c, ok, err := parseConstraint(b)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	if values, exact, ok := c.oneOf(); ok {
+		var v []string
+		if err := json.Unmarshal(values, &v); err != nil {
+			return nil, err
+		}
+		if !exact {
+			return StringIdealOneOf(v), nil
+		}
+		return StringOneOf(v), nil
+	}
+
+	var v string
+	switch {
+	case c.isRange():
+		return nil, errRangeUnsupported
+	case c.Exact != nil:
+		if err := json.Unmarshal(c.Exact, &v); err != nil {
+			return nil, err
+		}
+		return StringExact(v), nil
+	default:
+		if err := json.Unmarshal(c.Ideal, &v); err != nil {
+			return nil, err
+		}
+		return String(v), nil
+	}
+}
+
+// unmarshalBoolConstraint parses a BoolConstraint from JSON. If b is null or an empty object,
+// nil is returned.
+func unmarshalBoolConstraint(b []byte) (BoolConstraint, error) {
+	c, ok, err := parseConstraint(b)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var v bool
+	switch {
+	case c.isRange():
+		return nil, errRangeUnsupported
+	case c.Exact != nil:
+		if err := json.Unmarshal(c.Exact, &v); err != nil {
+			return nil, err
+		}
+		return BoolExact(v), nil
+	default:
+		if err := json.Unmarshal(c.Ideal, &v); err != nil {
+			return nil, err
+		}
+		return Bool(v), nil
+	}
+}
+
+// MarshalJSON implements json.Marshaler. The constraints that are nil are omitted.
+func (m MediaConstraints) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{})
+	add := func(name string, c interface{}) {
+		if c != nil {
+			fields[name] = c
+		}
+	}
+
+	add("deviceId", m.DeviceID)
+
+	add("width", m.Width)
+	add("height", m.Height)
+	add("frameRate", m.FrameRate)
+	add("frameFormat", m.FrameFormat)
+	if m.DiscardFramesOlderThan != 0 {
+		fields["discardFramesOlderThan"] = toSeconds(m.DiscardFramesOlderThan)
+	}
+	add("aspectRatio", m.AspectRatio)
+	add("resizeMode", m.ResizeMode)
+
+	add("channelCount", m.ChannelCount)
+	add("latency", m.Latency)
+	add("sampleRate", m.SampleRate)
+	add("sampleSize", m.SampleSize)
+	add("isBigEndian", m.IsBigEndian)
+	add("isFloat", m.IsFloat)
+	add("isInterleaved", m.IsInterleaved)
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The constraints that are unknown are ignored, as the
+// browsers do, so the constraints for the browsers can be reused.
+func (m *MediaConstraints) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	var c MediaConstraints
+	for name, raw := range fields {
+		var err error
+		switch name {
+		case "deviceId":
+			c.DeviceID, err = unmarshalStringConstraint(raw)
+
+		case "width":
+			c.Width, err = unmarshalIntConstraint(raw)
+		case "height":
+			c.Height, err = unmarshalIntConstraint(raw)
+		case "frameRate":
+			c.FrameRate, err = unmarshalFloatConstraint(raw)
+		case "frameFormat":
+			c.FrameFormat, err = unmarshalFrameFormatConstraint(raw)
+		case "discardFramesOlderThan":
+			var s seconds
+			err = json.Unmarshal(raw, &s)
+			c.DiscardFramesOlderThan = s.duration()
+		case "aspectRatio":
+			c.AspectRatio, err = unmarshalFloatConstraint(raw)
+		case "resizeMode":
+			c.ResizeMode, err = unmarshalStringConstraint(raw)
+
+		case "channelCount":
+			c.ChannelCount, err = unmarshalIntConstraint(raw)
+		case "latency":
+			c.Latency, err = unmarshalDurationConstraint(raw)
+		case "sampleRate":
+			c.SampleRate, err = unmarshalIntConstraint(raw)
+		case "sampleSize":
+			c.SampleSize, err = unmarshalIntConstraint(raw)
+		case "isBigEndian":
+			c.IsBigEndian, err = unmarshalBoolConstraint(raw)
+		case "isFloat":
+			c.IsFloat, err = unmarshalBoolConstraint(raw)
+		case "isInterleaved":
+			c.IsInterleaved, err = unmarshalBoolConstraint(raw)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	*m = c
+	return nil
+}
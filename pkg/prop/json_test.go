@@ -0,0 +1,196 @@
+package prop
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/frame"
+)
+
+func TestMediaConstraintsJSONRoundTrip(t *testing.T) {
+	cases := map[string]MediaConstraints{
+		"Empty": {},
+		"Ideal": {
+			DeviceID: String("camera"),
+			VideoConstraints: VideoConstraints{
+				Width:       Int(1280),
+				FrameRate:   Float(29.97),
+				FrameFormat: FrameFormat(frame.FormatI420),
+				AspectRatio: Float(1.5),
+				ResizeMode:  String(ResizeModeNone),
+			},
+			AudioConstraints: AudioConstraints{
+				Latency: Duration(20 * time.Millisecond),
+				IsFloat: Bool(true),
+			},
+		},
+		"Exact": {
+			DeviceID: StringExact("camera"),
+			VideoConstraints: VideoConstraints{
+				Width:       IntExact(1280),
+				FrameRate:   FloatExact(30),
+				FrameFormat: FrameFormatExact(frame.FormatYUYV),
+			},
+			AudioConstraints: AudioConstraints{
+				Latency:     DurationExact(20 * time.Millisecond),
+				IsBigEndian: BoolExact(false),
+			},
+		},
+		"OneOf": {
+			DeviceID: StringOneOf{"camera", "microphone"},
+			VideoConstraints: VideoConstraints{
+				Height:      IntOneOf{480, 720},
+				FrameRate:   FloatOneOf{15, 30},
+				FrameFormat: FrameFormatOneOf{frame.FormatI420, frame.FormatNV12},
+			},
+			AudioConstraints: AudioConstraints{
+				Latency: DurationOneOf{10 * time.Millisecond, 20 * time.Millisecond},
+			},
+		},
+		"IdealOneOf": {
+			DeviceID: StringIdealOneOf{"camera", "microphone"},
+			VideoConstraints: VideoConstraints{
+				Height:      IntIdealOneOf{480, 720},
+				FrameRate:   FloatIdealOneOf{15, 30},
+				FrameFormat: FrameFormatIdealOneOf{frame.FormatI420, frame.FormatNV12},
+			},
+			AudioConstraints: AudioConstraints{
+				Latency: DurationIdealOneOf{10 * time.Millisecond, 20 * time.Millisecond},
+			},
+		},
+		"Ranged": {
+			VideoConstraints: VideoConstraints{
+				Width:                  IntRanged{Min: 640, Max: 1920, Ideal: 1280},
+				FrameRate:              FloatRanged{Max: 30},
+				DiscardFramesOlderThan: 500 * time.Millisecond,
+			},
+			AudioConstraints: AudioConstraints{
+				SampleRate: IntRanged{Min: 44100},
+				Latency:    DurationRanged{Min: 10 * time.Millisecond, Max: time.Second},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			b, err := json.Marshal(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded MediaConstraints
+			if err := json.Unmarshal(b, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c, decoded) {
+				t.Errorf("Expected\n%s\n\ngot\n%s\n\nfrom %s", &c, &decoded, b)
+			}
+		})
+	}
+}
+
+func TestMediaConstraintsUnmarshalJSON(t *testing.T) {
+	cases := map[string]struct {
+		json     string
+		expected MediaConstraints
+	}{
+		"Browser": {
+			json: `{"width": {"ideal": 1280}, "height": 720, "frameRate": {"max": 30}, "deviceId": {"exact": "camera"}}`,
+			expected: MediaConstraints{
+				DeviceID: StringExact("camera"),
+				VideoConstraints: VideoConstraints{
+					Width:     Int(1280),
+					Height:    Int(720),
+					FrameRate: FloatRanged{Max: 30},
+				},
+			},
+		},
+		"BareList": {
+			json: `{"deviceId": ["a", "b"]}`,
+			expected: MediaConstraints{
+				DeviceID: StringIdealOneOf{"a", "b"},
+			},
+		},
+		"IdealList": {
+			json: `{"deviceId": {"ideal": ["a", "b"]}, "width": {"exact": [640, 1280]}}`,
+			expected: MediaConstraints{
+				DeviceID: StringIdealOneOf{"a", "b"},
+				VideoConstraints: VideoConstraints{
+					Width: IntOneOf{640, 1280},
+				},
+			},
+		},
+		"LatencyInSeconds": {
+			json: `{"latency": {"max": 0.02}}`,
+			expected: MediaConstraints{
+				AudioConstraints: AudioConstraints{Latency: DurationRanged{Max: 20 * time.Millisecond}},
+			},
+		},
+		"NoConstraint": {
+			json:     `{"width": null, "height": {}}`,
+			expected: MediaConstraints{},
+		},
+		"UnknownConstraint": {
+			json:     `{"facingMode": "user"}`,
+			expected: MediaConstraints{},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			var decoded MediaConstraints
+			if err := json.Unmarshal([]byte(c.json), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c.expected, decoded) {
+				t.Errorf("Expected\n%s\n\ngot\n%s", &c.expected, &decoded)
+			}
+		})
+	}
+}
+
+func TestMediaConstraintsUnmarshalJSONError(t *testing.T) {
+	cases := map[string]string{
+		"WrongType":        `{"width": "large"}`,
+		"RangeUnsupported": `{"isFloat": {"min": true}}`,
+		"NotAnObject":      `[]`,
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			var decoded MediaConstraints
+			if err := json.Unmarshal([]byte(c), &decoded); err == nil {
+				t.Errorf("Expected an error, got %s", &decoded)
+			}
+		})
+	}
+}
+
+func TestRangedWithoutBoundsJSON(t *testing.T) {
+	// The zero bounds aren't specified, so the ranges without any bound are serialized to {}, which
+	// means no constraint, and parsed back to nil. Both of them accept any value.
+	c := MediaConstraints{
+		VideoConstraints: VideoConstraints{Width: IntRanged{}, FrameRate: FloatRanged{}},
+		AudioConstraints: AudioConstraints{Latency: DurationRanged{}},
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"frameRate":{},"latency":{},"width":{}}`; string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	var decoded MediaConstraints
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, MediaConstraints{}) {
+		t.Errorf("Expected no constraint, got %s", &decoded)
+	}
+}
@@ -140,36 +140,81 @@ func (p *Media) MergeConstraints(o MediaConstraints) {
 // FitnessDistance calculates fitness of media property and media constraints.
 // If no media satisfies given constraints, second return value will be false.
 func (p *MediaConstraints) FitnessDistance(o Media) (float64, bool) {
-	cmps := comparisons{}
-	cmps.add(p.DeviceID, o.DeviceID)
-	cmps.add(p.Width, o.Width)
-	cmps.add(p.Height, o.Height)
-	cmps.add(p.FrameFormat, o.FrameFormat)
-	// The next line is comment out for now to not include framerate in the fitness function.
-	// As camera.Properties does not have access to the list of available framerate at the moment,
-	// no driver can be matched with a framerate constraint.
-	// Note this also affect screen caputre as screen.Properties does not fill in the Framerate field.
-	// cmps.add(p.FrameRate, o.FrameRate)
-	cmps.add(p.SampleRate, o.SampleRate)
-	cmps.add(p.Latency, o.Latency)
-	cmps.add(p.ChannelCount, o.ChannelCount)
-	cmps.add(p.IsBigEndian, o.IsBigEndian)
-	cmps.add(p.IsFloat, o.IsFloat)
-	cmps.add(p.IsInterleaved, o.IsInterleaved)
+	cmps := p.comparisons(o)
 	return cmps.fitnessDistance()
 }
 
-type comparisons []struct {
+// ConstraintResult is the result of comparing a property with its constraint
+type ConstraintResult struct {
+	// Name is the name of the property, e.g. "Width"
+	Name string
+	// Constraint is the constraint of the property, e.g. IntExact(1920)
+	Constraint interface{}
+	// Actual is the value of the property, e.g. 1280
+	Actual interface{}
+	// Distance is the fitness distance between the property and the constraint
+	Distance float64
+	// Satisfied tells if the property satisfies the constraint
+	Satisfied bool
+}
+
+func (r ConstraintResult) String() string {
+	return fmt.Sprintf("%s %v vs %v", r.Name, r.Actual, r.Constraint)
+}
+
+// Compare compares each of the properties of o with its constraint. Unlike FitnessDistance, all the
+// constraints are compared even if some of them aren't satisfied, so the results tell why o doesn't fit.
+func (p *MediaConstraints) Compare(o Media) []ConstraintResult {
+	cmps := p.comparisons(o)
+	results := make([]ConstraintResult, 0, len(cmps))
+	for _, field := range cmps {
+		d, ok := compare(field.desired, field.actual)
+		results = append(results, ConstraintResult{
+			Name:       field.name,
+			Constraint: field.desired,
+			Actual:     field.actual,
+			Distance:   d,
+			Satisfied:  ok,
+		})
+	}
+	return results
+}
+
+func (p *MediaConstraints) comparisons(o Media) comparisons {
+	cmps := comparisons{}
+	cmps.add("DeviceID", p.DeviceID, o.DeviceID)
+	cmps.add("Width", p.Width, o.Width)
+	cmps.add("Height", p.Height, o.Height)
+	cmps.add("FrameFormat", p.FrameFormat, o.FrameFormat)
+	// The frame rate is only compared when the driver reports it, since some of the drivers, e.g. the
+	// cameras on macOS and Windows, can't enumerate their supported frame rates.
+	if o.FrameRate != 0 {
+		cmps.add("FrameRate", p.FrameRate, o.FrameRate)
+	}
+	// The aspect ratio is unknown for the properties without the size, e.g. audio
+	if aspectRatio := o.VideoAspectRatio(); aspectRatio != 0 {
+		cmps.add("AspectRatio", p.AspectRatio, aspectRatio)
+	}
+	cmps.add("ResizeMode", p.ResizeMode, o.VideoResizeMode())
+	cmps.add("SampleRate", p.SampleRate, o.SampleRate)
+	cmps.add("Latency", p.Latency, o.Latency)
+	cmps.add("ChannelCount", p.ChannelCount, o.ChannelCount)
+	cmps.add("IsBigEndian", p.IsBigEndian, o.IsBigEndian)
+	cmps.add("IsFloat", p.IsFloat, o.IsFloat)
+	cmps.add("IsInterleaved", p.IsInterleaved, o.IsInterleaved)
+	return cmps
+}
+
+type comparison struct {
+	name            string
 	desired, actual interface{}
 }
 
-func (c *comparisons) add(desired, actual interface{}) {
+type comparisons []comparison
+
+func (c *comparisons) add(name string, desired, actual interface{}) {
 	if desired != nil {
-		*c = append(*c,
-			struct{ desired, actual interface{} }{
-				desired, actual,
-			},
-		)
+		*c = append(*c, comparison{name, desired, actual})
 	}
 }
 
@@ -177,48 +222,7 @@ func (c *comparisons) add(desired, actual interface{}) {
 func (c *comparisons) fitnessDistance() (float64, bool) {
 	var dist float64
 	for _, field := range *c {
-		var d float64
-		var ok bool
-		switch c := field.desired.(type) {
-		case IntConstraint:
-			if actual, typeOK := field.actual.(int); typeOK {
-				d, ok = c.Compare(actual)
-			} else {
-				panic("wrong type of actual value")
-			}
-		case FloatConstraint:
-			if actual, typeOK := field.actual.(float32); typeOK {
-				d, ok = c.Compare(actual)
-			} else {
-				panic("wrong type of actual value")
-			}
-		case DurationConstraint:
-			if actual, typeOK := field.actual.(time.Duration); typeOK {
-				d, ok = c.Compare(actual)
-			} else {
-				panic("wrong type of actual value")
-			}
-		case FrameFormatConstraint:
-			if actual, typeOK := field.actual.(frame.Format); typeOK {
-				d, ok = c.Compare(actual)
-			} else {
-				panic("wrong type of actual value")
-			}
-		case StringConstraint:
-			if actual, typeOK := field.actual.(string); typeOK {
-				d, ok = c.Compare(actual)
-			} else {
-				panic("wrong type of actual value")
-			}
-		case BoolConstraint:
-			if actual, typeOK := field.actual.(bool); typeOK {
-				d, ok = c.Compare(actual)
-			} else {
-				panic("wrong type of actual value")
-			}
-		default:
-			panic("unsupported constraint type")
-		}
+		d, ok := compare(field.desired, field.actual)
 		dist += d
 		if !ok {
 			return 0, false
@@ -227,12 +231,55 @@ func (c *comparisons) fitnessDistance() (float64, bool) {
 	return dist, true
 }
 
+// compare compares actual with the desired constraint
+func compare(desired, actual interface{}) (float64, bool) {
+	switch c := desired.(type) {
+	case IntConstraint:
+		if actual, typeOK := actual.(int); typeOK {
+			return c.Compare(actual)
+		}
+		panic("wrong type of actual value")
+	case FloatConstraint:
+		if actual, typeOK := actual.(float32); typeOK {
+			return c.Compare(actual)
+		}
+		panic("wrong type of actual value")
+	case DurationConstraint:
+		if actual, typeOK := actual.(time.Duration); typeOK {
+			return c.Compare(actual)
+		}
+		panic("wrong type of actual value")
+	case FrameFormatConstraint:
+		if actual, typeOK := actual.(frame.Format); typeOK {
+			return c.Compare(actual)
+		}
+		panic("wrong type of actual value")
+	case StringConstraint:
+		if actual, typeOK := actual.(string); typeOK {
+			return c.Compare(actual)
+		}
+		panic("wrong type of actual value")
+	case BoolConstraint:
+		if actual, typeOK := actual.(bool); typeOK {
+			return c.Compare(actual)
+		}
+		panic("wrong type of actual value")
+	default:
+		panic("unsupported constraint type")
+	}
+}
+
 // VideoConstraints represents a video's constraints
 type VideoConstraints struct {
 	Width, Height          IntConstraint
 	FrameRate              FloatConstraint
 	FrameFormat            FrameFormatConstraint
 	DiscardFramesOlderThan time.Duration
+	// AspectRatio constrains the width divided by the height
+	AspectRatio FloatConstraint
+	// ResizeMode constrains whether the frames may be cropped and scaled from a native size of
+	// the device. It's either ResizeModeNone or ResizeModeCropAndScale.
+	ResizeMode StringConstraint
 }
 
 // Video represents a video's constraints
@@ -241,6 +288,39 @@ type Video struct {
 	FrameRate              float32
 	FrameFormat            frame.Format
 	DiscardFramesOlderThan time.Duration
+	// AspectRatio is the width divided by the height. If it's zero, it's derived from Width and Height.
+	AspectRatio float32
+	// ResizeMode tells whether the frames are cropped and scaled from a native size of the device.
+	// An empty ResizeMode is ResizeModeNone.
+	ResizeMode string
+}
+
+// Reference: https://w3c.github.io/mediacapture-main/#dom-videoresizemodeenum
+const (
+	// ResizeModeNone means the frames are in a native size of the device
+	ResizeModeNone = "none"
+	// ResizeModeCropAndScale means the frames may be cropped and downscaled from a native size of the device
+	ResizeModeCropAndScale = "crop-and-scale"
+)
+
+// VideoAspectRatio returns the aspect ratio of v, which is derived from the width and the height
+// unless AspectRatio is set. It's zero if the size is unknown.
+func (v *Video) VideoAspectRatio() float32 {
+	if v.AspectRatio != 0 {
+		return v.AspectRatio
+	}
+	if v.Width <= 0 || v.Height <= 0 {
+		return 0
+	}
+	return float32(v.Width) / float32(v.Height)
+}
+
+// VideoResizeMode returns the resize mode of v, where an empty ResizeMode is ResizeModeNone
+func (v *Video) VideoResizeMode() string {
+	if v.ResizeMode == "" {
+		return ResizeModeNone
+	}
+	return v.ResizeMode
 }
 
 // AudioConstraints represents an audio's constraints
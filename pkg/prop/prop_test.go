@@ -103,6 +103,15 @@ func TestCompareMatch(t *testing.T) {
 			}},
 			false,
 		},
+		"IntIdealOneOfUnmatch": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				Width: IntIdealOneOf{640, 1280},
+			}},
+			Media{Video: Video{
+				Width: 320,
+			}},
+			true,
+		},
 		"DurationExactUnmatch": {
 			MediaConstraints{AudioConstraints: AudioConstraints{
 				Latency: DurationExact(time.Second),
@@ -148,6 +157,57 @@ func TestCompareMatch(t *testing.T) {
 			}},
 			false,
 		},
+		"FloatExactUnmatch": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				FrameRate: FloatExact(60),
+			}},
+			Media{Video: Video{
+				FrameRate: 30,
+			}},
+			false,
+		},
+		"FloatExactUnknown": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				FrameRate: FloatExact(60),
+			}},
+			Media{Video: Video{}},
+			true,
+		},
+		"AspectRatioDerivedMatch": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				AspectRatio: FloatExact(16.0 / 9),
+			}},
+			Media{Video: Video{Width: 1280, Height: 720}},
+			true,
+		},
+		"AspectRatioReportedMatch": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				AspectRatio: FloatExact(16.0 / 9),
+			}},
+			Media{Video: Video{Width: 854, Height: 480, AspectRatio: 16.0 / 9}},
+			true,
+		},
+		"AspectRatioUnmatch": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				AspectRatio: FloatExact(16.0 / 9),
+			}},
+			Media{Video: Video{Width: 640, Height: 480}},
+			false,
+		},
+		"ResizeModeNoneMatch": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				ResizeMode: StringExact(ResizeModeNone),
+			}},
+			Media{Video: Video{Width: 640, Height: 480}},
+			true,
+		},
+		"ResizeModeNoneUnmatch": {
+			MediaConstraints{VideoConstraints: VideoConstraints{
+				ResizeMode: StringExact(ResizeModeNone),
+			}},
+			Media{Video: Video{Width: 320, Height: 240, ResizeMode: ResizeModeCropAndScale}},
+			false,
+		},
 		"BoolExactMatch": {
 			MediaConstraints{AudioConstraints: AudioConstraints{
 				IsFloat: BoolExact(true),
@@ -366,3 +426,36 @@ func TestString(t *testing.T) {
 		})
 	})
 }
+
+func TestCompareResults(t *testing.T) {
+	constraints := MediaConstraints{VideoConstraints: VideoConstraints{
+		Width:       IntExact(1920),
+		Height:      Int(720),
+		FrameFormat: FrameFormatExact(frame.FormatI420),
+	}}
+	results := constraints.Compare(Media{Video: Video{
+		Width:       1280,
+		Height:      720,
+		FrameFormat: frame.FormatYUY2,
+	}})
+
+	expected := []struct {
+		name      string
+		satisfied bool
+	}{
+		{"Width", false},
+		{"Height", true},
+		{"FrameFormat", false},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %v", len(expected), results)
+	}
+	for i, e := range expected {
+		if results[i].Name != e.name || results[i].Satisfied != e.satisfied {
+			t.Errorf("Expected %s to be satisfied=%v, got %v", e.name, e.satisfied, results[i])
+		}
+	}
+	if s := results[0].String(); s != "Width 1280 vs 1920 (exact)" {
+		t.Errorf("Unexpected description of the result: %s", s)
+	}
+}
@@ -70,3 +70,25 @@ func (StringOneOf) Value() (string, bool) { return "", false }
 func (f StringOneOf) String() string {
 	return fmt.Sprintf("%s (one of values)", strings.Join([]string(f), ","))
 }
+
+// StringIdealOneOf specifies list of ideal string values.
+// Any value may be selected, but the values in the list take priority.
+type StringIdealOneOf []string
+
+// Compare implements StringConstraint.
+func (f StringIdealOneOf) Compare(a string) (float64, bool) {
+	for _, v := range f {
+		if v == a {
+			return 0.0, true
+		}
+	}
+	return 1.0, true
+}
+
+// Value implements StringConstraint.
+func (StringIdealOneOf) Value() (string, bool) { return "", false }
+
+// String implements Stringify
+func (f StringIdealOneOf) String() string {
+	return fmt.Sprintf("%s (one of ideal values)", strings.Join([]string(f), ","))
+}
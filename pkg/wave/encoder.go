@@ -0,0 +1,98 @@
+package wave
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Encoder encodes Audio to raw chunk. It's the reverse of Decoder.
+type Encoder interface {
+	// Encode encodes a in endian byte order, and appends it to chunk
+	Encode(endian binary.ByteOrder, chunk []byte, a Audio) ([]byte, error)
+}
+
+// EncoderFunc is a proxy type for Encoder
+type EncoderFunc func(endian binary.ByteOrder, chunk []byte, a Audio) ([]byte, error)
+
+func (f EncoderFunc) Encode(endian binary.ByteOrder, chunk []byte, a Audio) ([]byte, error) {
+	return f(endian, chunk, a)
+}
+
+// NewEncoder creates an encoder to encode Audio to raw audio data in the given format. The samples
+// are converted to the sample format of format, so Audio in any sample format can be encoded.
+func NewEncoder(format *RawFormat) (Encoder, error) {
+	var put func(endian binary.ByteOrder, b []byte, s Sample)
+	switch {
+	case format.SampleSize == 2 && !format.IsFloat:
+		put = func(endian binary.ByteOrder, b []byte, s Sample) {
+			endian.PutUint16(b, uint16(toInt16(s)))
+		}
+	case format.SampleSize == 4 && format.IsFloat:
+		put = func(endian binary.ByteOrder, b []byte, s Sample) {
+			endian.PutUint32(b, math.Float32bits(toFloat32(s)))
+		}
+	default:
+		return nil, fmt.Errorf("%s format is not supported", format)
+	}
+
+	sampleSize := format.SampleSize
+	return EncoderFunc(func(endian binary.ByteOrder, chunk []byte, a Audio) ([]byte, error) {
+		info := a.ChunkInfo()
+		offset := len(chunk)
+		n := info.Len * info.Channels * sampleSize
+		if cap(chunk)-offset < n {
+			grown := make([]byte, offset, offset+n)
+			copy(grown, chunk)
+			chunk = grown
+		}
+		chunk = chunk[:offset+n]
+
+		for i := 0; i < info.Len; i++ {
+			for ch := 0; ch < info.Channels; ch++ {
+				var flatOffset int
+				if format.Interleaved {
+					flatOffset = (i*info.Channels + ch) * sampleSize
+				} else {
+					flatOffset = (ch*info.Len + i) * sampleSize
+				}
+				put(endian, chunk[offset+flatOffset:], a.At(i, ch))
+			}
+		}
+		return chunk, nil
+	}), nil
+}
+
+// toInt16 converts s to a 16-bits signed integer sample. Float samples are clipped to [-1, 1].
+func toInt16(s Sample) int16 {
+	switch v := s.(type) {
+	case Int16Sample:
+		return int16(v)
+	case Float32Sample:
+		return int16(clip(float32(v)) * math.MaxInt16)
+	default:
+		return int16(s.Int() >> 16)
+	}
+}
+
+// toFloat32 converts s to a 32-bits float sample, which ranges within [-1, 1].
+func toFloat32(s Sample) float32 {
+	switch v := s.(type) {
+	case Float32Sample:
+		return float32(v)
+	case Int16Sample:
+		return float32(v) / -math.MinInt16
+	default:
+		return float32(s.Int()) / 0x80000000
+	}
+}
+
+func clip(v float32) float32 {
+	if v > 1 {
+		return 1
+	}
+	if v < -1 {
+		return -1
+	}
+	return v
+}
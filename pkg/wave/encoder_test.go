@@ -0,0 +1,99 @@
+package wave
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	testCases := map[string]struct {
+		format   *RawFormat
+		audio    Audio
+		expected Audio
+	}{
+		"Int16Interleaved": {
+			format: &RawFormat{SampleSize: 2, IsFloat: false, Interleaved: true},
+			audio: &Int16Interleaved{
+				Data: []int16{1, -2, 3, -4},
+				Size: ChunkInfo{Len: 2, Channels: 2},
+			},
+			expected: &Int16Interleaved{
+				Data: []int16{1, -2, 3, -4},
+				Size: ChunkInfo{Len: 2, Channels: 2},
+			},
+		},
+		"Int16NonInterleaved": {
+			format: &RawFormat{SampleSize: 2, IsFloat: false, Interleaved: false},
+			audio: &Int16Interleaved{
+				Data: []int16{1, -2, 3, -4},
+				Size: ChunkInfo{Len: 2, Channels: 2},
+			},
+			expected: &Int16NonInterleaved{
+				Data: [][]int16{{1, 3}, {-2, -4}},
+				Size: ChunkInfo{Len: 2, Channels: 2},
+			},
+		},
+		"Float32ToInt16": {
+			format: &RawFormat{SampleSize: 2, IsFloat: false, Interleaved: true},
+			audio: &Float32Interleaved{
+				Data: []float32{1, -1, 0.5, 2},
+				Size: ChunkInfo{Len: 4, Channels: 1},
+			},
+			expected: &Int16Interleaved{
+				Data: []int16{32767, -32767, 16383, 32767},
+				Size: ChunkInfo{Len: 4, Channels: 1},
+			},
+		},
+		"Int16ToFloat32": {
+			format: &RawFormat{SampleSize: 4, IsFloat: true, Interleaved: true},
+			audio: &Int16Interleaved{
+				Data: []int16{16384, -32768},
+				Size: ChunkInfo{Len: 2, Channels: 1},
+			},
+			expected: &Float32Interleaved{
+				Data: []float32{0.5, -1},
+				Size: ChunkInfo{Len: 2, Channels: 1},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			encoder, err := NewEncoder(testCase.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoder, err := NewDecoder(testCase.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, endian := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+				prefix := []byte{0xff}
+				chunk, err := encoder.Encode(endian, prefix, testCase.audio)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if chunk[0] != 0xff {
+					t.Fatal("Expected the encoded audio to be appended to the chunk")
+				}
+
+				decoded, err := decoder.Decode(endian, chunk[1:], testCase.audio.ChunkInfo().Channels)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(testCase.expected, decoded) {
+					t.Errorf("Expected %v, got %v", testCase.expected, decoded)
+				}
+			}
+		})
+	}
+}
+
+func TestEncoderUnsupportedFormat(t *testing.T) {
+	if _, err := NewEncoder(&RawFormat{SampleSize: 3}); err == nil {
+		t.Error("Expected an error of the unsupported format")
+	}
+}
@@ -0,0 +1,156 @@
+package mediadevices
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+// defaultProbeTimeout is the time limit to open and query each driver when MediaStreamConstraints.ProbeTimeout is zero
+const defaultProbeTimeout = 5 * time.Second
+
+// DriverError describes a driver that has failed to be opened or queried while selecting the drivers
+type DriverError struct {
+	DeviceID string
+	Label    string
+	Err      error
+}
+
+func (e *DriverError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Label, e.DeviceID, e.Err)
+}
+
+func (e *DriverError) Unwrap() error {
+	return e.Err
+}
+
+// ProbeError is returned when none of the drivers fits the constraints, and some of the drivers
+// have failed to be opened or queried, e.g. a device has hung or a remote device is unreachable.
+type ProbeError struct {
+	// Err is the reason that the selection has failed
+	Err error
+	// Drivers are the drivers that have failed
+	Drivers []*DriverError
+}
+
+func (e *ProbeError) Error() string {
+	drivers := make([]string, 0, len(e.Drivers))
+	for _, d := range e.Drivers {
+		drivers = append(drivers, d.Error())
+	}
+	return fmt.Sprintf("%v, and %d driver(s) have failed: %s", e.Err, len(e.Drivers), strings.Join(drivers, "; "))
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// probeDrivers opens the closed drivers and queries their properties in parallel. Each driver is
+// given timeout, and the drivers that haven't finished by then are reported as DriverErrors.
+// The drivers that have been opened by probeDrivers are closed once they have been queried,
+// even if they have been abandoned.
+func probeDrivers(ctx context.Context, drivers []driver.Driver, timeout time.Duration) (map[driver.Driver][]prop.Media, []*DriverError) {
+	type result struct {
+		props []prop.Media
+		err   error
+	}
+
+	results := make([]result, len(drivers))
+	var wg sync.WaitGroup
+	for i, d := range drivers {
+		wg.Add(1)
+		go func(i int, d driver.Driver) {
+			defer wg.Done()
+			props, err := probeDriver(ctx, d, timeout)
+			results[i] = result{props: props, err: err}
+		}(i, d)
+	}
+	wg.Wait()
+
+	m := make(map[driver.Driver][]prop.Media)
+	var errs []*DriverError
+	for i, d := range drivers {
+		if err := results[i].err; err != nil {
+			errs = append(errs, &DriverError{DeviceID: d.ID(), Label: d.Info().Label, Err: err})
+			continue
+		}
+		m[d] = results[i].props
+	}
+
+	return m, errs
+}
+
+// lockDriver locks d if it implements driver.LockerContext, and returns the function to unlock it.
+// Opening and probing each driver is serialized by the lock, so a probe that has been abandoned can't
+// close the driver after another caller has opened it. It returns ctx.Err() if ctx is done before d
+// has been locked.
+func lockDriver(ctx context.Context, d driver.Driver) (func(), error) {
+	if locker, ok := d.(driver.LockerContext); ok {
+		return locker.LockContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return func() {}, nil
+}
+
+// openDriver opens d with ctx if d implements driver.OpenerContext
+func openDriver(ctx context.Context, d driver.Driver) error {
+	if opener, ok := d.(driver.OpenerContext); ok {
+		return opener.OpenContext(ctx)
+	}
+	return d.Open()
+}
+
+func probeDriver(ctx context.Context, d driver.Driver, timeout time.Duration) ([]prop.Media, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		props []prop.Media
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		unlock, err := lockDriver(ctx, d)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		// The lock is held until the driver opened by this probe has been closed, even if the probe
+		// has been abandoned
+		defer unlock()
+
+		if d.Status() != driver.StateClosed {
+			done <- result{props: d.Properties()}
+			return
+		}
+
+		if err := openDriver(ctx, d); err != nil {
+			done <- result{err: err}
+			return
+		}
+		props := d.Properties()
+		// Since it was closed, we should close it to avoid a leak. Nobody else can open the driver
+		// while it's locked, so it's only closed if it's still opened by this probe, and not e.g.
+		// closed by Manager.Unregister in the meantime. The driver has to be closed before the result
+		// is sent, so it can be opened again right away.
+		if d.Status() == driver.StateOpened {
+			d.Close()
+		}
+		done <- result{props: props}
+	}()
+
+	select {
+	case r := <-done:
+		return r.props, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
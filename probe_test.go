@@ -0,0 +1,99 @@
+package mediadevices
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+type fakeDriver struct {
+	id      string
+	openErr error
+	hang    chan struct{}
+	state   driver.State
+}
+
+func (d *fakeDriver) Open() error {
+	return d.OpenContext(context.Background())
+}
+
+func (d *fakeDriver) OpenContext(ctx context.Context) error {
+	if d.hang != nil {
+		<-d.hang
+	}
+	if d.openErr != nil {
+		return d.openErr
+	}
+	d.state = driver.StateOpened
+	return nil
+}
+
+func (d *fakeDriver) Close() error {
+	d.state = driver.StateClosed
+	return nil
+}
+
+func (d *fakeDriver) Properties() []prop.Media {
+	return []prop.Media{{DeviceID: d.id}}
+}
+
+func (d *fakeDriver) ID() string           { return d.id }
+func (d *fakeDriver) Info() driver.Info    { return driver.Info{Label: d.id} }
+func (d *fakeDriver) Status() driver.State { return d.state }
+
+func TestProbeDrivers(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	working := &fakeDriver{id: "working", state: driver.StateClosed}
+	drivers := []driver.Driver{
+		working,
+		&fakeDriver{id: "hanging", hang: hang, state: driver.StateClosed},
+		&fakeDriver{id: "broken", openErr: errExpected, state: driver.StateClosed},
+	}
+
+	start := time.Now()
+	props, errs := probeDrivers(context.Background(), drivers, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the hanging driver to time out, but it took %v", elapsed)
+	}
+
+	if len(props) != 1 || len(props[working]) != 1 {
+		t.Errorf("Expected the properties of the working driver only, got %v", props)
+	}
+	if working.Status() != driver.StateClosed {
+		t.Error("Expected the working driver to be closed after the probe")
+	}
+
+	expected := map[string]error{"hanging": context.DeadlineExceeded, "broken": errExpected}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d driver errors, got %v", len(expected), errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, expected[err.DeviceID]) {
+			t.Errorf("Expected %s to fail with %v, got %v", err.DeviceID, expected[err.DeviceID], err.Err)
+		}
+	}
+
+	probeErr := &ProbeError{Err: errNotFound, Drivers: errs}
+	if !errors.Is(probeErr, errNotFound) {
+		t.Errorf("Expected the probe error to wrap %v", errNotFound)
+	}
+}
+
+func TestProbeDriversCanceled(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, errs := probeDrivers(ctx, []driver.Driver{&fakeDriver{id: "hanging", hang: hang, state: driver.StateClosed}}, time.Minute)
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("Expected the probe to be canceled, got %v", errs)
+	}
+}
@@ -0,0 +1,142 @@
+package mediadevices
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/pion/mediadevices/pkg/codec"
+)
+
+var (
+	// errInputChanged is returned by the encoder input to let restartableEncoder know that the encoder
+	// has to be rebuilt for the new input properties
+	errInputChanged            = errors.New("encoder input properties have changed")
+	errKeyFrameControlDisabled = errors.New("encoder doesn't support forcing key frames")
+	errBitRateControlDisabled  = errors.New("encoder doesn't support changing bit rate")
+)
+
+// restartableEncoder rebuilds the encoder when the input properties have changed, e.g. the resolution
+// has been changed by ApplyConstraints, so that the readers don't need to be recreated. The controller
+// returned by Controller stays valid across restarts.
+type restartableEncoder struct {
+	mu      sync.Mutex
+	encoder codec.ReadCloser
+	build   func() (codec.ReadCloser, error)
+	closed  bool
+	// bitRate is the latest bit rate that has been set through the controller. It's applied to
+	// the rebuilt encoders.
+	bitRate int
+}
+
+func newRestartableEncoder(encoder codec.ReadCloser, build func() (codec.ReadCloser, error)) *restartableEncoder {
+	return &restartableEncoder{encoder: encoder, build: build}
+}
+
+func (e *restartableEncoder) current() codec.ReadCloser {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.encoder
+}
+
+func (e *restartableEncoder) Read() ([]byte, func(), error) {
+	encoder := e.current()
+	data, release, err := encoder.Read()
+	if !errors.Is(err, errInputChanged) {
+		return data, release, err
+	}
+
+	encoder, err = e.restart(encoder)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return encoder.Read()
+}
+
+// restart replaces old with a new encoder
+func (e *restartableEncoder) restart(old codec.ReadCloser) (codec.ReadCloser, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return nil, io.EOF
+	}
+
+	old.Close()
+	encoder, err := e.build()
+	if err != nil {
+		return nil, err
+	}
+	e.encoder = encoder
+
+	if bitRateController, ok := encoder.Controller().(codec.BitRateController); ok && e.bitRate != 0 {
+		if err := bitRateController.SetBitRate(e.bitRate); err != nil {
+			logger.Warnf("failed to restore bit rate: %s", err)
+		}
+	}
+	return encoder, nil
+}
+
+func (e *restartableEncoder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	return e.encoder.Close()
+}
+
+// Controller returns a controller that controls the current encoder. It implements the same
+// controller interfaces as the controller of the current encoder.
+func (e *restartableEncoder) Controller() codec.EncoderController {
+	controller := e.current().Controller()
+	_, isKeyFrameController := controller.(codec.KeyFrameController)
+	_, isBitRateController := controller.(codec.BitRateController)
+
+	switch {
+	case isKeyFrameController && isBitRateController:
+		return &struct {
+			keyFrameControllerFunc
+			bitRateControllerFunc
+		}{e.forceKeyFrame, e.setBitRate}
+	case isKeyFrameController:
+		return keyFrameControllerFunc(e.forceKeyFrame)
+	case isBitRateController:
+		return bitRateControllerFunc(e.setBitRate)
+	default:
+		return controller
+	}
+}
+
+func (e *restartableEncoder) forceKeyFrame() error {
+	keyFrameController, ok := e.current().Controller().(codec.KeyFrameController)
+	if !ok {
+		return errKeyFrameControlDisabled
+	}
+	return keyFrameController.ForceKeyFrame()
+}
+
+func (e *restartableEncoder) setBitRate(bitRate int) error {
+	e.mu.Lock()
+	e.bitRate = bitRate
+	encoder := e.encoder
+	e.mu.Unlock()
+
+	bitRateController, ok := encoder.Controller().(codec.BitRateController)
+	if !ok {
+		return errBitRateControlDisabled
+	}
+	return bitRateController.SetBitRate(bitRate)
+}
+
+// keyFrameControllerFunc is a proxy type for codec.KeyFrameController
+type keyFrameControllerFunc func() error
+
+func (f keyFrameControllerFunc) ForceKeyFrame() error {
+	return f()
+}
+
+// bitRateControllerFunc is a proxy type for codec.BitRateController
+type bitRateControllerFunc func(int) error
+
+func (f bitRateControllerFunc) SetBitRate(bitRate int) error {
+	return f(bitRate)
+}
@@ -5,28 +5,64 @@ import (
 	"time"
 )
 
-type samplerFunc func() uint32
+// samplerFunc returns the time since the previous sample in the codec's clock rate. timestamp is
+// the capture time of the sample, or zero time if it's unknown.
+type samplerFunc func(timestamp time.Time) uint32
 
-// newVideoSampler creates a video sampler that uses the actual video frame rate and
-// the codec's clock rate to come up with a duration for each sample.
+// newVideoSampler creates a video sampler that uses the capture time of the video frames and
+// the codec's clock rate to come up with the time since the previous sample. If the capture time
+// is unknown, the time when the sample is read is used instead.
 func newVideoSampler(clockRate uint32) samplerFunc {
 	clockRateFloat := float64(clockRate)
-	lastTimestamp := time.Now()
+	var lastTimestamp time.Time
 
-	return samplerFunc(func() uint32 {
-		now := time.Now()
-		duration := now.Sub(lastTimestamp).Seconds()
+	return samplerFunc(func(timestamp time.Time) uint32 {
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		if lastTimestamp.IsZero() {
+			lastTimestamp = timestamp
+			return 0
+		}
+
+		duration := timestamp.Sub(lastTimestamp).Seconds()
+		if duration < 0 {
+			// The capture time went backwards, e.g. the source has been replaced
+			duration = 0
+		}
 		samples := uint32(math.Round(clockRateFloat * duration))
-		lastTimestamp = now
+		lastTimestamp = timestamp
 		return samples
 	})
 }
 
-// newAudioSampler creates a audio sampler that uses a fixed latency and
-// the codec's clock rate to come up with a duration for each sample.
+// newAudioSampler creates an audio sampler that uses the capture time of the audio samples and
+// the codec's clock rate to come up with the time since the previous sample. The time is normally
+// the codec's latency, so the timestamps stay continuous against the jitter of the capture time.
+// When the capture time has moved ahead of the timestamps by more than the latency, e.g. the source
+// has dropped some samples, the sample that comes after the gap skips it, so the audio stays in sync
+// with the video.
+// If the capture time is unknown, the latency is used as it is.
 func newAudioSampler(clockRate uint32, latency time.Duration) samplerFunc {
 	samples := uint32(math.Round(float64(clockRate) * latency.Seconds()))
-	return samplerFunc(func() uint32 {
-		return samples
+	// clock is the capture time that the next sample is expected at
+	var clock time.Time
+
+	return samplerFunc(func(timestamp time.Time) uint32 {
+		if timestamp.IsZero() {
+			return samples
+		}
+
+		n := samples
+		switch drift := timestamp.Sub(clock); {
+		case clock.IsZero() || drift < -latency:
+			// The first sample, or the capture time went backwards, e.g. the source has been replaced
+		case drift > latency:
+			n += uint32(math.Round(float64(clockRate) * drift.Seconds()))
+		default:
+			timestamp = clock
+		}
+		clock = timestamp.Add(latency)
+		return n
 	})
 }
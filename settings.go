@@ -0,0 +1,82 @@
+package mediadevices
+
+import (
+	"github.com/pion/mediadevices/pkg/driver"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+// GetSettings returns the current settings of the track. For the tracks from drivers, these are the
+// settings that the driver has been opened with, adjusted by the cropping, scaling, and throttling
+// that are needed to satisfy the constraints. For the other tracks, the settings are detected from
+// the frames, so it blocks until a frame is available.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediastreamtrack-getsettings
+func (track *VideoTrack) GetSettings() prop.Media {
+	if _, ok := track.capture.source.(driver.Driver); !ok {
+		settings, err := detectCurrentVideoProp(track.Broadcaster)
+		if err != nil {
+			logger.Debugf("failed to detect the current settings: %s", err)
+		}
+		settings.DeviceID = track.ID()
+		return settings
+	}
+
+	track.capture.mu.Lock()
+	settings := track.capture.constraints.settings
+	track.capture.mu.Unlock()
+
+	settings.DeviceID = track.ID()
+	return settings
+}
+
+// GetCapabilities returns the ranges of the settings that the track supports. For the tracks from
+// drivers, they're derived from the properties of the driver, and the frames can also be cropped and
+// scaled. For the other tracks, the capabilities are the current settings.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediastreamtrack-getcapabilities
+func (track *VideoTrack) GetCapabilities() prop.MediaCapabilities {
+	capabilities := captureCapabilities(track.capture.capture, track.GetSettings)
+	if _, ok := track.capture.source.(driver.Driver); ok && len(capabilities.ResizeMode) > 0 {
+		capabilities.AddResizeMode(prop.ResizeModeCropAndScale)
+	}
+	return capabilities
+}
+
+// GetSettings returns the current settings of the track. For the tracks from drivers, these are the
+// settings that the driver has been opened with. For the other tracks, the settings are detected from
+// the chunks, so it blocks until a chunk is available.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediastreamtrack-getsettings
+func (track *AudioTrack) GetSettings() prop.Media {
+	if _, ok := track.capture.source.(driver.Driver); !ok {
+		settings, err := detectCurrentAudioProp(track.Broadcaster)
+		if err != nil {
+			logger.Debugf("failed to detect the current settings: %s", err)
+		}
+		settings.DeviceID = track.ID()
+		return settings
+	}
+
+	track.capture.mu.Lock()
+	settings := track.capture.constraints.selectedMedia
+	track.capture.mu.Unlock()
+
+	settings.DeviceID = track.ID()
+	return settings
+}
+
+// GetCapabilities returns the ranges of the settings that the track supports. For the tracks from
+// drivers, they're derived from the properties of the driver. For the other tracks, the capabilities
+// are the current settings.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediastreamtrack-getcapabilities
+func (track *AudioTrack) GetCapabilities() prop.MediaCapabilities {
+	return captureCapabilities(track.capture.capture, track.GetSettings)
+}
+
+func captureCapabilities(c *capture, getSettings func() prop.Media) prop.MediaCapabilities {
+	d, ok := c.source.(driver.Driver)
+	if !ok {
+		return prop.NewMediaCapabilities(getSettings())
+	}
+
+	capabilities := prop.NewMediaCapabilities(d.Properties()...)
+	capabilities.DeviceID = d.ID()
+	return capabilities
+}
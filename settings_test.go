@@ -0,0 +1,115 @@
+package mediadevices
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+func TestVideoTrackSettings(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Video: func(c *MediaTrackConstraints) {
+			c.Width = prop.Int(640)
+			c.Height = prop.Int(480)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetVideoTracks()[0].(*VideoTrack)
+	defer track.Close()
+
+	settings := track.GetSettings()
+	if settings.DeviceID != track.ID() {
+		t.Errorf("Expected device ID %s, got %s", track.ID(), settings.DeviceID)
+	}
+	if settings.Width != 640 || settings.Height != 480 || settings.FrameRate != 30 {
+		t.Errorf("Expected 640x480 at 30 fps, got %dx%d at %v fps", settings.Width, settings.Height, settings.FrameRate)
+	}
+
+	err = track.ApplyConstraints(MediaTrackConstraints{
+		MediaConstraints: prop.MediaConstraints{
+			VideoConstraints: prop.VideoConstraints{
+				Width:     prop.Int(320),
+				FrameRate: prop.Float(15),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings = track.GetSettings()
+	if settings.Width != 320 || settings.Height != 240 || settings.FrameRate != 15 {
+		t.Errorf("Expected 320x240 at 15 fps, got %dx%d at %v fps", settings.Width, settings.Height, settings.FrameRate)
+	}
+
+	expected := prop.MediaCapabilities{
+		DeviceID: track.ID(),
+		VideoCapabilities: prop.VideoCapabilities{
+			Width:       prop.IntRange{Min: 640, Max: 640},
+			Height:      prop.IntRange{Min: 480, Max: 480},
+			AspectRatio: prop.FloatRange{Min: 640.0 / 480, Max: 640.0 / 480},
+			FrameRate:   prop.FloatRange{Min: 15, Max: 60},
+			FrameFormat: []frame.Format{frame.FormatYUYV},
+			ResizeMode:  []string{prop.ResizeModeNone, prop.ResizeModeCropAndScale},
+		},
+	}
+	if capabilities := track.GetCapabilities(); !reflect.DeepEqual(capabilities, expected) {
+		t.Errorf("Expected\n%s\n\ngot\n%s", &expected, &capabilities)
+	}
+}
+
+func TestAudioTrackSettings(t *testing.T) {
+	stream, err := GetUserMedia(MediaStreamConstraints{
+		Audio: func(c *MediaTrackConstraints) {
+			c.ChannelCount = prop.IntExact(2)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stream.GetAudioTracks()[0].(*AudioTrack)
+	defer track.Close()
+
+	settings := track.GetSettings()
+	if settings.DeviceID != track.ID() {
+		t.Errorf("Expected device ID %s, got %s", track.ID(), settings.DeviceID)
+	}
+	if settings.ChannelCount != 2 || settings.SampleRate != 48000 {
+		t.Errorf("Expected 2 channels at 48000 Hz, got %d channels at %d Hz", settings.ChannelCount, settings.SampleRate)
+	}
+
+	expected := prop.MediaCapabilities{
+		DeviceID: track.ID(),
+		AudioCapabilities: prop.AudioCapabilities{
+			ChannelCount: prop.IntRange{Min: 1, Max: 2},
+			Latency:      prop.DurationRange{Min: 20 * time.Millisecond, Max: 20 * time.Millisecond},
+			SampleRate:   prop.IntRange{Min: 48000, Max: 48000},
+		},
+	}
+	if capabilities := track.GetCapabilities(); !reflect.DeepEqual(capabilities, expected) {
+		t.Errorf("Expected\n%s\n\ngot\n%s", &expected, &capabilities)
+	}
+}
+
+func TestSourceTrackSettings(t *testing.T) {
+	track := NewVideoTrack(&fakeVideoSource{id: "source", width: 320, height: 240}, nil)
+	defer track.Close()
+
+	settings := track.GetSettings()
+	if settings.DeviceID != "source" || settings.Width != 320 || settings.Height != 240 {
+		t.Errorf("Expected 320x240 from source, got %dx%d from %s", settings.Width, settings.Height, settings.DeviceID)
+	}
+
+	capabilities := track.GetCapabilities()
+	if capabilities.Width != (prop.IntRange{Min: 320, Max: 320}) || capabilities.Height != (prop.IntRange{Min: 240, Max: 240}) {
+		t.Errorf("Expected the capabilities to be the current settings, got\n%s", &capabilities)
+	}
+	// The frames of the source aren't resized by the track
+	if !reflect.DeepEqual(capabilities.ResizeMode, []string{prop.ResizeModeNone}) {
+		t.Errorf("Expected the resize mode %s only, got %v", prop.ResizeModeNone, capabilities.ResizeMode)
+	}
+}
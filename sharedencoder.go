@@ -0,0 +1,260 @@
+package mediadevices
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	mio "github.com/pion/mediadevices/pkg/io"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+// defaultSharedKeyFrameInterval is the minimum duration between 2 forced key frames on a shared
+// encoder. Without this limit, every viewer that lost a packet would make the encoder produce a
+// key frame for all of the other viewers.
+const defaultSharedKeyFrameInterval = 500 * time.Millisecond
+
+// sharedEncoderKey builds a key that identifies encoders that can be shared between bindings.
+// Bindings which negotiated the same codec parameters, except the payload type, will get the same key.
+func sharedEncoderKey(params webrtc.RTPCodecParameters) string {
+	return fmt.Sprintf("%s/%d/%d/%s",
+		strings.ToLower(params.MimeType), params.ClockRate, params.Channels, params.SDPFmtpLine)
+}
+
+// sharedRTPEncoder owns a single RTP reader, which encodes and packetizes the track source only once,
+// and broadcasts the packets to multiple readers. The readers are woken up as soon as the packets have
+// been read, and a reader that has been too slow and missed some packets requests a key frame to let
+// its peer recover. The bit rate of the encoder is the lowest one of the readers, so all of their peers
+// can keep up with it.
+type sharedRTPEncoder struct {
+	reader      RTPReadCloser
+	broadcaster *mio.Broadcaster
+	refCount    int
+
+	keyFrameMu       sync.Mutex
+	keyFrameInterval time.Duration
+	lastKeyFrame     time.Time
+
+	bitRateMu sync.Mutex
+	// bitRates are the bit rates that have been set by the readers
+	bitRates map[*sharedBitRateController]int
+	bitRate  int
+}
+
+func newSharedRTPEncoder(reader RTPReadCloser) *sharedRTPEncoder {
+	source := mio.ReaderFunc(func() (interface{}, func(), error) {
+		// Packets are owned by the packetizer output, so there's nothing to be released here
+		pkts, _, err := reader.Read()
+		if err != nil {
+			return nil, func() {}, err
+		}
+		return pkts, func() {}, nil
+	})
+
+	return &sharedRTPEncoder{
+		reader:           reader,
+		broadcaster:      mio.NewBroadcaster(source, &mio.BroadcasterConfig{Mode: mio.BroadcasterNotify}),
+		keyFrameInterval: defaultSharedKeyFrameInterval,
+		bitRates:         make(map[*sharedBitRateController]int),
+	}
+}
+
+// ForceKeyFrame forwards the key frame request to the underlying encoder. Requests that come
+// within keyFrameInterval of the previous one are dropped since the upcoming key frame will
+// be received by all of the readers anyway.
+func (s *sharedRTPEncoder) ForceKeyFrame() error {
+	s.keyFrameMu.Lock()
+	defer s.keyFrameMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastKeyFrame) < s.keyFrameInterval {
+		return nil
+	}
+
+	keyFrameController, ok := s.reader.Controller().(codec.KeyFrameController)
+	if !ok {
+		return nil
+	}
+
+	if err := keyFrameController.ForceKeyFrame(); err != nil {
+		return err
+	}
+	s.lastKeyFrame = now
+	return nil
+}
+
+// sharedBitRateController is the BitRateController of a reader of sharedRTPEncoder
+type sharedBitRateController struct {
+	s *sharedRTPEncoder
+}
+
+// SetBitRate sets the bit rate of the reader, and the encoder goes down to it if it's the lowest one
+func (c *sharedBitRateController) SetBitRate(bitRate int) error {
+	c.s.bitRateMu.Lock()
+	defer c.s.bitRateMu.Unlock()
+	c.s.bitRates[c] = bitRate
+	return c.s.updateBitRate()
+}
+
+// remove forgets the bit rate of the reader, so the encoder can go up to the bit rates of the others
+func (c *sharedBitRateController) remove() error {
+	c.s.bitRateMu.Lock()
+	defer c.s.bitRateMu.Unlock()
+	if _, ok := c.s.bitRates[c]; !ok {
+		return nil
+	}
+	delete(c.s.bitRates, c)
+	return c.s.updateBitRate()
+}
+
+// updateBitRate sets the bit rate of the encoder to the lowest one of the readers. bitRateMu must be
+// held by the caller.
+func (s *sharedRTPEncoder) updateBitRate() error {
+	bitRate := 0
+	for _, b := range s.bitRates {
+		if bitRate == 0 || b < bitRate {
+			bitRate = b
+		}
+	}
+	if bitRate == 0 || bitRate == s.bitRate {
+		return nil
+	}
+
+	bitRateController, ok := s.reader.Controller().(codec.BitRateController)
+	if !ok {
+		return nil
+	}
+	if err := bitRateController.SetBitRate(bitRate); err != nil {
+		return err
+	}
+	s.bitRate = bitRate
+	return nil
+}
+
+// controller returns an EncoderController that's safe to be used by multiple readers. bitRate is the
+// BitRateController of the reader.
+func (s *sharedRTPEncoder) controller(bitRate *sharedBitRateController) codec.EncoderController {
+	controller := s.reader.Controller()
+	_, isKeyFrameController := controller.(codec.KeyFrameController)
+	_, isBitRateController := controller.(codec.BitRateController)
+	switch {
+	case isKeyFrameController && isBitRateController:
+		return &struct {
+			keyFrameControllerFunc
+			bitRateControllerFunc
+		}{s.ForceKeyFrame, bitRate.SetBitRate}
+	case isKeyFrameController:
+		return keyFrameControllerFunc(s.ForceKeyFrame)
+	case isBitRateController:
+		return bitRateControllerFunc(bitRate.SetBitRate)
+	default:
+		return nil
+	}
+}
+
+// newReader creates a new reader that reads the shared packets. Each reader has its own SSRC,
+// sequence numbers, and payload type. closeFn is called once when the reader is closed.
+func (s *sharedRTPEncoder) newReader(ssrc uint32, payloadType uint8, closeFn func() error) RTPReadCloser {
+	reader := s.broadcaster.NewReader(func(src interface{}) interface{} { return src })
+	sequencer := rtp.NewRandomSequencer()
+	var closeOnce sync.Once
+	var skipped uint64
+	bitRate := &sharedBitRateController{s: s}
+
+	return &rtpReadCloserImpl{
+		readFn: func() ([]*rtp.Packet, func(), error) {
+			data, _, err := reader.Read()
+			if err != nil {
+				return nil, func() {}, err
+			}
+
+			// The packets of whole frames have been overwritten before this reader got them, so its
+			// peer can't decode the following frames until a key frame comes
+			if stats, ok := mio.Stats(reader); ok && stats.Skipped > skipped {
+				skipped = stats.Skipped
+				if err := s.ForceKeyFrame(); err != nil {
+					logger.Warnf("failed to force key frame: %s", err)
+				}
+			}
+
+			sharedPkts := data.([]*rtp.Packet)
+			pkts := make([]*rtp.Packet, len(sharedPkts))
+			for i, sharedPkt := range sharedPkts {
+				// The payload is read only, so only the header needs to be copied
+				pkt := *sharedPkt
+				pkt.Header.SSRC = ssrc
+				pkt.Header.PayloadType = payloadType
+				pkt.Header.SequenceNumber = sequencer.NextSequenceNumber()
+				pkt.Header.CSRC = append([]uint32(nil), sharedPkt.Header.CSRC...)
+				pkt.Header.Extensions = append([]rtp.Extension(nil), sharedPkt.Header.Extensions...)
+				pkts[i] = &pkt
+			}
+			return pkts, func() {}, nil
+		},
+		closeFn: func() error {
+			var err error
+			closeOnce.Do(func() {
+				if err := bitRate.remove(); err != nil {
+					logger.Warnf("failed to set bit rate: %s", err)
+				}
+				err = closeFn()
+			})
+			return err
+		},
+		controllerFn: func() codec.EncoderController {
+			return s.controller(bitRate)
+		},
+	}
+}
+
+// newSharedRTPReader creates an RTP reader that reuses an existing encoder with the same codec parameters
+// if there's any. Otherwise, a new encoder will be built from specializedTrack.
+func (track *baseTrack) newSharedRTPReader(specializedTrack Track, params webrtc.RTPCodecParameters, ssrc uint32, mtu int) (RTPReadCloser, error) {
+	track.sharedMu.Lock()
+	defer track.sharedMu.Unlock()
+
+	if track.sharedEncoders == nil {
+		track.sharedEncoders = make(map[string]*sharedRTPEncoder)
+	}
+
+	key := sharedEncoderKey(params)
+	encoder, ok := track.sharedEncoders[key]
+	if !ok {
+		// SSRC and sequence numbers will be rewritten by each reader, so they don't matter here
+		reader, err := specializedTrack.NewRTPReader(params.MimeType, 0, mtu)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder = newSharedRTPEncoder(reader)
+		track.sharedEncoders[key] = encoder
+	} else {
+		// The new reader joins in the middle of the stream, so it needs a key frame to start decoding
+		if err := encoder.ForceKeyFrame(); err != nil {
+			logger.Warnf("failed to force key frame: %s", err)
+		}
+	}
+	encoder.refCount++
+
+	return encoder.newReader(ssrc, uint8(params.PayloadType), func() error {
+		return track.releaseSharedEncoder(key, encoder)
+	}), nil
+}
+
+// releaseSharedEncoder decrements the reference count of the encoder, and closes it when
+// there's no reader left.
+func (track *baseTrack) releaseSharedEncoder(key string, encoder *sharedRTPEncoder) error {
+	track.sharedMu.Lock()
+	encoder.refCount--
+	if encoder.refCount > 0 {
+		track.sharedMu.Unlock()
+		return nil
+	}
+	delete(track.sharedEncoders, key)
+	track.sharedMu.Unlock()
+
+	return encoder.reader.Close()
+}
@@ -0,0 +1,233 @@
+package mediadevices
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+type fakeRTPReadCloser struct {
+	mu        sync.Mutex
+	reads     int
+	closed    int
+	keyFrames int
+}
+
+func (r *fakeRTPReadCloser) Read() ([]*rtp.Packet, func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	return []*rtp.Packet{
+		{Header: rtp.Header{SSRC: 1, PayloadType: 1, Timestamp: uint32(r.reads)}, Payload: []byte{byte(r.reads)}},
+		{Header: rtp.Header{SSRC: 1, PayloadType: 1, Timestamp: uint32(r.reads)}, Payload: []byte{byte(r.reads)}},
+	}, func() {}, nil
+}
+
+func (r *fakeRTPReadCloser) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed++
+	return nil
+}
+
+func (r *fakeRTPReadCloser) Controller() codec.EncoderController {
+	return r
+}
+
+func (r *fakeRTPReadCloser) ForceKeyFrame() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keyFrames++
+	return nil
+}
+
+type fakeRTPTrack struct {
+	mockMediaStreamTrack
+	readers []*fakeRTPReadCloser
+}
+
+func (track *fakeRTPTrack) NewRTPReader(codecName string, ssrc uint32, mtu int) (RTPReadCloser, error) {
+	reader := &fakeRTPReadCloser{}
+	track.readers = append(track.readers, reader)
+	return reader, nil
+}
+
+func TestSharedRTPReader(t *testing.T) {
+	tr := newBaseTrack(nil, VideoInput, nil)
+	specializedTrack := &fakeRTPTrack{mockMediaStreamTrack: mockMediaStreamTrack{kind: VideoInput}}
+
+	vp8 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		PayloadType:        96,
+	}
+	vp8OtherPayloadType := vp8
+	vp8OtherPayloadType.PayloadType = 100
+	vp9 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000},
+		PayloadType:        98,
+	}
+
+	r1, err := tr.newSharedRTPReader(specializedTrack, vp8, 1000, rtpOutboundMTU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := tr.newSharedRTPReader(specializedTrack, vp8OtherPayloadType, 2000, rtpOutboundMTU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r3, err := tr.newSharedRTPReader(specializedTrack, vp9, 3000, rtpOutboundMTU)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(specializedTrack.readers); n != 2 {
+		t.Fatalf("Expected 2 encoders to be built, got %d", n)
+	}
+
+	pkts1, _, err := r1.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkts2, _, err := r2.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := specializedTrack.readers[0].reads; n != 1 {
+		t.Errorf("Expected the shared encoder to be read once, but got %d", n)
+	}
+
+	for i := range pkts1 {
+		if pkts1[i].SSRC != 1000 || pkts1[i].PayloadType != 96 {
+			t.Errorf("Expected SSRC 1000 and payload type 96, got %d and %d", pkts1[i].SSRC, pkts1[i].PayloadType)
+		}
+		if pkts2[i].SSRC != 2000 || pkts2[i].PayloadType != 100 {
+			t.Errorf("Expected SSRC 2000 and payload type 100, got %d and %d", pkts2[i].SSRC, pkts2[i].PayloadType)
+		}
+		if pkts1[i].Timestamp != pkts2[i].Timestamp {
+			t.Errorf("Expected the same timestamp, got %d and %d", pkts1[i].Timestamp, pkts2[i].Timestamp)
+		}
+	}
+	if pkts1[1].SequenceNumber != pkts1[0].SequenceNumber+1 {
+		t.Error("Expected sequence numbers to be continuous")
+	}
+
+	r1.Close()
+	r1.Close()
+	if n := specializedTrack.readers[0].closed; n != 0 {
+		t.Fatal("Expected the shared encoder to be alive while it's still being used")
+	}
+
+	r2.Close()
+	if n := specializedTrack.readers[0].closed; n != 1 {
+		t.Fatalf("Expected the shared encoder to be closed once, but got %d", n)
+	}
+
+	r3.Close()
+	if n := len(tr.sharedEncoders); n != 0 {
+		t.Errorf("Expected no shared encoder left, got %d", n)
+	}
+}
+
+func TestSharedRTPEncoderKeyFrameRateLimit(t *testing.T) {
+	reader := &fakeRTPReadCloser{}
+	encoder := newSharedRTPEncoder(reader)
+	encoder.keyFrameInterval = 50 * time.Millisecond
+
+	keyFrameController, ok := encoder.newReader(0, 0, func() error { return nil }).Controller().(codec.KeyFrameController)
+	if !ok {
+		t.Fatal("Expected the shared reader to have a KeyFrameController")
+	}
+
+	for i := 0; i < 10; i++ {
+		keyFrameController.ForceKeyFrame()
+	}
+	if reader.keyFrames != 1 {
+		t.Errorf("Expected 1 key frame request, got %d", reader.keyFrames)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	keyFrameController.ForceKeyFrame()
+	if reader.keyFrames != 2 {
+		t.Errorf("Expected 2 key frame requests, got %d", reader.keyFrames)
+	}
+}
+
+func TestSharedRTPEncoderSlowReader(t *testing.T) {
+	reader := &fakeRTPReadCloser{}
+	encoder := newSharedRTPEncoder(reader)
+	fast := encoder.newReader(1000, 96, func() error { return nil })
+	slow := encoder.newReader(2000, 96, func() error { return nil })
+
+	// The fast reader overwrites the whole ring buffer before the slow reader reads
+	for i := 0; i < 40; i++ {
+		if _, _, err := fast.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reader.keyFrames != 0 {
+		t.Fatalf("Expected no key frame request, got %d", reader.keyFrames)
+	}
+
+	if _, _, err := slow.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if reader.keyFrames != 1 {
+		t.Errorf("Expected the slow reader to request a key frame, got %d requests", reader.keyFrames)
+	}
+}
+
+// fakeBitRateRTPReadCloser records the bit rates that have been set
+type fakeBitRateRTPReadCloser struct {
+	fakeRTPReadCloser
+	bitRates []int
+}
+
+func (r *fakeBitRateRTPReadCloser) Controller() codec.EncoderController {
+	return r
+}
+
+func (r *fakeBitRateRTPReadCloser) SetBitRate(bitRate int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bitRates = append(r.bitRates, bitRate)
+	return nil
+}
+
+func TestSharedRTPEncoderBitRate(t *testing.T) {
+	reader := &fakeBitRateRTPReadCloser{}
+	encoder := newSharedRTPEncoder(reader)
+	r1 := encoder.newReader(1000, 96, func() error { return nil })
+	r2 := encoder.newReader(2000, 96, func() error { return nil })
+
+	controller1, ok := r1.Controller().(codec.BitRateController)
+	if !ok {
+		t.Fatal("Expected the shared reader to have a BitRateController")
+	}
+	if _, ok := r1.Controller().(codec.KeyFrameController); !ok {
+		t.Fatal("Expected the shared reader to have a KeyFrameController")
+	}
+	controller2 := r2.Controller().(codec.BitRateController)
+
+	controller1.SetBitRate(1000000)
+	controller2.SetBitRate(500000)
+	// The other peer can't keep up with the higher bit rate, so the encoder stays at the lowest one
+	controller1.SetBitRate(2000000)
+	// The encoder can go up to the bit rate of the remaining peer
+	r2.Close()
+
+	expected := []int{1000000, 500000, 2000000}
+	if len(reader.bitRates) != len(expected) {
+		t.Fatalf("Expected the bit rates %v, got %v", expected, reader.bitRates)
+	}
+	for i := range expected {
+		if reader.bitRates[i] != expected[i] {
+			t.Errorf("Expected the bit rates %v, got %v", expected, reader.bitRates)
+			break
+		}
+	}
+}
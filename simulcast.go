@@ -0,0 +1,103 @@
+package mediadevices
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/io/video"
+)
+
+var (
+	errEmptyRID     = errors.New("simulcast layer must have a RID")
+	errNoSimulcast  = errors.New("at least 1 simulcast layer is required")
+	errDuplicateRID = errors.New("simulcast layers must have distinct RIDs")
+)
+
+// SimulcastLayer describes one of the encodings of a simulcast video track.
+type SimulcastLayer struct {
+	// RID is the RTP stream ID of this layer, e.g. "q", "h", or "f".
+	RID string
+	// Transform is applied to the captured frames before encoding, e.g. video.Scale. nil means
+	// the frames are encoded as they are captured.
+	Transform video.TransformFunc
+	// Selector contains the encoders, and their params, for this layer. If nil, the selector of
+	// the original track is used.
+	Selector *CodecSelector
+}
+
+// simulcastLayerSource is the source of a simulcast layer. The capture source is owned by the
+// original track, so closing a layer only stops the layer itself.
+type simulcastLayerSource struct {
+	id     string
+	mu     sync.Mutex
+	closed bool
+}
+
+func (source *simulcastLayerSource) ID() string {
+	return source.id
+}
+
+func (source *simulcastLayerSource) Close() error {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	source.closed = true
+	return nil
+}
+
+func (source *simulcastLayerSource) isClosed() bool {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return source.closed
+}
+
+// NewSimulcastLayers creates a track for each of the given layers on top of the current capture source.
+// The layer tracks share the same ID and StreamID but have distinct RIDs, so they can be sent as
+// the encodings of a single simulcast RTPSender: the first layer is given to
+// webrtc.PeerConnection.AddTrack, and the rest are given to webrtc.RTPSender.AddEncoding.
+func (track *VideoTrack) NewSimulcastLayers(layers ...SimulcastLayer) ([]Track, error) {
+	if len(layers) == 0 {
+		return nil, errNoSimulcast
+	}
+
+	rids := make(map[string]struct{})
+	for _, layer := range layers {
+		if layer.RID == "" {
+			return nil, errEmptyRID
+		}
+		if _, ok := rids[layer.RID]; ok {
+			return nil, fmt.Errorf("%w: %s", errDuplicateRID, layer.RID)
+		}
+		rids[layer.RID] = struct{}{}
+	}
+
+	streamID := track.StreamID()
+	tracks := make([]Track, 0, len(layers))
+	for _, layer := range layers {
+		selector := layer.Selector
+		if selector == nil {
+			selector = track.selector
+		}
+
+		source := &simulcastLayerSource{id: track.ID()}
+		reader := video.Merge(layer.Transform)(track.NewReader(track.shouldCopyFrames))
+		layerReader := video.WithTimestamp(video.ReaderFunc(func() (image.Image, func(), error) {
+			if source.isClosed() {
+				return nil, func() {}, io.EOF
+			}
+			return reader.Read()
+		}), func() time.Time {
+			return video.Timestamp(reader)
+		})
+
+		layerTrack := newVideoTrackFromReader(source, layerReader, selector).(*VideoTrack)
+		layerTrack.rid = layer.RID
+		layerTrack.setStreamID(streamID)
+		tracks = append(tracks, layerTrack)
+	}
+
+	return tracks, nil
+}
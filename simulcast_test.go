@@ -0,0 +1,105 @@
+package mediadevices
+
+import (
+	"errors"
+	"image"
+	"io"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/io/video"
+)
+
+type fakeVideoSource struct {
+	id            string
+	width, height int
+	closed        bool
+}
+
+func (source *fakeVideoSource) ID() string { return source.id }
+
+func (source *fakeVideoSource) Close() error {
+	source.closed = true
+	return nil
+}
+
+func (source *fakeVideoSource) Read() (image.Image, func(), error) {
+	return image.NewYCbCr(image.Rect(0, 0, source.width, source.height), image.YCbCrSubsampleRatio420), func() {}, nil
+}
+
+func TestNewSimulcastLayers(t *testing.T) {
+	source := &fakeVideoSource{id: "camera", width: 640, height: 480}
+	track := NewVideoTrack(source, nil).(*VideoTrack)
+	defer track.Close()
+
+	layers, err := track.NewSimulcastLayers(
+		SimulcastLayer{RID: "q", Transform: video.Scale(160, 120, nil)},
+		SimulcastLayer{RID: "h", Transform: video.Scale(320, 240, nil)},
+		SimulcastLayer{RID: "f"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		rid           string
+		width, height int
+	}{
+		{"q", 160, 120},
+		{"h", 320, 240},
+		{"f", 640, 480},
+	}
+
+	if len(layers) != len(expected) {
+		t.Fatalf("Expected %d layers, got %d", len(expected), len(layers))
+	}
+
+	for i, e := range expected {
+		layer := layers[i].(*VideoTrack)
+		if layer.RID() != e.rid {
+			t.Errorf("Expected RID %s, got %s", e.rid, layer.RID())
+		}
+		if layer.ID() != track.ID() {
+			t.Errorf("Expected ID %s, got %s", track.ID(), layer.ID())
+		}
+		if layer.StreamID() != layers[0].StreamID() {
+			t.Errorf("Expected all layers to share StreamID %s, got %s", layers[0].StreamID(), layer.StreamID())
+		}
+
+		img, _, err := layer.NewReader(false).Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bounds := img.Bounds(); bounds.Dx() != e.width || bounds.Dy() != e.height {
+			t.Errorf("Expected layer %s to be %dx%d, got %dx%d", e.rid, e.width, e.height, bounds.Dx(), bounds.Dy())
+		}
+	}
+
+	// Closing a layer shouldn't close the capture source
+	layers[0].Close()
+	if source.closed {
+		t.Error("Expected the capture source to be alive")
+	}
+	if _, _, err := layers[0].(*VideoTrack).NewReader(false).Read(); err != io.EOF {
+		t.Errorf("Expected the closed layer to return EOF, got %v", err)
+	}
+	if _, _, err := layers[1].(*VideoTrack).NewReader(false).Read(); err != nil {
+		t.Errorf("Expected the other layers to be alive, got %v", err)
+	}
+}
+
+func TestNewSimulcastLayersInvalid(t *testing.T) {
+	track := NewVideoTrack(&fakeVideoSource{width: 640, height: 480}, nil).(*VideoTrack)
+	defer track.Close()
+
+	if _, err := track.NewSimulcastLayers(); err != errNoSimulcast {
+		t.Errorf("Expected %v, got %v", errNoSimulcast, err)
+	}
+
+	if _, err := track.NewSimulcastLayers(SimulcastLayer{RID: "q"}, SimulcastLayer{}); err != errEmptyRID {
+		t.Errorf("Expected %v, got %v", errEmptyRID, err)
+	}
+
+	if _, err := track.NewSimulcastLayers(SimulcastLayer{RID: "q"}, SimulcastLayer{RID: "q"}); !errors.Is(err, errDuplicateRID) {
+		t.Errorf("Expected %v, got %v", errDuplicateRID, err)
+	}
+}
@@ -0,0 +1,206 @@
+package mediadevices
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	icodec "github.com/pion/mediadevices/internal/codec"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+// statsRateWindow is the window that rates, e.g. frame rate and bit rate, are averaged over
+const statsRateWindow = time.Second
+
+// TrackStats is a snapshot of the statistics of a track. It's modeled after the media-source and
+// outbound-rtp stats in https://www.w3.org/TR/webrtc-stats/.
+type TrackStats struct {
+	// FramesCaptured is the number of video frames, or audio chunks, that have been captured.
+	FramesCaptured uint64
+	// FrameRate is the number of frames, or chunks, captured per second.
+	FrameRate float64
+	// FramesEncoded is the number of frames, or chunks, that have been encoded by all of the encoders.
+	FramesEncoded uint64
+	// TransformLatency is the average time per frame from the capture to the encoder input. It
+	// includes the time spent in the transforms.
+	TransformLatency time.Duration
+	// EncodeLatency is the average time per frame that the encoders take to encode it.
+	EncodeLatency time.Duration
+	// EncodedBitRate is the bit rate of the encoded data in bps, summed up over all of the encoders.
+	EncodedBitRate float64
+	// KeyFrames is the number of encoded key frames. Audio tracks don't have key frames.
+	KeyFrames uint64
+	// ForcedKeyFrames is the number of key frames that have been requested, e.g. by PLI or FIR.
+	ForcedKeyFrames uint64
+	// PeerConnections contains the statistics of each bound peer connection keyed by the ID of
+	// webrtc.TrackLocalContext.
+	PeerConnections map[string]PeerConnectionStats
+}
+
+// PeerConnectionStats is a snapshot of the statistics of a track binding to a peer connection.
+type PeerConnectionStats struct {
+	// Codec is the codec that has been selected for the binding.
+	Codec webrtc.RTPCodecParameters
+	// BytesSent is the number of RTP bytes, including the headers, that have been sent.
+	BytesSent uint64
+	// PacketsSent is the number of RTP packets that have been sent.
+	PacketsSent uint64
+}
+
+// trackStats collects the statistics of a track
+type trackStats struct {
+	mu                    sync.Mutex
+	framesCaptured        uint64
+	framesEncoded         uint64
+	framesTransformed     uint64
+	totalTransformLatency time.Duration
+	totalEncodeLatency    time.Duration
+	keyFrames             uint64
+	forcedKeyFrames       uint64
+	frameRate             *icodec.RateMeter
+	bitRate               *icodec.RateMeter
+	peerConnections       map[string]*PeerConnectionStats
+}
+
+func newTrackStats() *trackStats {
+	return &trackStats{
+		frameRate:       icodec.NewRateMeter(statsRateWindow),
+		bitRate:         icodec.NewRateMeter(statsRateWindow),
+		peerConnections: make(map[string]*PeerConnectionStats),
+	}
+}
+
+func (s *trackStats) snapshot() TrackStats {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := TrackStats{
+		FramesCaptured:  s.framesCaptured,
+		FrameRate:       s.frameRate.Rate(now),
+		FramesEncoded:   s.framesEncoded,
+		EncodedBitRate:  s.bitRate.Rate(now),
+		KeyFrames:       s.keyFrames,
+		ForcedKeyFrames: s.forcedKeyFrames,
+		PeerConnections: make(map[string]PeerConnectionStats, len(s.peerConnections)),
+	}
+	if s.framesTransformed > 0 {
+		stats.TransformLatency = s.totalTransformLatency / time.Duration(s.framesTransformed)
+	}
+	if s.framesEncoded > 0 {
+		stats.EncodeLatency = s.totalEncodeLatency / time.Duration(s.framesEncoded)
+	}
+	for id, pc := range s.peerConnections {
+		stats.PeerConnections[id] = *pc
+	}
+	return stats
+}
+
+// onCapture records a frame that has been captured
+func (s *trackStats) onCapture(now time.Time) {
+	s.frameRate.Add(1, now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.framesCaptured++
+}
+
+// onTransform records a frame that has reached an encoder
+func (s *trackStats) onTransform(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.framesTransformed++
+	s.totalTransformLatency += latency
+}
+
+// onEncode records a frame that has been encoded
+func (s *trackStats) onEncode(size int, keyFrame bool, latency time.Duration, now time.Time) {
+	s.bitRate.Add(float64(size*8), now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.framesEncoded++
+	s.totalEncodeLatency += latency
+	if keyFrame {
+		s.keyFrames++
+	}
+}
+
+func (s *trackStats) onForcedKeyFrame() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forcedKeyFrames++
+}
+
+func (s *trackStats) addPeerConnection(id string, selectedCodec webrtc.RTPCodecParameters) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.peerConnections[id] = &PeerConnectionStats{Codec: selectedCodec}
+}
+
+func (s *trackStats) removePeerConnection(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.peerConnections, id)
+}
+
+// onSend records a packet that has been sent to a peer connection
+func (s *trackStats) onSend(id string, pkt *rtp.Packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pc, ok := s.peerConnections[id]; ok {
+		pc.PacketsSent++
+		pc.BytesSent += uint64(pkt.Header.MarshalSize() + len(pkt.Payload))
+	}
+}
+
+// Stats returns a snapshot of the statistics of the track. The statistics are collected from all
+// of the encoders and bindings of the track, including the readers created by NewRTPReader and
+// NewEncodedReader. The statistics of the underlying broadcaster can be retrieved with
+// track.Broadcaster.Stats.
+func (track *VideoTrack) Stats() TrackStats {
+	return track.stats.snapshot()
+}
+
+// Stats returns a snapshot of the statistics of the track. The statistics are collected from all
+// of the encoders and bindings of the track, including the readers created by NewRTPReader and
+// NewEncodedReader. The statistics of the underlying broadcaster can be retrieved with
+// track.Broadcaster.Stats.
+func (track *AudioTrack) Stats() TrackStats {
+	return track.stats.snapshot()
+}
+
+// isKeyFrame tells if the encoded data is a key frame. Unknown codecs never have key frames.
+func isKeyFrame(mimeType string, data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	switch {
+	case strings.EqualFold(mimeType, webrtc.MimeTypeVP8):
+		// https://datatracker.ietf.org/doc/html/rfc6386#section-9.1
+		return data[0]&0x01 == 0
+	case strings.EqualFold(mimeType, webrtc.MimeTypeVP9):
+		// https://storage.googleapis.com/downloads.webmproject.org/docs/vp9/vp9-bitstream-specification-v0.6-20160331-draft.pdf
+		// Section 6.2 uncompressed header
+		profile := (data[0]>>5)&0x01 | (data[0]>>3)&0x02
+		showExistingFrameBit, frameTypeBit := uint(3), uint(2)
+		if profile == 3 {
+			showExistingFrameBit, frameTypeBit = 2, 1
+		}
+		if data[0]>>showExistingFrameBit&0x01 == 1 {
+			return false
+		}
+		return data[0]>>frameTypeBit&0x01 == 0
+	case strings.EqualFold(mimeType, webrtc.MimeTypeH264):
+		// Look for an IDR NAL unit in the Annex B byte stream
+		for i := 0; i+3 < len(data); i++ {
+			if data[i] == 0 && data[i+1] == 0 && data[i+2] == 1 && data[i+3]&0x1F == 5 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package mediadevices
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+type fakeVP8EncoderBuilder struct{}
+
+func (b *fakeVP8EncoderBuilder) RTPCodec() *codec.RTPCodec {
+	return codec.NewRTPVP8Codec(90000)
+}
+
+func (b *fakeVP8EncoderBuilder) BuildVideoEncoder(r video.Reader, p prop.Media) (codec.ReadCloser, error) {
+	return &fakeVP8Encoder{r: r}, nil
+}
+
+// fakeVP8Encoder produces a key frame every 2 frames, or when it's forced to
+type fakeVP8Encoder struct {
+	mu            sync.Mutex
+	r             video.Reader
+	frames        int
+	forceKeyFrame bool
+}
+
+func (e *fakeVP8Encoder) Read() ([]byte, func(), error) {
+	if _, _, err := e.r.Read(); err != nil {
+		return nil, func() {}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	frameTag := byte(0x01)
+	if e.frames%2 == 0 || e.forceKeyFrame {
+		frameTag = 0x00
+	}
+	e.frames++
+	e.forceKeyFrame = false
+	return []byte{frameTag, 0x00, 0x00, 0x00}, func() {}, nil
+}
+
+func (e *fakeVP8Encoder) Close() error { return nil }
+
+func (e *fakeVP8Encoder) Controller() codec.EncoderController { return e }
+
+func (e *fakeVP8Encoder) ForceKeyFrame() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.forceKeyFrame = true
+	return nil
+}
+
+func TestTrackStats(t *testing.T) {
+	selector := NewCodecSelector(WithVideoEncoders(&fakeVP8EncoderBuilder{}))
+	track := NewVideoTrack(&fakeVideoSource{width: 320, height: 240}, selector).(*VideoTrack)
+	defer track.Close()
+
+	reader, err := track.NewRTPReader(webrtc.MimeTypeVP8, 1, rtpOutboundMTU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	for i := 0; i < 4; i++ {
+		if _, _, err := reader.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keyFrameController, ok := reader.Controller().(codec.KeyFrameController)
+	if !ok {
+		t.Fatal("Expected the reader to have a KeyFrameController")
+	}
+	if err := keyFrameController.ForceKeyFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := track.Stats()
+	if stats.FramesCaptured != 5 {
+		t.Errorf("Expected 5 captured frames, got %d", stats.FramesCaptured)
+	}
+	if stats.FramesEncoded != 5 {
+		t.Errorf("Expected 5 encoded frames, got %d", stats.FramesEncoded)
+	}
+	if stats.KeyFrames != 3 {
+		t.Errorf("Expected 3 key frames, got %d", stats.KeyFrames)
+	}
+	if stats.ForcedKeyFrames != 1 {
+		t.Errorf("Expected 1 forced key frame, got %d", stats.ForcedKeyFrames)
+	}
+	if stats.FrameRate <= 0 || stats.EncodedBitRate <= 0 {
+		t.Errorf("Expected frame rate and bit rate to be measured, got %f fps and %f bps", stats.FrameRate, stats.EncodedBitRate)
+	}
+}
+
+func TestTrackStatsPeerConnections(t *testing.T) {
+	stats := newTrackStats()
+	vp8 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		PayloadType:        96,
+	}
+	stats.addPeerConnection("pc1", vp8)
+
+	pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1}, Payload: make([]byte, 100)}
+	stats.onSend("pc1", pkt)
+	stats.onSend("pc1", pkt)
+	// Packets of unknown peer connections should be ignored
+	stats.onSend("pc2", pkt)
+
+	snapshot := stats.snapshot()
+	expected := PeerConnectionStats{Codec: vp8, BytesSent: 2 * (12 + 100), PacketsSent: 2}
+	if len(snapshot.PeerConnections) != 1 || !reflect.DeepEqual(snapshot.PeerConnections["pc1"], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, snapshot.PeerConnections)
+	}
+
+	stats.removePeerConnection("pc1")
+	if n := len(stats.snapshot().PeerConnections); n != 0 {
+		t.Errorf("Expected no peer connection after unbind, got %d", n)
+	}
+}
+
+func TestIsKeyFrame(t *testing.T) {
+	testCases := map[string]struct {
+		mimeType string
+		data     []byte
+		expected bool
+	}{
+		"VP8KeyFrame":   {webrtc.MimeTypeVP8, []byte{0x10, 0x02, 0x00}, true},
+		"VP8DeltaFrame": {webrtc.MimeTypeVP8, []byte{0x11, 0x02, 0x00}, false},
+		// frame_marker=2, profile=0, show_existing_frame=0, frame_type=0
+		"VP9KeyFrame": {webrtc.MimeTypeVP9, []byte{0x82}, true},
+		// frame_marker=2, profile=0, show_existing_frame=0, frame_type=1
+		"VP9DeltaFrame": {webrtc.MimeTypeVP9, []byte{0x86}, false},
+		// frame_marker=2, profile=3, reserved_zero=0, show_existing_frame=0, frame_type=0
+		"VP9Profile3KeyFrame": {webrtc.MimeTypeVP9, []byte{0xB0}, true},
+		"H264IDR":             {webrtc.MimeTypeH264, []byte{0x00, 0x00, 0x00, 0x01, 0x67, 0x00, 0x00, 0x01, 0x65}, true},
+		"H264NonIDR":          {webrtc.MimeTypeH264, []byte{0x00, 0x00, 0x00, 0x01, 0x41}, false},
+		"Opus":                {webrtc.MimeTypeOpus, []byte{0x00}, false},
+		"Empty":               {webrtc.MimeTypeVP8, nil, false},
+	}
+
+	for name, testCase := range testCases {
+		if actual := isKeyFrame(testCase.mimeType, testCase.data); actual != testCase.expected {
+			t.Errorf("%s: expected %v, got %v", name, testCase.expected, actual)
+		}
+	}
+}
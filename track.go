@@ -1,12 +1,15 @@
 package mediadevices
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"image"
 	"io"
+	"math"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pion/interceptor"
 	"github.com/pion/rtcp"
@@ -16,6 +19,7 @@ import (
 	"github.com/pion/mediadevices/pkg/driver"
 	"github.com/pion/mediadevices/pkg/io/audio"
 	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
 	"github.com/pion/mediadevices/pkg/wave"
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
@@ -57,6 +61,9 @@ type Track interface {
 	// If the error is already occured before registering, the handler will be
 	// immediately called.
 	OnEnded(func(error))
+	// Subscribe registers a handler to receive the lifecycle events of the track, and returns a function
+	// to unsubscribe. Unlike OnEnded, there can be any number of subscribers.
+	Subscribe(func(TrackEvent)) (unsubscribe func())
 	Kind() webrtc.RTPCodecType
 	// StreamID is the group this track belongs too. This must be unique
 	StreamID() string
@@ -77,18 +84,56 @@ type Track interface {
 	NewEncodedReader(codecName string) (EncodedReadCloser, error)
 	// NewEncodedReader creates a new Go standard io.ReadCloser that reads the encoded data in codecName format
 	NewEncodedIOReader(codecName string) (io.ReadCloser, error)
+	// ApplyConstraints applies the constraints to the live track without interrupting the readers
+	// and bindings of the track. If the constraints can't be satisfied, an error is returned
+	// and the track keeps the previous settings.
+	ApplyConstraints(MediaTrackConstraints) error
+	// GetSettings returns the current settings of the track, e.g. the resolution, frame rate, and
+	// device ID that the track has ended up with
+	GetSettings() prop.Media
+	// GetCapabilities returns the ranges and the sets of the settings that the track supports
+	GetCapabilities() prop.MediaCapabilities
+	// Enabled tells if the track is enabled
+	Enabled() bool
+	// SetEnabled enables or disables the track. Disabled tracks keep running, but send black frames
+	// or silence instead of the captured media.
+	SetEnabled(bool)
+	// OnMute sets a handler that is called when the track is disabled
+	OnMute(func())
+	// OnUnmute sets a handler that is called when the track is enabled again
+	OnUnmute(func())
 }
 
 type baseTrack struct {
 	Source
 	err                   error
-	onErrorHandler        func(error)
 	errMu                 sync.Mutex
 	mu                    sync.Mutex
-	endOnce               sync.Once
 	kind                  MediaDeviceType
+	rid                   string
 	selector              *CodecSelector
 	activePeerConnections map[string]chan<- chan<- struct{}
+
+	sharedEncoding bool
+	sharedMu       sync.Mutex
+	sharedEncoders map[string]*sharedRTPEncoder
+
+	bandwidthEstimation *BandwidthEstimationConfig
+
+	stats *trackStats
+
+	streamIDMu sync.Mutex
+	streamID   string
+
+	enabledMu sync.Mutex
+	enabled   bool
+
+	events trackEvents
+	// handlersMu guards the subscriptions of the handlers that are set by OnEnded, OnMute, and OnUnmute
+	handlersMu          sync.Mutex
+	onEndedUnsubscribe  func()
+	onMuteUnsubscribe   func()
+	onUnmuteUnsubscribe func()
 }
 
 func newBaseTrack(source Source, kind MediaDeviceType, selector *CodecSelector) *baseTrack {
@@ -97,6 +142,9 @@ func newBaseTrack(source Source, kind MediaDeviceType, selector *CodecSelector)
 		kind:                  kind,
 		selector:              selector,
 		activePeerConnections: make(map[string]chan<- chan<- struct{}),
+		sharedEncoders:        make(map[string]*sharedRTPEncoder),
+		stats:                 newTrackStats(),
+		enabled:               true,
 	}
 }
 
@@ -112,49 +160,99 @@ func (track *baseTrack) Kind() webrtc.RTPCodecType {
 	}
 }
 
+// StreamID returns the ID of the stream that the track has been added to last. If the track hasn't been
+// added to any stream, a random ID is generated once and kept for the lifetime of the track.
 func (track *baseTrack) StreamID() string {
-	// TODO: StreamID should be used to group multiple tracks. Should get this information from mediastream instead.
-	generator, err := uuid.NewRandom()
-	if err != nil {
-		panic(err)
+	track.streamIDMu.Lock()
+	defer track.streamIDMu.Unlock()
+
+	if track.streamID == "" {
+		generator, err := uuid.NewRandom()
+		if err != nil {
+			panic(err)
+		}
+		track.streamID = generator.String()
 	}
 
-	return generator.String()
+	return track.streamID
+}
+
+// setStreamID is called by the stream that the track is added to
+func (track *baseTrack) setStreamID(id string) {
+	track.streamIDMu.Lock()
+	defer track.streamIDMu.Unlock()
+	track.streamID = id
 }
 
 // RID is only relevant if you wish to use Simulcast
 func (track *baseTrack) RID() string {
-	return ""
+	return track.rid
+}
+
+// SharedEncoding indicates if peer connections bound to this track share encoders.
+func (track *baseTrack) SharedEncoding() bool {
+	track.mu.Lock()
+	defer track.mu.Unlock()
+	return track.sharedEncoding
+}
+
+// SetSharedEncoding enables encoder sharing between the peer connections bound to this track. When enabled,
+// bindings that negotiated the same codec parameters reuse a single encoder and packetizer, and only
+// the SSRC, sequence numbers, and payload type are rewritten for each binding. This only affects
+// the bindings that are made after the call.
+func (track *baseTrack) SetSharedEncoding(shared bool) {
+	track.mu.Lock()
+	defer track.mu.Unlock()
+	track.sharedEncoding = shared
+}
+
+// SetBandwidthEstimation enables the encoder bit rate to be controlled by the RTCP feedback, REMB,
+// transport-wide congestion control, and receiver reports, of each bound peer connection.
+// Setting nil disables the bit rate control. This only affects the bindings that are made after the call,
+// and the encoders that implement codec.BitRateController. Since shared encoders are used by multiple
+// peer connections, their bit rate is the lowest one that's been estimated for the peer connections.
+func (track *baseTrack) SetBandwidthEstimation(config *BandwidthEstimationConfig) {
+	track.mu.Lock()
+	defer track.mu.Unlock()
+	track.bandwidthEstimation = config
+}
+
+// cloneSettingsTo copies the settings of the track to clone
+func (track *baseTrack) cloneSettingsTo(clone *baseTrack) {
+	track.mu.Lock()
+	defer track.mu.Unlock()
+	clone.rid = track.rid
+	clone.streamID = track.StreamID()
+	clone.sharedEncoding = track.sharedEncoding
+	clone.bandwidthEstimation = track.bandwidthEstimation
+
+	track.enabledMu.Lock()
+	defer track.enabledMu.Unlock()
+	clone.enabled = track.enabled
 }
 
 // OnEnded sets an error handler. When a track has been created and started, if an
-// error occurs, handler will get called with the error given to the parameter.
+// error occurs, handler will get called with the error given to the parameter. The handler
+// replaces the one that has been set by the previous call. Use Subscribe to have multiple handlers.
 func (track *baseTrack) OnEnded(handler func(error)) {
-	track.errMu.Lock()
-	track.onErrorHandler = handler
-	err := track.err
-	track.errMu.Unlock()
-
-	if err != nil && handler != nil {
-		// Already errored.
-		track.endOnce.Do(func() {
-			handler(err)
-		})
+	var eventHandler func(TrackEvent)
+	if handler != nil {
+		eventHandler = func(event TrackEvent) {
+			if ended, ok := event.(TrackEndedEvent); ok {
+				handler(ended.Err)
+			}
+		}
 	}
+	track.replaceHandler(&track.onEndedUnsubscribe, eventHandler)
 }
 
 // onError is a callback when an error occurs
 func (track *baseTrack) onError(err error) {
 	track.errMu.Lock()
 	track.err = err
-	handler := track.onErrorHandler
 	track.errMu.Unlock()
 
-	if handler != nil {
-		track.endOnce.Do(func() {
-			handler(err)
-		})
-	}
+	track.events.emit(TrackEndedEvent{Err: err})
 }
 
 func (track *baseTrack) bind(ctx webrtc.TrackLocalContext, specializedTrack Track) (webrtc.RTPCodecParameters, error) {
@@ -171,11 +269,18 @@ func (track *baseTrack) bind(ctx webrtc.TrackLocalContext, specializedTrack Trac
 	var errReasons []string
 	for _, wantedCodec := range ctx.CodecParameters() {
 		logger.Debugf("trying to build %s rtp reader", wantedCodec.MimeType)
-		encodedReader, err = specializedTrack.NewRTPReader(wantedCodec.MimeType, uint32(ctx.SSRC()), rtpOutboundMTU)
+		if track.sharedEncoding {
+			encodedReader, err = track.newSharedRTPReader(specializedTrack, wantedCodec, uint32(ctx.SSRC()), rtpOutboundMTU)
+		} else {
+			encodedReader, err = specializedTrack.NewRTPReader(wantedCodec.MimeType, uint32(ctx.SSRC()), rtpOutboundMTU)
+		}
 
 		track.errMu.Lock()
 		if track.err != nil {
 			err = track.err
+			if encodedReader != nil {
+				encodedReader.Close()
+			}
 			encodedReader = nil
 		}
 		track.errMu.Unlock()
@@ -191,6 +296,7 @@ func (track *baseTrack) bind(ctx webrtc.TrackLocalContext, specializedTrack Trac
 	if encodedReader == nil {
 		return webrtc.RTPCodecParameters{}, errors.New(strings.Join(errReasons, "\n\n"))
 	}
+	track.stats.addPeerConnection(ctx.ID(), selectedCodec)
 
 	go func() {
 		var doneCh chan<- struct{}
@@ -201,6 +307,7 @@ func (track *baseTrack) bind(ctx webrtc.TrackLocalContext, specializedTrack Trac
 
 			// When there's another call to unbind, it won't block since we remove the current ctx from active connections
 			track.removeActivePeerConnection(ctx.ID())
+			track.stats.removePeerConnection(ctx.ID())
 			close(signalCh)
 			if doneCh != nil {
 				close(doneCh)
@@ -226,20 +333,33 @@ func (track *baseTrack) bind(ctx webrtc.TrackLocalContext, specializedTrack Trac
 					track.onError(err)
 					return
 				}
+				track.stats.onSend(ctx.ID(), pkt)
 			}
 		}
 	}()
 
-	keyFrameController, ok := encodedReader.Controller().(codec.KeyFrameController)
-	if ok {
-		go track.rtcpReadLoop(ctx.RTCPReader(), keyFrameController, stopRead)
+	controller := encodedReader.Controller()
+	_, isKeyFrameController := controller.(codec.KeyFrameController)
+
+	var bitRate *bitRateControl
+	if bitRateController, ok := controller.(codec.BitRateController); ok && track.bandwidthEstimation != nil {
+		bitRate, err = newBitRateControl(track.bandwidthEstimation, bitRateController, uint32(ctx.SSRC()))
+		if err != nil {
+			logger.Warnf("failed to set start bit rate: %s", err)
+			bitRate = nil
+		}
+	}
+
+	if isKeyFrameController || bitRate != nil {
+		go track.rtcpReadLoop(ctx.RTCPReader(), controller, bitRate, stopRead)
 	}
 
 	return selectedCodec, nil
 }
 
-func (track *baseTrack) rtcpReadLoop(reader interceptor.RTCPReader, keyFrameController codec.KeyFrameController, stopRead chan struct{}) {
+func (track *baseTrack) rtcpReadLoop(reader interceptor.RTCPReader, controller codec.EncoderController, bitRate *bitRateControl, stopRead chan struct{}) {
 	readerBuffer := make([]byte, rtcpInboundMTU)
+	keyFrameController, isKeyFrameController := controller.(codec.KeyFrameController)
 
 readLoop:
 	for {
@@ -265,14 +385,27 @@ readLoop:
 		}
 
 		for _, pkt := range pkts {
+			if bitRate != nil {
+				bitRate.onRTCP(pkt)
+			}
+
 			switch pkt.(type) {
 			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				if !isKeyFrameController {
+					continue
+				}
 				if err := keyFrameController.ForceKeyFrame(); err != nil {
 					logger.Warnf("failed to force key frame: %s", err)
 					continue readLoop
 				}
 			}
 		}
+
+		if bitRate != nil {
+			if err := bitRate.update(); err != nil {
+				logger.Warnf("failed to set bit rate: %s", err)
+			}
+		}
 	}
 }
 
@@ -302,8 +435,14 @@ func (track *baseTrack) removeActivePeerConnection(id string) chan<- chan<- stru
 	return ch
 }
 
-func newTrackFromDriver(d driver.Driver, constraints MediaTrackConstraints, selector *CodecSelector) (Track, error) {
-	if err := d.Open(); err != nil {
+func newTrackFromDriver(ctx context.Context, d driver.Driver, constraints MediaTrackConstraints, selector *CodecSelector) (Track, error) {
+	unlock, err := lockDriver(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	err = openDriver(ctx, d)
+	unlock()
+	if err != nil {
 		return nil, err
 	}
 
@@ -322,6 +461,7 @@ type VideoTrack struct {
 	*baseTrack
 	*video.Broadcaster
 	shouldCopyFrames bool
+	capture          *videoCapture
 }
 
 // NewVideoTrack constructs a new VideoTrack
@@ -340,21 +480,52 @@ func (track *VideoTrack) SetShouldCopyFrames(shouldCopyFrames bool) {
 }
 
 func newVideoTrackFromReader(source Source, reader video.Reader, selector *CodecSelector) Track {
+	return newVideoTrackFromCapture(newVideoCapture(source, reader, MediaTrackConstraints{}), selector)
+}
+
+// newVideoTrackFromCapture creates a new track on top of the capture
+func newVideoTrackFromCapture(c *videoCapture, selector *CodecSelector) *VideoTrack {
+	source := c.newTrackSource()
 	base := newBaseTrack(source, VideoInput, selector)
-	wrappedReader := video.ReaderFunc(func() (img image.Image, release func(), err error) {
-		img, _, err = reader.Read()
-		if err != nil {
-			base.onError(err)
+	var changes settingsChanges
+	reader := video.DetectChanges(settingsDetectInterval, frameRateChangeTolerance, func(p prop.Media) {
+		if changes.changed(p) {
+			base.onSettingsChanged(p)
+		}
+	})(c.newReader(source))
+	var black blackFrames
+	var lastBlackFrame time.Time
+	wrappedReader := video.WithTimestamp(video.ReaderFunc(func() (image.Image, func(), error) {
+		for {
+			img, _, err := reader.Read()
+			if err != nil {
+				base.onError(err)
+				return img, func() {}, err
+			}
+
+			now := time.Now()
+			if base.Enabled() {
+				lastBlackFrame = time.Time{}
+			} else {
+				// Disabled tracks only send a black frame once in a while to keep the encoders running
+				if now.Sub(lastBlackFrame) < disabledFrameInterval {
+					continue
+				}
+				lastBlackFrame = now
+				img = black.get(img.Bounds())
+			}
+			base.stats.onCapture(now)
+			return img, func() {}, nil
 		}
-		return img, func() {}, err
+	}), func() time.Time {
+		return video.Timestamp(reader)
 	})
 
 	// TODO: Allow users to configure broadcaster
-	broadcaster := video.NewBroadcaster(wrappedReader, nil)
-
 	return &VideoTrack{
 		baseTrack:   base,
-		Broadcaster: broadcaster,
+		Broadcaster: video.NewBroadcaster(wrappedReader, nil),
+		capture:     c,
 	}
 }
 
@@ -365,7 +536,19 @@ func newVideoTrackFromDriver(d driver.Driver, recorder driver.VideoRecorder, con
 		return nil, err
 	}
 
-	return newVideoTrackFromReader(d, reader, selector), nil
+	return newVideoTrackFromCapture(newVideoCapture(d, reader, constraints), selector), nil
+}
+
+// Clone creates a new track on top of the same capture source. The clone has its own transforms,
+// readers, and OnEnded handler, and starts with the settings of the track, e.g. SharedEncoding, but
+// without its transforms. The capture source is closed once the track and all of its clones have
+// been closed. Since the capture source is shared, ApplyConstraints on any of them affects all of them.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediastreamtrack-clone
+func (track *VideoTrack) Clone() Track {
+	clone := newVideoTrackFromCapture(track.capture, track.selector)
+	clone.shouldCopyFrames = track.shouldCopyFrames
+	track.cloneSettingsTo(clone.baseTrack)
+	return clone
 }
 
 // Transform transforms the underlying source by applying the given fns in serial order
@@ -389,24 +572,80 @@ func (track *VideoTrack) newEncodedReader(codecNames ...string) (EncodedReadClos
 		return nil, nil, err
 	}
 
-	encodedReader, selectedCodec, err := track.selector.selectVideoCodecByNames(reader, inputProp, codecNames...)
+	// Keep track of the frames that are in the encoder to tell the capture time of the encoded data
+	// and to measure the latency
+	var inputs encoderQueue
+	// When the resolution changes, e.g. by ApplyConstraints, the frame is kept in pending until
+	// the encoder has been rebuilt for encoderProp
+	encoderProp := inputProp
+	var pending image.Image
+	var pendingRelease func()
+	encoderInput := video.WithTimestamp(video.ReaderFunc(func() (image.Image, func(), error) {
+		img, release := pending, pendingRelease
+		pending, pendingRelease = nil, nil
+		if img == nil {
+			var err error
+			img, release, err = reader.Read()
+			if err != nil {
+				return img, release, err
+			}
+
+			if bounds := img.Bounds(); bounds.Dx() != encoderProp.Width || bounds.Dy() != encoderProp.Height {
+				encoderProp.Width, encoderProp.Height = bounds.Dx(), bounds.Dy()
+				pending, pendingRelease = img, release
+				// The frames that have been buffered by the encoder are dropped with it
+				inputs.reset()
+				return nil, func() {}, errInputChanged
+			}
+		}
+
+		in := queuedInput{timestamp: video.Timestamp(reader), inputTime: time.Now()}
+		inputs.push(in)
+		track.stats.onTransform(in.inputTime.Sub(in.timestamp))
+		return img, release, nil
+	}), func() time.Time {
+		return video.Timestamp(reader)
+	})
+
+	encoder, selectedCodec, err := track.selector.selectVideoCodecByNames(encoderInput, inputProp, codecNames...)
 	if err != nil {
 		return nil, nil, err
 	}
+	encodedReader := newRestartableEncoder(encoder, func() (codec.ReadCloser, error) {
+		encoder, _, err := track.selector.selectVideoCodecByNames(encoderInput, encoderProp, selectedCodec.MimeType)
+		return encoder, err
+	})
 
 	sample := newVideoSampler(selectedCodec.ClockRate)
 
 	return &encodedReadCloserImpl{
 		readFn: func() (EncodedBuffer, func(), error) {
 			data, release, err := encodedReader.Read()
-			buffer := EncodedBuffer{
-				Data:    data,
-				Samples: sample(),
+			var buffer EncodedBuffer
+			if err != nil || len(data) == 0 {
+				// The encoder is buffering the frame, so its capture time is kept in the queue
+				buffer.Data = data
+				return buffer, release, err
+			}
+
+			// The encoded data belongs to the oldest frame in the encoder
+			in, ok := inputs.pop()
+			if !ok {
+				in = queuedInput{timestamp: video.Timestamp(reader), inputTime: time.Now()}
 			}
-			return buffer, release, err
+			now := time.Now()
+			track.stats.onEncode(len(data), isKeyFrame(selectedCodec.MimeType, data), now.Sub(in.inputTime), now)
+			buffer = EncodedBuffer{
+				Data:      data,
+				Samples:   sample(in.timestamp),
+				Timestamp: in.timestamp,
+			}
+			return buffer, release, nil
+		},
+		closeFn: encodedReader.Close,
+		controllerFn: func() codec.EncoderController {
+			return track.wrapController(encodedReader.Controller())
 		},
-		closeFn:      encodedReader.Close,
-		controllerFn: encodedReader.Controller,
 	}, selectedCodec, nil
 }
 
@@ -441,7 +680,7 @@ func (track *VideoTrack) NewRTPReader(codecName string, ssrc uint32, mtu int) (R
 			}
 			defer release()
 
-			pkts := packetizer.Packetize(encoded.Data, encoded.Samples)
+			pkts := packetize(packetizer, encoded)
 			return pkts, release, err
 		},
 		closeFn:      encodedReader.Close,
@@ -449,11 +688,20 @@ func (track *VideoTrack) NewRTPReader(codecName string, ssrc uint32, mtu int) (R
 	}, nil
 }
 
+// packetize packetizes the encoded buffer. The samples of the buffer are counted from the previous
+// one, so the timestamp is advanced before the buffer is stamped, unlike Packetize does, and each
+// packet is stamped with its own capture time.
+func packetize(packetizer rtp.Packetizer, encoded EncodedBuffer) []*rtp.Packet {
+	packetizer.SkipSamples(encoded.Samples)
+	return packetizer.Packetize(encoded.Data, 0)
+}
+
 // AudioTrack is a specific track type that contains audio source which allows multiple readers to access, and
 // manipulate.
 type AudioTrack struct {
 	*baseTrack
 	*audio.Broadcaster
+	capture *audioCapture
 }
 
 // NewAudioTrack constructs a new AudioTrack
@@ -462,21 +710,41 @@ func NewAudioTrack(source AudioSource, selector *CodecSelector) Track {
 }
 
 func newAudioTrackFromReader(source Source, reader audio.Reader, selector *CodecSelector) Track {
+	return newAudioTrackFromCapture(newAudioCapture(source, reader, MediaTrackConstraints{}), selector)
+}
+
+// newAudioTrackFromCapture creates a new track on top of the capture
+func newAudioTrackFromCapture(c *audioCapture, selector *CodecSelector) *AudioTrack {
+	source := c.newTrackSource()
 	base := newBaseTrack(source, AudioInput, selector)
-	wrappedReader := audio.ReaderFunc(func() (chunk wave.Audio, release func(), err error) {
-		chunk, _, err = reader.Read()
+	var changes settingsChanges
+	reader := audio.DetectChanges(0, func(p prop.Media) {
+		if changes.changed(p) {
+			base.onSettingsChanged(p)
+		}
+	})(c.newReader(source))
+	var silence silentChunks
+	wrappedReader := audio.WithTimestamp(audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		chunk, _, err := reader.Read()
 		if err != nil {
 			base.onError(err)
+			return chunk, func() {}, err
+		}
+
+		if !base.Enabled() {
+			chunk = silence.get(chunk)
 		}
-		return chunk, func() {}, err
+		base.stats.onCapture(time.Now())
+		return chunk, func() {}, nil
+	}), func() time.Time {
+		return audio.Timestamp(reader)
 	})
 
 	// TODO: Allow users to configure broadcaster
-	broadcaster := audio.NewBroadcaster(wrappedReader, nil)
-
 	return &AudioTrack{
 		baseTrack:   base,
-		Broadcaster: broadcaster,
+		Broadcaster: audio.NewBroadcaster(wrappedReader, nil),
+		capture:     c,
 	}
 }
 
@@ -487,7 +755,18 @@ func newAudioTrackFromDriver(d driver.Driver, recorder driver.AudioRecorder, con
 		return nil, err
 	}
 
-	return newAudioTrackFromReader(d, reader, selector), nil
+	return newAudioTrackFromCapture(newAudioCapture(d, reader, constraints), selector), nil
+}
+
+// Clone creates a new track on top of the same capture source. The clone has its own transforms,
+// readers, and OnEnded handler, and starts with the settings of the track, e.g. SharedEncoding, but
+// without its transforms. The capture source is closed once the track and all of its clones have
+// been closed. Since the capture source is shared, ApplyConstraints on any of them affects all of them.
+// Reference: https://w3c.github.io/mediacapture-main/#dom-mediastreamtrack-clone
+func (track *AudioTrack) Clone() Track {
+	clone := newAudioTrackFromCapture(track.capture, track.selector)
+	track.cloneSettingsTo(clone.baseTrack)
+	return clone
 }
 
 // Transform transforms the underlying source by applying the given fns in serial order
@@ -511,24 +790,87 @@ func (track *AudioTrack) newEncodedReader(codecNames ...string) (EncodedReadClos
 		return nil, nil, err
 	}
 
-	encodedReader, selectedCodec, err := track.selector.selectAudioCodecByNames(reader, inputProp, codecNames...)
+	// Keep track of the chunks that are in the encoder to tell the capture time of the encoded data
+	// and to measure the latency
+	var inputs encoderQueue
+	// When the format changes, e.g. by ApplyConstraints, the chunk is kept in pending until
+	// the encoder has been rebuilt for encoderProp
+	encoderProp := inputProp
+	var pending wave.Audio
+	var pendingRelease func()
+	encoderInput := audio.WithTimestamp(audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		chunk, release := pending, pendingRelease
+		pending, pendingRelease = nil, nil
+		if chunk == nil {
+			var err error
+			chunk, release, err = reader.Read()
+			if err != nil {
+				return chunk, release, err
+			}
+
+			if info := chunk.ChunkInfo(); info.SamplingRate != encoderProp.SampleRate || info.Channels != encoderProp.ChannelCount {
+				encoderProp.SampleRate, encoderProp.ChannelCount = info.SamplingRate, info.Channels
+				pending, pendingRelease = chunk, release
+				// The samples that have been buffered by the encoder are dropped with it
+				inputs.reset()
+				return nil, func() {}, errInputChanged
+			}
+		}
+
+		info := chunk.ChunkInfo()
+		in := queuedInput{
+			timestamp:  audio.Timestamp(reader),
+			inputTime:  time.Now(),
+			samples:    info.Len,
+			sampleRate: info.SamplingRate,
+		}
+		inputs.push(in)
+		track.stats.onTransform(in.inputTime.Sub(in.timestamp))
+		return chunk, release, nil
+	}), func() time.Time {
+		return audio.Timestamp(reader)
+	})
+
+	encoder, selectedCodec, err := track.selector.selectAudioCodecByNames(encoderInput, inputProp, codecNames...)
 	if err != nil {
 		return nil, nil, err
 	}
+	encodedReader := newRestartableEncoder(encoder, func() (codec.ReadCloser, error) {
+		encoder, _, err := track.selector.selectAudioCodecByNames(encoderInput, encoderProp, selectedCodec.MimeType)
+		return encoder, err
+	})
 
 	sample := newAudioSampler(selectedCodec.ClockRate, selectedCodec.Latency)
 
 	return &encodedReadCloserImpl{
 		readFn: func() (EncodedBuffer, func(), error) {
 			data, release, err := encodedReader.Read()
-			buffer := EncodedBuffer{
-				Data:    data,
-				Samples: sample(),
+			var buffer EncodedBuffer
+			if err != nil || len(data) == 0 {
+				buffer.Data = data
+				return buffer, release, err
 			}
-			return buffer, release, err
+
+			// The encoded data is the oldest samples in the encoder, whose number is given by the latency
+			// of the codec
+			n := int(math.Round(selectedCodec.Latency.Seconds() * float64(encoderProp.SampleRate)))
+			in, ok := inputs.popSamples(n)
+			if !ok {
+				in = queuedInput{timestamp: audio.Timestamp(reader), inputTime: time.Now()}
+			}
+			now := time.Now()
+			track.stats.onEncode(len(data), isKeyFrame(selectedCodec.MimeType, data), now.Sub(in.inputTime), now)
+			buffer = EncodedBuffer{
+				Data:      data,
+				Samples:   sample(in.timestamp),
+				Timestamp: in.timestamp,
+			}
+			return buffer, release, nil
+		},
+		closeFn: encodedReader.Close,
+		controllerFn: func() codec.EncoderController {
+			return track.wrapController(encodedReader.Controller())
 		},
-		closeFn:      encodedReader.Close,
-		controllerFn: encodedReader.Controller,
 	}, selectedCodec, nil
 }
 
@@ -563,7 +905,7 @@ func (track *AudioTrack) NewRTPReader(codecName string, ssrc uint32, mtu int) (R
 			}
 			defer release()
 
-			pkts := packetizer.Packetize(encoded.Data, encoded.Samples)
+			pkts := packetize(packetizer, encoded)
 			return pkts, release, err
 		},
 		closeFn:      encodedReader.Close,
@@ -2,12 +2,16 @@ package mediadevices
 
 import (
 	"errors"
+	"image"
 	"io"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/mediadevices/pkg/io/video"
+	"github.com/pion/mediadevices/pkg/prop"
 	"github.com/pion/webrtc/v3"
 )
 
@@ -137,7 +141,7 @@ func TestRtcpHandler(t *testing.T) {
 		stop := make(chan struct{}, 1)
 		stopped := make(chan struct{})
 		go func() {
-			tr.rtcpReadLoop(&fakeRTCPReader{end: stop}, &fakeKeyFrameController{}, stop)
+			tr.rtcpReadLoop(&fakeRTCPReader{end: stop}, &fakeKeyFrameController{}, nil, stop)
 			stopped <- struct{}{}
 		}()
 
@@ -187,7 +191,7 @@ func TestRtcpHandler(t *testing.T) {
 				mockKeyFrameController := &fakeKeyFrameController{called: make(chan struct{}, 1)}
 				mockRTCPReader := &fakeRTCPReader{end: stop, mockReturn: make(chan []byte, 1)}
 
-				go tr.rtcpReadLoop(mockRTCPReader, mockKeyFrameController, stop)
+				go tr.rtcpReadLoop(mockRTCPReader, mockKeyFrameController, nil, stop)
 
 				mockRTCPReader.mockReturn <- packet
 
@@ -200,3 +204,180 @@ func TestRtcpHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestVideoSampler(t *testing.T) {
+	sample := newVideoSampler(90000)
+	start := time.Now()
+
+	if samples := sample(start); samples != 0 {
+		t.Errorf("Expected the first sample to have 0 duration, got %d", samples)
+	}
+	// The duration should come from the capture time rather than the time when the sample is read
+	if samples := sample(start.Add(100 * time.Millisecond)); samples != 9000 {
+		t.Errorf("Expected 9000 samples, got %d", samples)
+	}
+	if samples := sample(start.Add(50 * time.Millisecond)); samples != 0 {
+		t.Errorf("Expected 0 samples when the capture time goes backwards, got %d", samples)
+	}
+	if samples := sample(start.Add(150 * time.Millisecond)); samples != 9000 {
+		t.Errorf("Expected 9000 samples, got %d", samples)
+	}
+}
+
+func TestAudioSampler(t *testing.T) {
+	sample := newAudioSampler(48000, 20*time.Millisecond)
+	start := time.Now()
+
+	if samples := sample(start); samples != 960 {
+		t.Errorf("Expected 960 samples, got %d", samples)
+	}
+	// The jitter of the capture time shouldn't affect the duration
+	if samples := sample(start.Add(25 * time.Millisecond)); samples != 960 {
+		t.Errorf("Expected 960 samples against the jitter, got %d", samples)
+	}
+	// 60ms of samples have been dropped after the second sample, which was expected at 40ms
+	if samples := sample(start.Add(100 * time.Millisecond)); samples != 960+2880 {
+		t.Errorf("Expected the timestamp to skip the dropped samples, got %d", samples)
+	}
+	if samples := sample(start.Add(120 * time.Millisecond)); samples != 960 {
+		t.Errorf("Expected 960 samples, got %d", samples)
+	}
+	if samples := sample(time.Time{}); samples != 960 {
+		t.Errorf("Expected 960 samples without the capture time, got %d", samples)
+	}
+}
+
+// timestampedVideoSource captures a frame every 100ms from start
+type timestampedVideoSource struct {
+	fakeVideoSource
+	start  time.Time
+	frames int
+}
+
+func (source *timestampedVideoSource) Read() (image.Image, func(), error) {
+	source.frames++
+	return source.fakeVideoSource.Read()
+}
+
+func (source *timestampedVideoSource) Timestamp() time.Time {
+	return source.start.Add(time.Duration(source.frames-1) * 100 * time.Millisecond)
+}
+
+type laggingEncoderBuilder struct{}
+
+func (b *laggingEncoderBuilder) RTPCodec() *codec.RTPCodec {
+	return codec.NewRTPVP8Codec(90000)
+}
+
+func (b *laggingEncoderBuilder) BuildVideoEncoder(r video.Reader, p prop.Media) (codec.ReadCloser, error) {
+	return &laggingEncoder{r: r}, nil
+}
+
+// laggingEncoder buffers 2 frames before it outputs the first one, e.g. like x264 lookahead. The
+// output is the number of the frame that it belongs to.
+type laggingEncoder struct {
+	r      video.Reader
+	frames int
+}
+
+func (e *laggingEncoder) Read() ([]byte, func(), error) {
+	if _, _, err := e.r.Read(); err != nil {
+		return nil, func() {}, err
+	}
+	e.frames++
+	if e.frames <= 2 {
+		return []byte{}, func() {}, nil
+	}
+	return []byte{byte(e.frames - 3)}, func() {}, nil
+}
+
+func (e *laggingEncoder) Close() error                        { return nil }
+func (e *laggingEncoder) Controller() codec.EncoderController { return nil }
+
+func TestEncodedTimestampWithLaggingEncoder(t *testing.T) {
+	source := &timestampedVideoSource{fakeVideoSource: fakeVideoSource{width: 320, height: 240}, start: time.Now()}
+	selector := NewCodecSelector(WithVideoEncoders(&laggingEncoderBuilder{}))
+	track := NewVideoTrack(source, selector).(*VideoTrack)
+	defer track.Close()
+
+	reader, err := track.NewEncodedReader(webrtc.MimeTypeVP8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	for i := 0; i < 6; i++ {
+		buffer, _, err := reader.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(buffer.Data) == 0 {
+			if !buffer.Timestamp.IsZero() || buffer.Samples != 0 {
+				t.Errorf("Expected no timestamp while the encoder is buffering, got %v", buffer)
+			}
+			continue
+		}
+
+		// The capture time should be the one of the frame that has been encoded, which is 2 frames
+		// before the latest one
+		expected := source.Timestamp().Add(-200 * time.Millisecond)
+		if !buffer.Timestamp.Equal(expected) {
+			t.Errorf("Expected the frame %d to be captured at %v, got %v", buffer.Data[0], expected, buffer.Timestamp)
+		}
+		if buffer.Data[0] > 0 && buffer.Samples != 9000 {
+			t.Errorf("Expected 9000 samples between the frames, got %d", buffer.Samples)
+		}
+	}
+}
+
+// gappedVideoSource captures the frames at the given offsets from start
+type gappedVideoSource struct {
+	fakeVideoSource
+	start   time.Time
+	offsets []time.Duration
+	frames  int
+}
+
+func (source *gappedVideoSource) Read() (image.Image, func(), error) {
+	source.frames++
+	return source.fakeVideoSource.Read()
+}
+
+func (source *gappedVideoSource) Timestamp() time.Time {
+	return source.start.Add(source.offsets[source.frames-1])
+}
+
+func TestRTPTimestampCaptureGap(t *testing.T) {
+	// The third frame is captured 200ms after the second one, e.g. the source has dropped a frame
+	source := &gappedVideoSource{
+		fakeVideoSource: fakeVideoSource{width: 320, height: 240},
+		start:           time.Now(),
+		offsets:         []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond, 400 * time.Millisecond},
+	}
+	selector := NewCodecSelector(WithVideoEncoders(&fakeVP8EncoderBuilder{}))
+	track := NewVideoTrack(source, selector).(*VideoTrack)
+	defer track.Close()
+
+	reader, err := track.NewRTPReader(webrtc.MimeTypeVP8, 1, 1200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var timestamps []uint32
+	for range source.offsets {
+		pkts, _, err := reader.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		timestamps = append(timestamps, pkts[0].Timestamp)
+	}
+
+	// The gap should be between the packets of the frames that it's between, rather than be shifted
+	// to the next frame
+	for i, expected := range []uint32{9000, 18000, 9000} {
+		if d := timestamps[i+1] - timestamps[i]; d != expected {
+			t.Errorf("Expected the frame %d to be %d samples after the previous one, got %d", i+1, expected, d)
+		}
+	}
+}